@@ -5,9 +5,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/rasulov-emirlan/accounter-backend/config"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/notifications"
+	"github.com/rasulov-emirlan/accounter-backend/internal/storage/memory"
 	"github.com/rasulov-emirlan/accounter-backend/internal/storage/postgresql"
 	"github.com/rasulov-emirlan/accounter-backend/internal/transport/httprest"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
@@ -40,16 +45,73 @@ func main() {
 	cleaner.Add(repo.Close)
 	log.Info("repositories initialized")
 
+	bypass, err := repo.BypassesTenancy(ctx)
+	if err != nil {
+		log.Fatal("could not check database role", logging.Error("err", err))
+	}
+	if bypass && !cfg.Flags.DevMode {
+		log.Fatal("database role bypasses row level security, connect as a member of accounter_app")
+	}
+	if bypass {
+		log.Warn("database role bypasses row level security, tenants are not isolated")
+	}
+
 	commDeps := domains.CommonDependencies{Log: log, Val: validation.GetValidator()}
-	authDeps := domains.AuthDependencies{OwnersRepo: repo.Owners(), SecretKey: []byte(cfg.JWTsecret)}
-	storesDeps := domains.StoresDependencies{StoresRepo: repo.Stores()}
-	categoriesDeps := domains.CategoriesDependencies{CategoriesRepo: repo.Categories()}
-	doms, err := domains.NewDomainCombiner(commDeps, authDeps, storesDeps, categoriesDeps)
+	authDeps := domains.AuthDependencies{OwnersRepo: repo.Owners(), SellersRepo: repo.Sellers(), TwoFARepo: repo.TwoFactor(), APIKeysRepo: repo.APIKeys(), KV: repo.KeyValue()}
+	authDeps.Keys, err = loadKeyring(cfg)
+	if err != nil {
+		log.Fatal("could not load jwt keys", logging.Error("err", err))
+	}
+	usesSecret := cfg.JWTPrivateKeyFile == "" || cfg.JWTAcceptSecret
+	if usesSecret && cfg.JWTsecret == config.DevJWTSecret && !cfg.Flags.DevMode {
+		log.Fatal("tokens would be signed with the default jwt secret, set JWT_SECRET or JWT_PRIVATE_KEY_FILE")
+	}
+	// JWT_SECRET may be left default with key files, cursors still derive their key from it
+	if cfg.CursorSecret == "" && cfg.JWTsecret == config.DevJWTSecret && !cfg.Flags.DevMode {
+		log.Fatal("cursors would be signed with a key derived from the default jwt secret, set CURSOR_SECRET or JWT_SECRET")
+	}
+	authDeps.HashCost = cfg.Auth.BcryptCost
+	authDeps.LoginLimits = auth.LoginLimits{
+		FreeAttempts:   cfg.Auth.LoginFreeAttempts,
+		IPFreeAttempts: cfg.Auth.LoginIPFreeAttempts,
+		BackoffBase:    cfg.Auth.LoginBackoffBase,
+		MaxLockout:     cfg.Auth.LoginMaxLockout,
+		Window:         cfg.Auth.LoginAttemptsWindow,
+	}
+	if cfg.SessionStore == "memory" {
+		authDeps.KV = memory.NewKeyValue()
+	}
+	// there is no real sms provider yet, so codes are only printed or logged
+	switch {
+	case cfg.Notifier == "stdout" || cfg.Notifier == "" && cfg.Flags.DevMode:
+		authDeps.Notifier = notifications.NewStdout()
+	case cfg.Notifier == "log":
+		authDeps.Notifier = notifications.NewLog(log)
+	}
+	storesDeps := domains.StoresDependencies{StoresRepo: repo.Stores(), UnitOfWork: repo.UnitOfWork()}
+	categoriesDeps := domains.CategoriesDependencies{CategoriesRepo: repo.Categories(), UnitOfWork: repo.UnitOfWork()}
+	itemsDeps := domains.ItemsDependencies{ItemsRepo: repo.Items(), UnitOfWork: repo.UnitOfWork()}
+	stockDeps := domains.StockDependencies{StockRepo: repo.Stock(), UnitOfWork: repo.UnitOfWork()}
+	warehousesDeps := domains.WarehousesDependencies{WarehousesRepo: repo.Warehouses(), UnitOfWork: repo.UnitOfWork()}
+	transfersDeps := domains.TransfersDependencies{TransfersRepo: repo.Transfers(), UnitOfWork: repo.UnitOfWork()}
+	salesDeps := domains.SalesDependencies{SalesRepo: repo.Sales(), UnitOfWork: repo.UnitOfWork()}
+	sellersDeps := domains.SellersDependencies{SellersRepo: repo.Sellers(), UnitOfWork: repo.UnitOfWork()}
+	policiesDeps := domains.PoliciesDependencies{ResourcesRepo: repo.Policies()}
+	auditDeps := domains.AuditDependencies{AuditRepo: repo.Audit()}
+	doms, err := domains.NewDomainCombiner(
+		commDeps, authDeps, storesDeps, categoriesDeps, itemsDeps,
+		stockDeps, warehousesDeps, transfersDeps, salesDeps, sellersDeps,
+		policiesDeps, auditDeps,
+	)
 	if err != nil {
 		log.Fatal("could not init domains", logging.Error("err", err))
 	}
 	log.Info("domains initialized")
 
+	if cfg.Trash.Retention > 0 {
+		go purgeTrash(ctx, doms, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	}
+
 	srvr := httprest.NewServer(cfg)
 	cleaner.Add(srvr.Stop)
 	go func() {
@@ -74,3 +136,45 @@ func main() {
 		log.Fatal("cleaner close", logging.Error("err", err))
 	}
 }
+
+func loadKeyring(cfg config.Config) (auth.Keyring, error) {
+	if cfg.JWTPrivateKeyFile == "" {
+		key, err := auth.NewSecretKey([]byte(cfg.JWTsecret))
+		if err != nil {
+			return auth.Keyring{}, err
+		}
+		return auth.NewKeyring(key)
+	}
+
+	keys, err := auth.LoadKeyring(cfg.JWTPrivateKeyFile, cfg.JWTPublicKeyFiles...)
+	if err != nil {
+		return auth.Keyring{}, err
+	}
+	if cfg.JWTAcceptSecret {
+		return keys.WithSecret([]byte(cfg.JWTsecret))
+	}
+	return keys, nil
+}
+
+// purgeTrash removes stores and categories that were deleted longer than
+// retention ago, every interval until ctx is done. Services log failures.
+func purgeTrash(ctx context.Context, doms domains.DomainCombiner, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// trash of every owner is purged at once
+	ctx = tenancy.WithSystem(ctx, "trash purge")
+
+	for {
+		deletedBefore := time.Now().UTC().Add(-retention)
+		// stores are purged only after their categories are gone
+		_, _ = doms.CategoriesService().Purge(ctx, deletedBefore)
+		_, _ = doms.StoresService().Purge(ctx, deletedBefore)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
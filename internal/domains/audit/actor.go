@@ -0,0 +1,29 @@
+package audit
+
+import "context"
+
+// Actor is who makes the request. Transport puts it into context, so
+// domains do not have to pass it through every call.
+type Actor struct {
+	ID        string
+	Role      string
+	OwnerID   string // owner whose data actor works with
+	APIKeyID  string
+	RequestID string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns actor of ctx, requests without one are made by the
+// system itself.
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok {
+		return Actor{ID: RoleSystem, Role: RoleSystem}
+	}
+	return actor
+}
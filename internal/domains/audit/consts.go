@@ -0,0 +1,31 @@
+package audit
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/audit/"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionLink    = "link"
+	ActionUnlink  = "unlink"
+
+	EntityStore     = "store"
+	EntityCategory  = "category"
+	EntityItem      = "item"
+	EntitySize      = "size"
+	EntityWarehouse = "warehouse"
+	EntitySeller    = "seller"
+	EntityTransfer  = "transfer"
+	EntityReceipt   = "receipt"
+	EntityReturn    = "return"
+
+	RoleSystem = "system"
+)
+
+var (
+	ErrNoOwner = apperr.New(apperr.KindInternal, "audit.noOwner", "не удалось определить владельца для журнала изменений")
+	ErrDefault = apperr.New(apperr.KindInternal, "audit.default", "что-то пошло не так")
+)
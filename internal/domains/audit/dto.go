@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type (
+	// Change is what a domain reports after mutating an entity. Before
+	// and After hold fields worth auditing, Before is nil for creations
+	// and After is nil for deletions.
+	Change struct {
+		// OwnerID may be empty, then owner of the actor is used.
+		OwnerID    string
+		Action     string
+		EntityType string
+		EntityID   string
+		Before     map[string]any
+		After      map[string]any
+	}
+
+	ReadByInput struct {
+		OwnerID    string                       `json:"ownerID" validate:"required"`
+		ActorID    entities.OptField[string]    `json:"actorID"`
+		Action     entities.OptField[string]    `json:"action"`
+		EntityType entities.OptField[string]    `json:"entityType"`
+		EntityID   entities.OptField[string]    `json:"entityID"`
+		From       entities.OptField[time.Time] `json:"from"`
+		To         entities.OptField[time.Time] `json:"to"`
+
+		// Pagination, newest entries go first
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+	}
+)
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	// AuditRepository only appends, entries are never updated or deleted.
+	AuditRepository interface {
+		Append(ctx context.Context, entry entities.AuditEntry) error
+		ReadBy(ctx context.Context, filter ReadByInput) ([]entities.AuditEntry, error)
+		// Count counts entries matching filters, pagination is ignored.
+		Count(ctx context.Context, filter ReadByInput) (uint64, error)
+	}
+
+	// Recorder is what other domains depend on. Record should run in the
+	// same unit of work as the change, so that a change is never left
+	// without its entry.
+	Recorder interface {
+		Record(ctx context.Context, change Change) error
+	}
+
+	Service interface {
+		Recorder
+		ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.AuditEntry], error)
+	}
+
+	service struct {
+		repo AuditRepository
+		log  *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo AuditRepository, log *logging.Logger) service {
+	return service{repo: repo, log: log}
+}
+
+func (s service) Record(ctx context.Context, change Change) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Record")).End()
+	defer s.log.Sync()
+
+	actor := ActorFrom(ctx)
+	ownerID := change.OwnerID
+	if ownerID == "" {
+		ownerID = actor.OwnerID
+	}
+	owner, err := uuid.Parse(ownerID)
+	if err != nil {
+		s.log.Error("audit:Record - no owner for entry", logging.String("stage", "validation"), logging.String("entityType", change.EntityType), logging.String("entityID", change.EntityID))
+		return ErrNoOwner
+	}
+
+	changes, err := diff(change.Before, change.After)
+	if err != nil {
+		s.log.Error("audit:Record - failed to build diff", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	entry := entities.AuditEntry{
+		ID:         uuid.New(),
+		OwnerID:    owner,
+		ActorID:    actor.ID,
+		ActorRole:  actor.Role,
+		APIKeyID:   actor.APIKeyID,
+		Action:     change.Action,
+		EntityType: change.EntityType,
+		EntityID:   change.EntityID,
+		Changes:    changes,
+		RequestID:  actor.RequestID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.repo.Append(ctx, entry); err != nil {
+		s.log.Error("audit:Record - failed to append entry", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Debug("audit:Record - entry appended", logging.String("stage", "repository"), logging.String("action", entry.Action), logging.String("entityType", entry.EntityType), logging.String("entityID", entry.EntityID))
+	return nil
+}
+
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// diff keeps only fields that differ between before and after.
+func diff(before, after map[string]any) (json.RawMessage, error) {
+	changes := make(map[string]fieldChange)
+	for field, value := range after {
+		old, ok := before[field]
+		if !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = fieldChange{Before: old, After: value}
+		}
+	}
+	for field, value := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = fieldChange{Before: value}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+func (s service) ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.AuditEntry], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	// validate filters
+	if _, err := uuid.Parse(filter.OwnerID); err != nil {
+		s.log.Debug("audit:ReadBy - failed to parse owner id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Page[entities.AuditEntry]{}, apperr.NewField(apperr.KindInvalid, "ownerID", "audit.ownerIDInvalid", "id владельца не валиден")
+	}
+
+	pageNumber, ok := filter.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filter.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("audit:ReadBy - invalid page number", logging.String("stage", "validation"), logging.Uint64("pageNumber", pageNumber))
+		return entities.Page[entities.AuditEntry]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "audit.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filter.PageSize.Get()
+	if !ok {
+		pageSize = 50
+		filter.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("audit:ReadBy - invalid page size", logging.String("stage", "validation"), logging.Uint("pageSize", pageSize))
+		return entities.Page[entities.AuditEntry]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "audit.pageSizeOutOfRange", "размер страницы должен быть в диапазоне от 1 до 100")
+	}
+
+	action, ok := filter.Action.Get()
+	if ok {
+		switch action {
+		case ActionCreate, ActionUpdate, ActionDelete, ActionRestore, ActionLink, ActionUnlink:
+		default:
+			s.log.Debug("audit:ReadBy - invalid action", logging.String("stage", "validation"), logging.String("action", action))
+			return entities.Page[entities.AuditEntry]{}, apperr.NewField(apperr.KindInvalid, "action", "audit.actionUnknown", "действие должно быть одним из create, update, delete, restore, link, unlink")
+		}
+	}
+
+	from, okFrom := filter.From.Get()
+	to, okTo := filter.To.Get()
+	if okFrom && okTo && to.Before(from) {
+		s.log.Debug("audit:ReadBy - invalid period", logging.String("stage", "validation"))
+		return entities.Page[entities.AuditEntry]{}, apperr.NewField(apperr.KindInvalid, "to", "audit.periodReversed", "конец периода не может быть раньше начала")
+	}
+
+	entries, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		s.log.Error("audit:ReadBy - failed to read entries", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.AuditEntry]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		s.log.Error("audit:ReadBy - failed to count entries", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.AuditEntry]{}, ErrDefault
+	}
+
+	s.log.Info("audit:ReadBy - entries read", logging.String("stage", "repository"), logging.Int("count", len(entries)))
+	return entities.NumberedPage(entries, pageNumber, pageSize, total), nil
+}
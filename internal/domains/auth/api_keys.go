@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+func (s service) CreateAPIKey(ctx context.Context, accessKey AccessKey, input CreateAPIKeyInput) (CreatedAPIKey, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.CreateAPIKey")).End()
+	defer s.log.Sync()
+
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		s.log.Debug("auth:CreateAPIKey - unknown scope", logging.String("stage", "validation"), logging.Any("scopes", input.Scopes))
+		return CreatedAPIKey{}, err
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		s.log.Error("auth:CreateAPIKey - failed to generate key", logging.String("stage", "token"), logging.Error("err", err))
+		return CreatedAPIKey{}, ErrDefault
+	}
+	key := APIKeyPrefix + secret
+
+	ownerID, err := uuid.Parse(accessKey.UserID)
+	if err != nil {
+		s.log.Error("auth:CreateAPIKey - invalid user id", logging.String("stage", "validation"), logging.Error("err", err))
+		return CreatedAPIKey{}, ErrDefault
+	}
+
+	apiKey := entities.NewAPIKey(&entities.Owner{ID: ownerID}, input.Name, key[:len(APIKeyPrefix)+6], hashCode(key), scopes)
+	if err := s.val.Validate(apiKey); err != nil {
+		s.log.Debug("auth:CreateAPIKey - failed to validate key", logging.String("stage", "validation"), logging.Error("err", err))
+		return CreatedAPIKey{}, err
+	}
+
+	if err := s.apiKeysRepo.Create(ctx, apiKey); err != nil {
+		s.log.Error("auth:CreateAPIKey - failed to create key", logging.String("stage", "repository"), logging.Error("err", err))
+		return CreatedAPIKey{}, ErrDefault
+	}
+
+	s.log.Info("auth:CreateAPIKey - key created", logging.String("stage", "success"), logging.String("keyID", apiKey.ID.String()), logging.String("userID", accessKey.UserID))
+	return CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (s service) ReadAPIKeys(ctx context.Context, accessKey AccessKey) ([]entities.APIKey, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadAPIKeys")).End()
+	defer s.log.Sync()
+
+	keys, err := s.apiKeysRepo.ReadByOwner(ctx, accessKey.UserID)
+	if err != nil {
+		s.log.Error("auth:ReadAPIKeys - failed to read keys", logging.String("stage", "repository"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	return keys, nil
+}
+
+func (s service) RevokeAPIKey(ctx context.Context, accessKey AccessKey, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.RevokeAPIKey")).End()
+	defer s.log.Sync()
+
+	if err := s.apiKeysRepo.Revoke(ctx, accessKey.UserID, id); err != nil {
+		if err == ErrAPIKeyNotFound {
+			s.log.Debug("auth:RevokeAPIKey - key not found", logging.String("stage", "repository"), logging.String("keyID", id))
+			return err
+		}
+		s.log.Error("auth:RevokeAPIKey - failed to revoke key", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:RevokeAPIKey - key revoked", logging.String("stage", "success"), logging.String("keyID", id), logging.String("userID", accessKey.UserID))
+	return nil
+}
+
+func (s service) ParseAPIKey(ctx context.Context, key string) (AccessKey, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ParseAPIKey")).End()
+
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return AccessKey{}, ErrInvalidAPIKey
+	}
+
+	// owner of the key is not known yet
+	ctx = tenancy.WithSystem(ctx, "api key")
+	apiKey, err := s.apiKeysRepo.ReadByHash(ctx, hashCode(key))
+	if err != nil {
+		if err == ErrInvalidAPIKey {
+			return AccessKey{}, err
+		}
+		s.log.Error("auth:ParseAPIKey - failed to read key", logging.String("stage", "repository"), logging.Error("err", err))
+		return AccessKey{}, ErrDefault
+	}
+	if apiKey.RevokedAt != nil {
+		return AccessKey{}, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > APIKeyTouchInterval {
+		// not being able to record usage must not break the request
+		if err := s.apiKeysRepo.Touch(ctx, apiKey.ID.String(), now); err != nil {
+			s.log.Error("auth:ParseAPIKey - failed to touch key", logging.String("stage", "repository"), logging.Error("err", err))
+		}
+	}
+
+	return AccessKey{
+		UserID:   apiKey.Owner.ID.String(),
+		Role:     RoleOwner,
+		APIKeyID: apiKey.ID.String(),
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+// normalizeScopes checks scopes and removes duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	set := make(map[string]struct{}, len(scopes))
+	for _, scope := range scopes {
+		resource, access, ok := strings.Cut(scope, ":")
+		if !ok || (access != ScopeRead && access != ScopeWrite) || !isScopeResource(resource) {
+			return nil, ErrUnknownScope
+		}
+		set[scope] = struct{}{}
+	}
+
+	normalized := make([]string, 0, len(set))
+	for scope := range set {
+		normalized = append(normalized, scope)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func isScopeResource(resource string) bool {
+	for _, r := range ScopeResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,9 @@
 package auth
 
 import (
-	"errors"
 	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 )
 
 const (
@@ -13,14 +14,73 @@ const (
 
 	AccessKeyTTL  = time.Hour               // 1 hour
 	RefreshKeyTTL = time.Hour * 24 * 30 * 2 // 2 months
+
+	// keys in KeyValueRepository
+	sessionKeyPrefix    = "session:"        // + session id, holds id of the last issued refresh token
+	generationKeyPrefix = "generation:"     // + user id, holds sessions generation of the user
+	resetKeyPrefix      = "reset:"          // + hash of reset token, holds owner id
+	loginFailuresPrefix = "login-failures:" // + user:<username> or ip:<ip>, holds number of failures, see loginLimiter
+	loginLockPrefix     = "login-lock:"     // + same as login-failures, holds unix time login is locked until
+	challengeKeyPrefix  = "2fa-challenge:"  // + hash of challenge token, holds <owner id>:<attempts>
+	totpStepKeyPrefix   = "2fa-step:"       // + owner id, holds last used totp time step
+
+	SellerLoginRequestTTL  = time.Minute * 10
+	SellerLoginMaxAttempts = 5
+
+	PasswordResetTTL = time.Minute * 15
+
+	TwoFactorChallengeTTL  = time.Minute * 5
+	TwoFactorMaxAttempts   = 5
+	TwoFactorRecoveryCodes = 10
+
+	// APIKeyPrefix starts every api key, so leaked keys are easy to find.
+	APIKeyPrefix = "acc_"
+	// LastUsedAt of api keys is updated not more often than this.
+	APIKeyTouchInterval = time.Minute
+)
+
+// Resources api keys may be scoped to, scope is "<resource>:read" or
+// "<resource>:write". Write does not include read.
+var ScopeResources = []string{
+	"stores", "categories", "items", "stock", "warehouses", "transfers", "sales", "sellers", "audit",
+}
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
 )
 
 var (
-	ErrUsernameTaken       = errors.New("это имя пользователя уже занято")
-	ErrUsernameNotFound    = errors.New("пользователь с таким именем не найден")
-	ErrIdNotFound          = errors.New("пользователь с таким id не найден")
-	ErrWrongPassword       = errors.New("неверный пароль")
-	ErrInvalidRefreshToken = errors.New("инвалидный токен для обновления сессии")
-	ErrInvalidAccessToken  = errors.New("инвалидный токен доступа")
-	ErrDefault             = errors.New("что-то пошло не так")
+	ErrUsernameTaken        = apperr.New(apperr.KindConflict, "auth.usernameTaken", "это имя пользователя уже занято")
+	ErrUsernameNotFound     = apperr.New(apperr.KindUnauthorized, "auth.usernameNotFound", "пользователь с таким именем не найден")
+	ErrIdNotFound           = apperr.New(apperr.KindNotFound, "auth.idNotFound", "пользователь с таким id не найден")
+	ErrWrongPassword        = apperr.New(apperr.KindUnauthorized, "auth.wrongPassword", "неверный пароль")
+	ErrInvalidCredentials   = apperr.New(apperr.KindUnauthorized, "auth.invalidCredentials", "неверное имя пользователя или пароль")
+	ErrUsernameTooShort     = apperr.New(apperr.KindValidation, "auth.usernameTooShort", "имя пользователя не может содержать менее 6 символов")
+	ErrInvalidRefreshToken  = apperr.New(apperr.KindUnauthorized, "auth.invalidRefreshToken", "инвалидный токен для обновления сессии")
+	ErrInvalidAccessToken   = apperr.New(apperr.KindUnauthorized, "auth.invalidAccessToken", "инвалидный токен доступа")
+	ErrAccessTokenExpired   = apperr.New(apperr.KindUnauthorized, "auth.accessTokenExpired", "срок действия токена доступа истёк")
+	ErrSellerNotFound       = apperr.New(apperr.KindNotFound, "auth.sellerNotFound", "продавец с таким именем не найден")
+	ErrSellerInactive       = apperr.New(apperr.KindForbidden, "auth.sellerInactive", "продавец деактивирован владельцем")
+	ErrLoginRequestNotFound = apperr.New(apperr.KindNotFound, "auth.loginRequestNotFound", "запрос на вход не найден")
+	ErrLoginRequestExpired  = apperr.New(apperr.KindGone, "auth.loginRequestExpired", "запрос на вход истёк, запросите вход заново")
+	ErrLoginNotApproved     = apperr.New(apperr.KindConflict, "auth.loginNotApproved", "вход ещё не подтверждён")
+	ErrLoginRejected        = apperr.New(apperr.KindForbidden, "auth.loginRejected", "владелец отклонил вход")
+	ErrWrongCode            = apperr.New(apperr.KindUnauthorized, "auth.wrongCode", "неверный код")
+	ErrTooManyAttempts      = apperr.New(apperr.KindGone, "auth.tooManyAttempts", "слишком много попыток, запросите вход заново")
+	ErrSessionRevoked       = apperr.New(apperr.KindUnauthorized, "auth.sessionRevoked", "сессия завершена, войдите заново")
+	ErrRefreshTokenReused   = apperr.New(apperr.KindUnauthorized, "auth.refreshTokenReused", "токен обновления уже был использован, сессия завершена")
+	ErrKeyNotFound          = apperr.New(apperr.KindUnauthorized, "auth.keyNotFound", "ключ не найден")
+	ErrInvalidResetToken    = apperr.New(apperr.KindInvalid, "auth.invalidResetToken", "токен для сброса пароля недействителен или истёк")
+	ErrResetUnavailable     = apperr.New(apperr.KindUnavailable, "auth.resetUnavailable", "сброс пароля сейчас недоступен")
+	ErrLoginLocked          = apperr.New(apperr.KindTooManyRequests, "auth.loginLocked", "слишком много неудачных попыток входа, попробуйте позже")
+	ErrTwoFactorEnabled     = apperr.New(apperr.KindConflict, "auth.twoFactorEnabled", "двухфакторная аутентификация уже включена")
+	ErrTwoFactorNotEnrolled = apperr.New(apperr.KindConflict, "auth.twoFactorNotEnrolled", "сначала начните настройку двухфакторной аутентификации")
+	ErrTwoFactorDisabled    = apperr.New(apperr.KindConflict, "auth.twoFactorDisabled", "двухфакторная аутентификация не включена")
+	ErrInvalidChallenge     = apperr.New(apperr.KindUnauthorized, "auth.invalidChallenge", "время на подтверждение входа истекло, войдите заново")
+	ErrInvalidAPIKey        = apperr.New(apperr.KindUnauthorized, "auth.invalidAPIKey", "инвалидный ключ API")
+	ErrAPIKeyNotFound       = apperr.New(apperr.KindNotFound, "auth.apiKeyNotFound", "ключ API не найден")
+	ErrUnknownScope         = apperr.New(apperr.KindValidation, "auth.unknownScope", "неизвестная область доступа")
+	ErrScopeNotAllowed      = apperr.New(apperr.KindForbidden, "auth.scopeNotAllowed", "ключ API не даёт доступа к этому действию")
+	ErrDefault              = apperr.New(apperr.KindInternal, "auth.default", "что-то пошло не так")
 )
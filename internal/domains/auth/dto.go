@@ -1,6 +1,11 @@
 package auth
 
-import "github.com/golang-jwt/jwt"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
 
 type (
 	RegisterInput struct {
@@ -13,25 +18,160 @@ type (
 	LoginInput struct {
 		Username string `json:"username" validate:"required,min=6,max=500"`
 		Password string `json:"password" validate:"required,min=6,max=500"`
+		IP       string `json:"-"` // set by transport, used to limit attempts
+	}
+
+	// LoginLimits configures brute-force protection of Login. After free
+	// attempts every failure locks logins for twice as long as the
+	// previous one, starting at BackoffBase and up to MaxLockout.
+	// Failures are forgotten after Window without new ones.
+	LoginLimits struct {
+		FreeAttempts   int // per username
+		IPFreeAttempts int // per client ip
+		BackoffBase    time.Duration
+		MaxLockout     time.Duration
+		Window         time.Duration
 	}
 
 	RequestSellerLoginInput struct {
 		Username string `json:"username" validate:"required,min=6,max=500"`
+		IP       string `json:"-"` // set by transport, used to limit requests
+	}
+
+	// CompleteSellerLoginInput finishes a login request. Code may be
+	// omitted when the seller waits for the owner's approval.
+	CompleteSellerLoginInput struct {
+		RequestID string `json:"requestID" validate:"required,uuid4"`
+		Code      string `json:"code" validate:"omitempty,len=6,numeric"`
+	}
+
+	UpdateProfileInput struct {
+		FullName    entities.OptField[string] `json:"fullName"`
+		Username    entities.OptField[string] `json:"username"`
+		PhoneNumber entities.OptField[string] `json:"phoneNumber"`
+	}
+
+	// DeleteAccountInput re-authenticates the owner, code is required
+	// only with 2FA on. With Anonymize business records such as the
+	// stock journal and receipts are kept without personal data.
+	DeleteAccountInput struct {
+		Password     string `json:"password" validate:"required,max=500"`
+		Code         string `json:"code" validate:"omitempty,len=6,numeric"`
+		RecoveryCode string `json:"recoveryCode" validate:"omitempty,max=20"`
+		Anonymize    bool   `json:"anonymize"`
+	}
+
+	ChangePasswordInput struct {
+		CurrentPassword string `json:"currentPassword" validate:"required,max=500"`
+		NewPassword     string `json:"newPassword" validate:"required,min=6,max=500"`
+	}
+
+	RequestPasswordResetInput struct {
+		Username string `json:"username" validate:"required,min=6,max=500"`
+	}
+
+	ResetPasswordInput struct {
+		Token       string `json:"token" validate:"required,hexadecimal,max=64"`
+		NewPassword string `json:"newPassword" validate:"required,min=6,max=500"`
+	}
+
+	// TwoFactorLoginInput finishes login of an owner with 2FA, either
+	// Code from the authenticator app or one of RecoveryCodes is required.
+	TwoFactorLoginInput struct {
+		ChallengeToken string `json:"challengeToken" validate:"required,hexadecimal,max=64"`
+		Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+		RecoveryCode   string `json:"recoveryCode" validate:"required_without=Code,omitempty,max=20"`
+		IP             string `json:"-"` // set by transport, used to limit attempts
 	}
 
+	TwoFactorCodeInput struct {
+		Code string `json:"code" validate:"required,len=6,numeric"`
+	}
+
+	DisableTwoFactorInput struct {
+		Password     string `json:"password" validate:"required,max=500"`
+		Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+		RecoveryCode string `json:"recoveryCode" validate:"required_without=Code,omitempty,max=20"`
+	}
+
+	CreateAPIKeyInput struct {
+		Name   string   `json:"name" validate:"required,max=100"`
+		Scopes []string `json:"scopes" validate:"required,min=1,dive,required"`
+	}
+
+	// CreatedAPIKey has the key itself, it is shown only once.
+	CreatedAPIKey struct {
+		entities.APIKey
+		Key string `json:"key"`
+	}
+
+	// TwoFactorEnrollment is shown once, URI is meant for a QR code.
+	TwoFactorEnrollment struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+
+	// TwoFactorSettings of an owner, Secret is set before enrollment is
+	// confirmed and Enabled after.
+	TwoFactorSettings struct {
+		Secret  string
+		Enabled bool
+	}
+
+	// Session has only ChallengeToken when login needs a second factor,
+	// see Service.LoginTwoFactor.
 	Session struct {
-		AccessToken  string `json:"accessToken"`
-		RefreshToken string `json:"refreshToken"`
+		AccessToken    string `json:"accessToken,omitempty"`
+		RefreshToken   string `json:"refreshToken,omitempty"`
+		ChallengeToken string `json:"challengeToken,omitempty"`
 	}
 
 	AccessKey struct {
-		UserID string `json:"userID"`
-		Role   string `json:"role"` // owner/seller
+		UserID   string   `json:"userID"`
+		Role     string   `json:"role"`               // owner/seller
+		OwnerID  string   `json:"ownerID,omitempty"`  // only for sellers
+		StoreIDs []string `json:"storeIDs,omitempty"` // only for sellers, stores they may act in
+
+		// APIKeyID and Scopes are set only when request is made with an
+		// api key, such requests may do only what Scopes allow.
+		APIKeyID string   `json:"apiKeyID,omitempty"`
+		Scopes   []string `json:"scopes,omitempty"`
+
+		// SessionID is shared by all tokens issued by refreshing one login.
+		SessionID  string `json:"sid"`
+		Generation int    `json:"gen"` // see Service.LogoutAll
 		jwt.StandardClaims
 	}
 
+	// RefreshKey is single use, its StandardClaims.Id is remembered
+	// for the session and changes on every refresh.
 	RefreshKey struct {
-		UserID string `json:"userID"`
+		UserID     string `json:"userID"`
+		Role       string `json:"role,omitempty"` // empty in old tokens means owner
+		SessionID  string `json:"sid"`
+		Generation int    `json:"gen"`
 		jwt.StandardClaims
 	}
 )
+
+// Owner is the id of the owner whose data the key works with.
+func (k AccessKey) Owner() string {
+	if k.Role == RoleOwner {
+		return k.UserID
+	}
+	return k.OwnerID
+}
+
+// Allows reports whether access key may use scope, keys issued by
+// login are not limited by scopes.
+func (k AccessKey) Allows(scope string) bool {
+	if k.APIKeyID == "" {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
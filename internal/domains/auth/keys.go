@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	errUnknownKey     = errors.New("unknown signing key")
+	errUnsupportedKey = errors.New("only RSA and Ed25519 keys are supported")
+	errMethodMismatch = errors.New("token is signed with a different algorithm than its key")
+	errNoSigningKey   = errors.New("keyring has no signing key")
+	errSecretTooShort = errors.New("secret key must be at least 32 bytes long")
+	errNotPEM         = errors.New("file does not contain a PEM block")
+	errDuplicateKeyID = errors.New("keyring already has a key with this id")
+)
+
+// SigningKey is one key of a Keyring. Tokens signed by asymmetric keys
+// carry the key id in the "kid" header.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	private interface{} // nil for keys that only verify
+	public  interface{} // secret itself for HS256
+}
+
+// NewSecretKey returns HS256 key, it has no id since it is never
+// published. It is a fallback for setups without key files.
+func NewSecretKey(secret []byte) (SigningKey, error) {
+	// shorter secrets are weaker than the 256 bit hash they key
+	if len(secret) < 32 {
+		return SigningKey{}, errSecretTooShort
+	}
+	return SigningKey{Method: jwt.SigningMethodHS256, private: secret, public: secret}, nil
+}
+
+// ParsePrivateKey parses PEM encoded RSA (PKCS #1 or #8) or Ed25519
+// (PKCS #8) private key. Key id is the RFC 7638 thumbprint.
+func ParsePrivateKey(data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errNotPEM
+	}
+
+	var key interface{}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return SigningKey{}, err
+		}
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return newAsymmetricKey(jwt.SigningMethodRS256, k, &k.PublicKey)
+	case ed25519.PrivateKey:
+		return newAsymmetricKey(jwt.SigningMethodEdDSA, k, k.Public())
+	}
+	return SigningKey{}, errUnsupportedKey
+}
+
+// ParsePublicKey parses PEM encoded RSA (PKIX or PKCS #1) or Ed25519
+// (PKIX) public key.
+func ParsePublicKey(data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errNotPEM
+	}
+
+	var key interface{}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+			return SigningKey{}, err
+		}
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return newAsymmetricKey(jwt.SigningMethodRS256, nil, k)
+	case ed25519.PublicKey:
+		return newAsymmetricKey(jwt.SigningMethodEdDSA, nil, k)
+	}
+	return SigningKey{}, errUnsupportedKey
+}
+
+func newAsymmetricKey(method jwt.SigningMethod, private interface{}, public crypto.PublicKey) (SigningKey, error) {
+	key := SigningKey{Method: method, private: private, public: public}
+
+	thumbprint, err := json.Marshal(key.jwk(true))
+	if err != nil {
+		return SigningKey{}, err
+	}
+	sum := sha256.Sum256(thumbprint)
+	key.ID = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key, nil
+}
+
+// JWK is a public key in RFC 7517 format.
+type JWK struct {
+	// fields are ordered as RFC 7638 thumbprint requires
+	Crv string `json:"crv,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kty string `json:"kty"`
+	N   string `json:"n,omitempty"`
+	X   string `json:"x,omitempty"`
+
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk returns only required members of the key when thumbprint is set.
+func (k SigningKey) jwk(thumbprint bool) JWK {
+	var j JWK
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		j.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	if !thumbprint {
+		j.Alg = k.Method.Alg()
+		j.Kid = k.ID
+		j.Use = "sig"
+	}
+	return j
+}
+
+// Keyring signs tokens with one key and verifies them with any of its
+// keys. To rotate keys start signing with a new key and keep the old
+// public key for verification until tokens signed by it expire.
+type Keyring struct {
+	signing SigningKey
+	verify  map[string]SigningKey // by key id, secret key has empty id
+}
+
+func NewKeyring(signing SigningKey, verification ...SigningKey) (Keyring, error) {
+	if signing.private == nil {
+		return Keyring{}, errNoSigningKey
+	}
+
+	k := Keyring{signing: signing, verify: map[string]SigningKey{signing.ID: signing}}
+	for _, key := range verification {
+		if _, ok := k.verify[key.ID]; ok {
+			if key.ID == signing.ID {
+				// public half of the signing key is allowed to be listed
+				continue
+			}
+			return Keyring{}, fmt.Errorf("%w: %q", errDuplicateKeyID, key.ID)
+		}
+		k.verify[key.ID] = key
+	}
+	return k, nil
+}
+
+// LoadKeyring reads signing private key and verification public keys
+// from PEM files.
+func LoadKeyring(privateKeyFile string, publicKeyFiles ...string) (Keyring, error) {
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return Keyring{}, err
+	}
+	signing, err := ParsePrivateKey(data)
+	if err != nil {
+		return Keyring{}, fmt.Errorf("%s: %w", privateKeyFile, err)
+	}
+
+	verification := make([]SigningKey, 0, len(publicKeyFiles))
+	for _, file := range publicKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return Keyring{}, err
+		}
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return Keyring{}, fmt.Errorf("%s: %w", file, err)
+		}
+		verification = append(verification, key)
+	}
+
+	return NewKeyring(signing, verification...)
+}
+
+// WithSecret returns keyring that also verifies HS256 tokens signed
+// with secret, so switching from a secret to key files does not log
+// everyone out.
+func (k Keyring) WithSecret(secret []byte) (Keyring, error) {
+	key, err := NewSecretKey(secret)
+	if err != nil {
+		return Keyring{}, err
+	}
+	if _, ok := k.verify[key.ID]; ok {
+		return Keyring{}, errDuplicateKeyID
+	}
+
+	verify := make(map[string]SigningKey, len(k.verify)+1)
+	for id, key := range k.verify {
+		verify[id] = key
+	}
+	verify[key.ID] = key
+	k.verify = verify
+	return k, nil
+}
+
+func (k Keyring) IsZero() bool {
+	return k.signing.private == nil
+}
+
+// JWKS returns public keys of the keyring, secret keys are skipped.
+func (k Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(k.verify))}
+	for _, key := range k.verify {
+		if key.ID == "" {
+			continue
+		}
+		set.Keys = append(set.Keys, key.jwk(false))
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (k Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	if k.signing.ID != "" {
+		token.Header["kid"] = k.signing.ID
+	}
+	return token.SignedString(k.signing.private)
+}
+
+// keyFunc is jwt.Keyfunc, it also makes sure that token can't choose
+// algorithm of its key.
+func (k Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verify[kid]
+	if !ok {
+		return nil, errUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errMethodMismatch
+	}
+	return key.public, nil
+}
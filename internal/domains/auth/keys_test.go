@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newEd25519Key(t *testing.T) (SigningKey, SigningKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return parsePair(t, private, public)
+}
+
+func newRSAKey(t *testing.T) (SigningKey, SigningKey) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return parsePair(t, private, &private.PublicKey)
+}
+
+// parsePair returns private and public keys parsed from PEM, like
+// LoadKeyring reads them.
+func parsePair(t *testing.T, private, public interface{}) (SigningKey, SigningKey) {
+	t.Helper()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+
+	privateKey, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	if err != nil {
+		t.Fatalf("ParsePrivateKey: %v", err)
+	}
+	publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	return privateKey, publicKey
+}
+
+func TestNewSecretKey(t *testing.T) {
+	if _, err := NewSecretKey(testSecret[:31]); err != errSecretTooShort {
+		t.Errorf("NewSecretKey: got %v, want %v", err, errSecretTooShort)
+	}
+	key, err := NewSecretKey(testSecret)
+	if err != nil {
+		t.Fatalf("NewSecretKey: %v", err)
+	}
+	if key.ID != "" || key.Method != jwt.SigningMethodHS256 {
+		t.Errorf("NewSecretKey: got id %q and %s, want no id and HS256", key.ID, key.Method.Alg())
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParsePrivateKey([]byte("not a key")); err != errNotPEM {
+		t.Errorf("ParsePrivateKey: got %v, want %v", err, errNotPEM)
+	}
+	if _, err := ParsePublicKey([]byte("not a key")); err != errNotPEM {
+		t.Errorf("ParsePublicKey: got %v, want %v", err, errNotPEM)
+	}
+
+	for name, newKey := range map[string]func(*testing.T) (SigningKey, SigningKey){"Ed25519": newEd25519Key, "RSA": newRSAKey} {
+		t.Run(name, func(t *testing.T) {
+			private, public := newKey(t)
+			if private.ID == "" || private.ID != public.ID {
+				t.Errorf("key ids: got %q and %q, want equal thumbprints", private.ID, public.ID)
+			}
+			if public.private != nil {
+				t.Errorf("ParsePublicKey: public key can sign")
+			}
+		})
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	private, public := newEd25519Key(t)
+	_, other := newEd25519Key(t)
+
+	if _, err := NewKeyring(public); err != errNoSigningKey {
+		t.Errorf("NewKeyring: got %v, want %v", err, errNoSigningKey)
+	}
+	if _, err := NewKeyring(private, public, other); err != nil {
+		t.Errorf("NewKeyring: signing key listed for verification: %v", err)
+	}
+	if _, err := NewKeyring(private, other, other); !errors.Is(err, errDuplicateKeyID) {
+		t.Errorf("NewKeyring: got %v, want %v", err, errDuplicateKeyID)
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	private, _ := newEd25519Key(t)
+	_, old := newRSAKey(t)
+
+	keyring, err := NewKeyring(private, old)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if keyring, err = keyring.WithSecret(testSecret); err != nil {
+		t.Fatalf("WithSecret: %v", err)
+	}
+	if _, err := keyring.WithSecret(testSecret); err != errDuplicateKeyID {
+		t.Errorf("WithSecret: got %v, want %v", err, errDuplicateKeyID)
+	}
+
+	set := keyring.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS: got %d keys, want 2, secret must not be published", len(set.Keys))
+	}
+	want := map[string]JWK{
+		private.ID: {Kty: "OKP", Crv: "Ed25519", Alg: "EdDSA", Kid: private.ID, Use: "sig"},
+		old.ID:     {Kty: "RSA", E: "AQAB", Alg: "RS256", Kid: old.ID, Use: "sig"},
+	}
+	for _, got := range set.Keys {
+		w, ok := want[got.Kid]
+		if !ok {
+			t.Errorf("JWKS: unexpected key %q", got.Kid)
+			continue
+		}
+		if got.Kty != w.Kty || got.Crv != w.Crv || got.E != w.E || got.Alg != w.Alg || got.Use != w.Use {
+			t.Errorf("JWKS: got %+v, want %+v", got, w)
+		}
+		if got.N == "" && got.X == "" {
+			t.Errorf("JWKS: key %q has no public key material", got.Kid)
+		}
+	}
+	if set.Keys[0].Kid > set.Keys[1].Kid {
+		t.Errorf("JWKS: keys are not sorted by id")
+	}
+}
+
+func TestKeyringVerify(t *testing.T) {
+	oldPrivate, oldPublic := newRSAKey(t)
+	private, public := newEd25519Key(t)
+	secretKey, err := NewSecretKey(testSecret)
+	if err != nil {
+		t.Fatalf("NewSecretKey: %v", err)
+	}
+
+	newKeyring := func(signing SigningKey, verification ...SigningKey) Keyring {
+		t.Helper()
+		k, err := NewKeyring(signing, verification...)
+		if err != nil {
+			t.Fatalf("NewKeyring: %v", err)
+		}
+		return k
+	}
+	withSecret := func(k Keyring) Keyring {
+		t.Helper()
+		k, err := k.WithSecret(testSecret)
+		if err != nil {
+			t.Fatalf("WithSecret: %v", err)
+		}
+		return k
+	}
+
+	// forged signs HS256 with the public key of the keyring, a classic
+	// attack on verifiers that let tokens choose the algorithm
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{Subject: "user"})
+	forged.Header["kid"] = private.ID
+	forgedToken, err := forged.SignedString([]byte(public.public.(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		signer  Keyring
+		token   string
+		keyring Keyring
+		err     error
+	}{
+		{"SameKey", newKeyring(private), "", newKeyring(private), nil},
+		{"PublicKey", newKeyring(private), "", newKeyring(oldPrivate, public), nil},
+		{"RotatedKey", newKeyring(oldPrivate), "", newKeyring(private, oldPublic), nil},
+		{"RemovedKey", newKeyring(oldPrivate), "", newKeyring(private), errUnknownKey},
+		{"Secret", newKeyring(secretKey), "", withSecret(newKeyring(private)), nil},
+		{"SecretNotAccepted", newKeyring(secretKey), "", newKeyring(private), errUnknownKey},
+		{"AlgorithmMismatch", Keyring{}, forgedToken, newKeyring(private), errMethodMismatch},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			token := c.token
+			if token == "" {
+				var err error
+				if token, err = c.signer.sign(jwt.StandardClaims{Subject: "user"}); err != nil {
+					t.Fatalf("sign: %v", err)
+				}
+			}
+
+			var claims jwt.StandardClaims
+			_, err := jwt.ParseWithClaims(token, &claims, c.keyring.keyFunc)
+			if c.err == nil {
+				if err != nil || claims.Subject != "user" {
+					t.Errorf("ParseWithClaims: got %v and subject %q", err, claims.Subject)
+				}
+				return
+			}
+			var ve *jwt.ValidationError
+			if !errors.As(err, &ve) || ve.Inner != c.err {
+				t.Errorf("ParseWithClaims: got %v, want %v", err, c.err)
+			}
+		})
+	}
+}
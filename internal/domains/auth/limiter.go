@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+)
+
+// loginLimiter counts failed logins per username and per client ip in
+// KeyValueRepository. Failures are counted with Increment, so concurrent
+// attempts are not lost, and the time login is locked until, in unix
+// seconds, is kept under its own key.
+type loginLimiter struct {
+	kv     KeyValueRepository
+	limits LoginLimits
+	now    func() time.Time
+
+	counters []loginCounter
+}
+
+type loginCounter struct {
+	id   string // user:<username> or ip:<ip>
+	free int
+}
+
+func (s service) loginLimiter(username, ip string) loginLimiter {
+	l := loginLimiter{kv: s.kv, limits: s.limits, now: time.Now}
+	l.counters = append(l.counters, loginCounter{
+		id:   "user:" + strings.ToLower(username),
+		free: s.limits.FreeAttempts,
+	})
+	if ip != "" {
+		l.counters = append(l.counters, loginCounter{
+			id:   "ip:" + ip,
+			free: s.limits.IPFreeAttempts,
+		})
+	}
+	return l
+}
+
+// sellerLoginLimiter counts login requests of sellers apart from owner
+// logins, usernames of sellers and owners may be the same.
+func (s service) sellerLoginLimiter(username, ip string) loginLimiter {
+	return s.loginLimiter("seller:"+username, ip)
+}
+
+// check returns ErrLoginLocked if any of the counters is locked.
+func (l loginLimiter) check(ctx context.Context) error {
+	for _, c := range l.counters {
+		lockedUntil, err := l.lockedUntil(ctx, c)
+		if err != nil {
+			return err
+		}
+		if l.now().Before(lockedUntil) {
+			return ErrLoginLocked
+		}
+	}
+	return nil
+}
+
+func (l loginLimiter) failed(ctx context.Context) error {
+	for _, c := range l.counters {
+		failures, err := l.kv.Increment(ctx, loginFailuresPrefix+c.id, l.limits.Window)
+		if err != nil {
+			return err
+		}
+
+		lock := l.lockout(int(failures), c.free)
+		if lock == 0 {
+			continue
+		}
+		lockedUntil := strconv.FormatInt(l.now().Add(lock).Unix(), 10)
+		if err := l.kv.Set(ctx, loginLockPrefix+c.id, lockedUntil, lock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// succeeded forgets failures of the username. Failures of the ip are
+// kept, otherwise an attacker could reset them with own account.
+func (l loginLimiter) succeeded(ctx context.Context) error {
+	return l.kv.Delete(ctx, loginFailuresPrefix+l.counters[0].id)
+}
+
+func (l loginLimiter) lockout(failures, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+
+	lock := l.limits.BackoffBase
+	for i := free; i < failures && lock < l.limits.MaxLockout; i++ {
+		lock *= 2
+	}
+	if lock > l.limits.MaxLockout {
+		lock = l.limits.MaxLockout
+	}
+	return lock
+}
+
+func (l loginLimiter) lockedUntil(ctx context.Context, c loginCounter) (time.Time, error) {
+	val, err := l.kv.Get(ctx, loginLockPrefix+c.id)
+	if err != nil {
+		if err == ErrKeyNotFound {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	unix, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
+
+func (s service) loginFailed(ctx context.Context, l loginLimiter) {
+	// failing to count is not a reason to tell user something else
+	if err := l.failed(ctx); err != nil {
+		s.log.Error("auth:Login - failed to count attempt", logging.String("stage", "limiter"), logging.Error("err", err))
+	}
+}
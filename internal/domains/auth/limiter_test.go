@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockout(t *testing.T) {
+	l := loginLimiter{limits: LoginLimits{BackoffBase: time.Second, MaxLockout: 10 * time.Second}}
+
+	cases := []struct {
+		name     string
+		failures int
+		free     int
+		want     time.Duration
+	}{
+		{"NoFailures", 0, 3, 0},
+		{"FreeAttempts", 2, 3, 0},
+		{"FirstLock", 3, 3, time.Second},
+		{"Doubles", 4, 3, 2 * time.Second},
+		{"DoublesAgain", 6, 3, 8 * time.Second},
+		{"Capped", 7, 3, 10 * time.Second},
+		{"StaysCapped", 1000, 3, 10 * time.Second},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := l.lockout(c.failures, c.free); got != c.want {
+				t.Errorf("lockout: got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+func (s service) ChangePassword(ctx context.Context, accessKey AccessKey, input ChangePasswordInput) (Session, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ChangePassword")).End()
+	defer s.log.Sync()
+
+	o, err := s.ownersRepo.Read(ctx, accessKey.UserID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:ChangePassword - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return Session{}, err
+		}
+		s.log.Error("auth:ChangePassword - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	if err := o.ComparePassword(input.CurrentPassword); err != nil {
+		s.log.Debug("auth:ChangePassword - wrong password", logging.String("stage", "validation"), logging.Error("err", err))
+		return Session{}, ErrWrongPassword
+	}
+
+	if err := s.updatePassword(ctx, o, input.NewPassword); err != nil {
+		if err == entities.ErrPasswordTooShort {
+			return Session{}, err
+		}
+		s.log.Error("auth:ChangePassword - failed to update password", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	session, err := s.startSession(ctx, ownerAccessKey(o))
+	if err != nil {
+		s.log.Error("auth:ChangePassword - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	s.log.Info("auth:ChangePassword - password changed", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return session, nil
+}
+
+func (s service) RequestPasswordReset(ctx context.Context, input RequestPasswordResetInput) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.RequestPasswordReset")).End()
+	defer s.log.Sync()
+
+	if s.notifier == nil {
+		s.log.Debug("auth:RequestPasswordReset - notifier is not configured", logging.String("stage", "notifier"))
+		return ErrResetUnavailable
+	}
+
+	o, err := s.ownersRepo.ReadByUsername(ctx, input.Username)
+	if err != nil {
+		if err == ErrUsernameNotFound {
+			// caller must not learn which usernames exist
+			s.log.Debug("auth:RequestPasswordReset - owner not found", logging.String("stage", "repository"), logging.String("username", input.Username))
+			return nil
+		}
+		s.log.Error("auth:RequestPasswordReset - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+	if o.PhoneNumber == "" {
+		s.log.Debug("auth:RequestPasswordReset - owner has no phone number", logging.String("stage", "validation"), logging.String("userID", o.ID.String()))
+		return nil
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		s.log.Error("auth:RequestPasswordReset - failed to generate token", logging.String("stage", "token"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	// only hash is stored, so leaked storage does not give reset tokens away
+	if err := s.kv.Set(ctx, resetKeyPrefix+hashCode(token), o.ID.String(), PasswordResetTTL); err != nil {
+		s.log.Error("auth:RequestPasswordReset - failed to save token", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	msg := fmt.Sprintf("Токен для сброса пароля в accounter: %s. Он действует %d минут.", token, int(PasswordResetTTL.Minutes()))
+	if err := s.notifier.Send(ctx, o.PhoneNumber, msg); err != nil {
+		s.log.Error("auth:RequestPasswordReset - failed to send token", logging.String("stage", "notifier"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:RequestPasswordReset - reset token sent", logging.String("stage", "success"), logging.String("userID", o.ID.String()))
+	return nil
+}
+
+func (s service) ResetPassword(ctx context.Context, input ResetPasswordInput) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ResetPassword")).End()
+	defer s.log.Sync()
+
+	key := resetKeyPrefix + hashCode(input.Token)
+	ownerID, err := s.kv.Get(ctx, key)
+	if err != nil {
+		if err == ErrKeyNotFound {
+			s.log.Debug("auth:ResetPassword - token not found", logging.String("stage", "repository"))
+			return ErrInvalidResetToken
+		}
+		s.log.Error("auth:ResetPassword - failed to read token", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	// token is burned before it is used, so it can't be used twice
+	// even if updating the password fails
+	if err := s.kv.Delete(ctx, key); err != nil {
+		s.log.Error("auth:ResetPassword - failed to delete token", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	o, err := s.ownersRepo.Read(ctx, ownerID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:ResetPassword - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return ErrInvalidResetToken
+		}
+		s.log.Error("auth:ResetPassword - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := s.updatePassword(ctx, o, input.NewPassword); err != nil {
+		if err == entities.ErrPasswordTooShort {
+			return err
+		}
+		s.log.Error("auth:ResetPassword - failed to update password", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:ResetPassword - password reset", logging.String("stage", "success"), logging.String("userID", ownerID))
+	return nil
+}
+
+// updatePassword saves new password and revokes every session of the owner.
+func (s service) updatePassword(ctx context.Context, o entities.Owner, password string) error {
+	if err := o.SetPassword(password, s.hashCost); err != nil {
+		return err
+	}
+
+	if _, err := s.ownersRepo.Update(ctx, o); err != nil {
+		return err
+	}
+
+	return s.revokeAll(ctx, o.ID.String())
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+func (s service) UpdateProfile(ctx context.Context, accessKey AccessKey, input UpdateProfileInput) (entities.Owner, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.UpdateProfile")).End()
+	defer s.log.Sync()
+
+	o, err := s.ownersRepo.Read(ctx, accessKey.UserID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:UpdateProfile - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Owner{}, err
+		}
+		s.log.Error("auth:UpdateProfile - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Owner{}, ErrDefault
+	}
+
+	if val, ok := input.FullName.Get(); ok {
+		o.FullName = val
+	}
+	if val, ok := input.PhoneNumber.Get(); ok {
+		o.PhoneNumber = val
+	}
+	if val, ok := input.Username.Get(); ok && val != o.Username {
+		if len(val) < 6 {
+			return entities.Owner{}, ErrUsernameTooShort
+		}
+
+		// repository checks it too, this only gives a clear error early
+		_, err := s.ownersRepo.ReadByUsername(ctx, val)
+		switch err {
+		case nil:
+			s.log.Debug("auth:UpdateProfile - username is taken", logging.String("stage", "validation"), logging.String("username", val))
+			return entities.Owner{}, ErrUsernameTaken
+		case ErrUsernameNotFound:
+		default:
+			s.log.Error("auth:UpdateProfile - failed to check username", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Owner{}, ErrDefault
+		}
+		o.Username = val
+	}
+
+	if err := s.val.Validate(o); err != nil {
+		s.log.Debug("auth:UpdateProfile - failed to validate owner", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Owner{}, err
+	}
+
+	o, err = s.ownersRepo.Update(ctx, o)
+	if err != nil {
+		if err == ErrUsernameTaken || err == ErrIdNotFound {
+			s.log.Debug("auth:UpdateProfile - failed to update owner", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Owner{}, err
+		}
+		s.log.Error("auth:UpdateProfile - failed to update owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Owner{}, ErrDefault
+	}
+
+	s.log.Info("auth:UpdateProfile - profile updated", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return o, nil
+}
+
+func (s service) DeleteAccount(ctx context.Context, accessKey AccessKey, input DeleteAccountInput) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.DeleteAccount")).End()
+	defer s.log.Sync()
+
+	o, err := s.ownersRepo.Read(ctx, accessKey.UserID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:DeleteAccount - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return err
+		}
+		s.log.Error("auth:DeleteAccount - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := o.ComparePassword(input.Password); err != nil {
+		s.log.Debug("auth:DeleteAccount - wrong password", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrWrongPassword
+	}
+
+	switch err := s.checkSecondFactor(ctx, accessKey.UserID, input.Code, input.RecoveryCode); err {
+	case nil, ErrTwoFactorDisabled:
+	case ErrWrongCode:
+		s.log.Debug("auth:DeleteAccount - wrong code", logging.String("stage", "2fa"), logging.String("userID", accessKey.UserID))
+		return err
+	default:
+		s.log.Error("auth:DeleteAccount - failed to check code", logging.String("stage", "2fa"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	remove := s.ownersRepo.Delete
+	if input.Anonymize {
+		remove = s.ownersRepo.Anonymize
+	}
+	if err := remove(ctx, accessKey.UserID); err != nil {
+		s.log.Error("auth:DeleteAccount - failed to delete owner", logging.String("stage", "repository"), logging.Bool("anonymize", input.Anonymize), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	// access keys stay valid until they expire, so they are revoked too
+	if err := s.revokeAll(ctx, accessKey.UserID); err != nil {
+		s.log.Error("auth:DeleteAccount - failed to revoke sessions", logging.String("stage", "session"), logging.Error("err", err))
+	}
+
+	s.log.Info("auth:DeleteAccount - account deleted", logging.String("stage", "success"), logging.String("userID", accessKey.UserID), logging.Bool("anonymize", input.Anonymize))
+	return nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAccessKeyAllows(t *testing.T) {
+	login := AccessKey{UserID: "owner", Role: RoleOwner}
+	apiKey := AccessKey{UserID: "owner", Role: RoleOwner, APIKeyID: "key", Scopes: []string{"items:read", "stock:write"}}
+	unscoped := AccessKey{UserID: "owner", Role: RoleOwner, APIKeyID: "key"}
+
+	cases := []struct {
+		name  string
+		key   AccessKey
+		scope string
+		want  bool
+	}{
+		{"LoginReads", login, "items:read", true},
+		{"LoginWrites", login, "sellers:write", true},
+		{"APIKeyInScope", apiKey, "items:read", true},
+		{"APIKeyWriteInScope", apiKey, "stock:write", true},
+		{"APIKeyWriteDoesNotRead", apiKey, "stock:read", false},
+		{"APIKeyReadDoesNotWrite", apiKey, "items:write", false},
+		{"APIKeyOtherResource", apiKey, "sellers:read", false},
+		{"APIKeyWithoutScopes", unscoped, "items:read", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.key.Allows(c.scope); got != c.want {
+				t.Errorf("Allows: got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAccessKeyOwner(t *testing.T) {
+	owner := AccessKey{UserID: "owner", Role: RoleOwner, OwnerID: "ignored"}
+	seller := AccessKey{UserID: "seller", Role: RoleSeller, OwnerID: "owner"}
+
+	if got := owner.Owner(); got != "owner" {
+		t.Errorf("Owner of owner: got %q, want %q", got, "owner")
+	}
+	if got := seller.Owner(); got != "owner" {
+		t.Errorf("Owner of seller: got %q, want %q", got, "owner")
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	cases := []struct {
+		name   string
+		scopes []string
+		want   []string
+		err    error
+	}{
+		{"Empty", nil, []string{}, nil},
+		{"Sorted", []string{"stock:write", "items:read", "audit:read"}, []string{"audit:read", "items:read", "stock:write"}, nil},
+		{"Duplicates", []string{"items:read", "items:read", "items:write"}, []string{"items:read", "items:write"}, nil},
+		{"UnknownResource", []string{"items:read", "owners:read"}, nil, ErrUnknownScope},
+		{"UnknownAccess", []string{"items:delete"}, nil, ErrUnknownScope},
+		{"NoAccess", []string{"items"}, nil, ErrUnknownScope},
+		{"WrongCase", []string{"Items:read"}, nil, ErrUnknownScope},
+		{"Wildcard", []string{"*:read"}, nil, ErrUnknownScope},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := normalizeScopes(c.scopes)
+			if err != c.err || !reflect.DeepEqual(got, c.want) {
+				t.Errorf("normalizeScopes: got %v %v, want %v %v", got, err, c.want, c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+func (s service) RequestSellerLogin(ctx context.Context, input RequestSellerLoginInput) (entities.SellerLoginRequest, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.RequestSellerLogin")).End()
+	defer s.log.Sync()
+
+	// every request may send a code, so all of them are counted
+	limiter := s.sellerLoginLimiter(input.Username, input.IP)
+	if err := limiter.check(ctx); err != nil {
+		if err == ErrLoginLocked {
+			s.log.Debug("auth:RequestSellerLogin - login is locked", logging.String("stage", "limiter"), logging.String("username", input.Username), logging.String("ip", input.IP))
+			return entities.SellerLoginRequest{}, err
+		}
+		s.log.Error("auth:RequestSellerLogin - failed to check attempts", logging.String("stage", "limiter"), logging.Error("err", err))
+		return entities.SellerLoginRequest{}, ErrDefault
+	}
+	s.loginFailed(ctx, limiter)
+
+	// unknown and inactive sellers get a request that can't be
+	// completed, so the answer does not tell which usernames exist
+	decoy := entities.NewSellerLoginRequest(nil, "", SellerLoginRequestTTL)
+	seller, err := s.sellersRepo.ReadByUsername(tenancy.WithSystem(ctx, "seller sign in"), input.Username)
+	if err != nil {
+		if err == ErrSellerNotFound {
+			s.log.Debug("auth:RequestSellerLogin - seller not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return decoy, nil
+		}
+		s.log.Error("auth:RequestSellerLogin - failed to read seller", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.SellerLoginRequest{}, ErrDefault
+	}
+	if !seller.IsActive {
+		s.log.Debug("auth:RequestSellerLogin - seller is inactive", logging.String("stage", "validation"), logging.String("sellerID", seller.ID.String()))
+		return decoy, nil
+	}
+
+	// without a way to deliver the code only owner can approve the request
+	var code, codeHash string
+	if s.notifier != nil && seller.PhoneNumber != "" {
+		code, err = generateCode()
+		if err != nil {
+			s.log.Error("auth:RequestSellerLogin - failed to generate code", logging.String("stage", "code"), logging.Error("err", err))
+			return entities.SellerLoginRequest{}, ErrDefault
+		}
+		codeHash = hashCode(code)
+	}
+
+	request := entities.NewSellerLoginRequest(&entities.Seller{ID: seller.ID}, codeHash, SellerLoginRequestTTL)
+	if err := s.sellersRepo.CreateLoginRequest(ctx, request); err != nil {
+		s.log.Error("auth:RequestSellerLogin - failed to create login request", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.SellerLoginRequest{}, ErrDefault
+	}
+
+	if code != "" {
+		msg := fmt.Sprintf("Код для входа в accounter: %s", code)
+		if err := s.notifier.Send(ctx, seller.PhoneNumber, msg); err != nil {
+			// owner can still approve the request, so it is not fatal
+			s.log.Error("auth:RequestSellerLogin - failed to send code", logging.String("stage", "notifier"), logging.Error("err", err))
+		}
+	}
+
+	s.log.Info("auth:RequestSellerLogin - login requested", logging.String("stage", "success"), logging.String("requestID", request.ID.String()), logging.String("sellerID", seller.ID.String()))
+	// seller is not shown, decoys have none
+	request.Seller = nil
+	return request, nil
+}
+
+func (s service) CompleteSellerLogin(ctx context.Context, input CompleteSellerLoginInput) (Session, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.CompleteSellerLogin")).End()
+	defer s.log.Sync()
+
+	request, err := s.sellersRepo.ReadLoginRequest(ctx, input.RequestID)
+	if err != nil {
+		if err == ErrLoginRequestNotFound {
+			// decoys of RequestSellerLogin look like pending requests
+			s.log.Debug("auth:CompleteSellerLogin - request not found", logging.String("stage", "repository"), logging.String("requestID", input.RequestID))
+			if input.Code == "" {
+				return Session{}, ErrLoginNotApproved
+			}
+			return Session{}, ErrWrongCode
+		}
+		s.log.Error("auth:CompleteSellerLogin - failed to read login request", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	if time.Now().After(request.ExpiresAt) {
+		s.log.Debug("auth:CompleteSellerLogin - request expired", logging.String("stage", "validation"), logging.String("requestID", input.RequestID))
+		return Session{}, ErrLoginRequestExpired
+	}
+
+	switch request.Status {
+	case entities.SellerLoginApproved:
+	case entities.SellerLoginPending:
+		if input.Code == "" || request.CodeHash == "" {
+			s.log.Debug("auth:CompleteSellerLogin - not approved yet", logging.String("stage", "validation"), logging.String("requestID", input.RequestID))
+			return Session{}, ErrLoginNotApproved
+		}
+		// attempt is counted before the code is compared, so parallel
+		// guesses can't get past the limit
+		if err := s.sellersRepo.UseLoginAttempt(ctx, input.RequestID, SellerLoginMaxAttempts); err != nil {
+			if err == ErrTooManyAttempts {
+				s.log.Debug("auth:CompleteSellerLogin - too many attempts", logging.String("stage", "validation"), logging.String("requestID", input.RequestID))
+				return Session{}, err
+			}
+			s.log.Error("auth:CompleteSellerLogin - failed to count attempt", logging.String("stage", "repository"), logging.Error("err", err))
+			return Session{}, ErrDefault
+		}
+		if subtle.ConstantTimeCompare([]byte(hashCode(input.Code)), []byte(request.CodeHash)) != 1 {
+			s.log.Debug("auth:CompleteSellerLogin - wrong code", logging.String("stage", "validation"), logging.String("requestID", input.RequestID))
+			return Session{}, ErrWrongCode
+		}
+	case entities.SellerLoginRejected:
+		s.log.Debug("auth:CompleteSellerLogin - request rejected", logging.String("stage", "validation"), logging.String("requestID", input.RequestID))
+		return Session{}, ErrLoginRejected
+	default:
+		s.log.Debug("auth:CompleteSellerLogin - request already used", logging.String("stage", "validation"), logging.String("requestID", input.RequestID))
+		return Session{}, ErrLoginRequestNotFound
+	}
+
+	// request can be completed only once even if two calls race
+	if err := s.sellersRepo.UseLoginRequest(ctx, input.RequestID, request.Status); err != nil {
+		if err == ErrLoginRequestNotFound {
+			s.log.Debug("auth:CompleteSellerLogin - request already used", logging.String("stage", "repository"), logging.String("requestID", input.RequestID))
+			return Session{}, err
+		}
+		s.log.Error("auth:CompleteSellerLogin - failed to use login request", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	seller, err := s.sellersRepo.Read(tenancy.WithSystem(ctx, "seller sign in"), request.Seller.ID.String())
+	if err != nil {
+		s.log.Error("auth:CompleteSellerLogin - failed to read seller", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+	if !seller.IsActive {
+		s.log.Debug("auth:CompleteSellerLogin - seller is inactive", logging.String("stage", "validation"), logging.String("sellerID", seller.ID.String()))
+		return Session{}, ErrSellerInactive
+	}
+
+	if err := s.sellerLoginLimiter(seller.Username, "").succeeded(ctx); err != nil {
+		s.log.Error("auth:CompleteSellerLogin - failed to reset attempts", logging.String("stage", "limiter"), logging.Error("err", err))
+	}
+
+	session, err := s.startSession(ctx, sellerAccessKey(seller))
+	if err != nil {
+		s.log.Error("auth:CompleteSellerLogin - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	s.log.Info("auth:CompleteSellerLogin - seller logged in", logging.String("stage", "success"), logging.String("username", seller.Username))
+	return session, nil
+}
+
+func (s service) ReadSellerLoginRequests(ctx context.Context, ownerID string) ([]entities.SellerLoginRequest, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadSellerLoginRequests")).End()
+	defer s.log.Sync()
+
+	requests, err := s.sellersRepo.ReadLoginRequests(ctx, ownerID)
+	if err != nil {
+		s.log.Error("auth:ReadSellerLoginRequests - failed to read login requests", logging.String("stage", "repository"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	s.log.Info("auth:ReadSellerLoginRequests - login requests read", logging.String("stage", "success"), logging.Int("count", len(requests)))
+	return requests, nil
+}
+
+func (s service) ApproveSellerLogin(ctx context.Context, ownerID, requestID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ApproveSellerLogin")).End()
+	defer s.log.Sync()
+
+	return s.decideSellerLogin(ctx, ownerID, requestID, entities.SellerLoginApproved)
+}
+
+func (s service) RejectSellerLogin(ctx context.Context, ownerID, requestID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.RejectSellerLogin")).End()
+	defer s.log.Sync()
+
+	return s.decideSellerLogin(ctx, ownerID, requestID, entities.SellerLoginRejected)
+}
+
+func (s service) decideSellerLogin(ctx context.Context, ownerID, requestID, status string) error {
+	if err := s.sellersRepo.DecideLoginRequest(ctx, ownerID, requestID, status); err != nil {
+		if err == ErrLoginRequestNotFound {
+			s.log.Debug("auth:DecideSellerLogin - request not found", logging.String("stage", "repository"), logging.String("requestID", requestID))
+			return err
+		}
+		s.log.Error("auth:DecideSellerLogin - failed to decide login request", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:DecideSellerLogin - login request decided", logging.String("stage", "success"), logging.String("requestID", requestID), logging.String("status", status))
+	return nil
+}
+
+func (s service) refreshSeller(ctx context.Context, claims RefreshKey, refreshID string) (Session, error) {
+	seller, err := s.sellersRepo.Read(tenancy.WithSystem(ctx, "seller refresh"), claims.UserID)
+	if err != nil {
+		if err == ErrSellerNotFound {
+			s.log.Debug("auth:Refresh - seller not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return Session{}, ErrInvalidRefreshToken
+		}
+		s.log.Error("auth:Refresh - failed to read seller", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+	if !seller.IsActive {
+		s.log.Debug("auth:Refresh - seller is inactive", logging.String("stage", "validation"), logging.String("sellerID", seller.ID.String()))
+		return Session{}, ErrSellerInactive
+	}
+
+	// stores are read again, so changes in assignments apply on refresh
+	session, err := s.continueSession(ctx, sellerAccessKey(seller), claims.SessionID, refreshID)
+	if err != nil {
+		s.log.Error("auth:Refresh - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	s.log.Info("auth:Refresh - successfully refreshed seller session", logging.String("stage", "success"), logging.String("username", seller.Username))
+	return session, nil
+}
+
+// generateCode returns a random 6 digit code.
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
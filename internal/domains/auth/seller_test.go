@@ -0,0 +1,248 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+// sellersRepository keeps sellers and their login requests in memory,
+// it behaves like the postgres one for the methods logins use.
+type sellersRepository struct {
+	mu       sync.Mutex
+	sellers  map[string]entities.Seller
+	requests map[string]entities.SellerLoginRequest
+}
+
+func newSellersRepository(sellers ...entities.Seller) *sellersRepository {
+	r := &sellersRepository{sellers: make(map[string]entities.Seller), requests: make(map[string]entities.SellerLoginRequest)}
+	for _, seller := range sellers {
+		r.sellers[seller.ID.String()] = seller
+	}
+	return r
+}
+
+func (r *sellersRepository) Read(ctx context.Context, id string) (entities.Seller, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seller, ok := r.sellers[id]
+	if !ok {
+		return entities.Seller{}, auth.ErrSellerNotFound
+	}
+	return seller, nil
+}
+
+func (r *sellersRepository) ReadByUsername(ctx context.Context, username string) (entities.Seller, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, seller := range r.sellers {
+		if seller.Username == username {
+			return seller, nil
+		}
+	}
+	return entities.Seller{}, auth.ErrSellerNotFound
+}
+
+func (r *sellersRepository) CreateLoginRequest(ctx context.Context, request entities.SellerLoginRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[request.ID.String()] = request
+	return nil
+}
+
+func (r *sellersRepository) ReadLoginRequest(ctx context.Context, id string) (entities.SellerLoginRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[id]
+	if !ok {
+		return entities.SellerLoginRequest{}, auth.ErrLoginRequestNotFound
+	}
+	return request, nil
+}
+
+func (r *sellersRepository) ReadLoginRequests(ctx context.Context, ownerID string) ([]entities.SellerLoginRequest, error) {
+	return nil, nil
+}
+
+func (r *sellersRepository) DecideLoginRequest(ctx context.Context, ownerID, id, status string) error {
+	return auth.ErrLoginRequestNotFound
+}
+
+func (r *sellersRepository) UseLoginRequest(ctx context.Context, id, from string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[id]
+	if !ok || request.Status != from {
+		return auth.ErrLoginRequestNotFound
+	}
+	request.Status = entities.SellerLoginUsed
+	r.requests[id] = request
+	return nil
+}
+
+func (r *sellersRepository) UseLoginAttempt(ctx context.Context, id string, max int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request := r.requests[id]
+	if request.Attempts >= max {
+		return auth.ErrTooManyAttempts
+	}
+	request.Attempts++
+	r.requests[id] = request
+	return nil
+}
+
+// codes remembers the last message sent to every phone.
+type codes struct {
+	mu   sync.Mutex
+	sent map[string]string
+}
+
+func (c *codes) Send(ctx context.Context, to, message string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sent == nil {
+		c.sent = make(map[string]string)
+	}
+	c.sent[to] = message
+	return nil
+}
+
+// code returns the code of the last message sent to phone.
+func (c *codes) code(phone string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	message := c.sent[phone]
+	return message[strings.LastIndex(message, " ")+1:]
+}
+
+func newSeller(active bool) entities.Seller {
+	seller := entities.NewSeller(&entities.Owner{ID: uuid.New()}, "seller_"+uuid.NewString(), "Test Seller", "+996700000001")
+	seller.IsActive = active
+	return seller
+}
+
+func TestSellerLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CompletesWithCode", func(t *testing.T) {
+		seller := newSeller(true)
+		notifier := &codes{}
+		s := newSellersService(t, newSellersRepository(seller), notifier)
+
+		request, err := s.RequestSellerLogin(ctx, auth.RequestSellerLoginInput{Username: seller.Username})
+		if err != nil {
+			t.Fatalf("RequestSellerLogin: %v", err)
+		}
+		session, err := s.CompleteSellerLogin(ctx, auth.CompleteSellerLoginInput{RequestID: request.ID.String(), Code: notifier.code(seller.PhoneNumber)})
+		if err != nil {
+			t.Fatalf("CompleteSellerLogin: %v", err)
+		}
+		key, err := s.ParseAccessKey(ctx, session.AccessToken)
+		if err != nil {
+			t.Fatalf("ParseAccessKey: %v", err)
+		}
+		if key.Role != auth.RoleSeller || key.UserID != seller.ID.String() {
+			t.Errorf("ParseAccessKey: got %+v, want key of the seller", key)
+		}
+	})
+
+	t.Run("RevokeSessions", func(t *testing.T) {
+		seller := newSeller(true)
+		notifier := &codes{}
+		s := newSellersService(t, newSellersRepository(seller), notifier)
+
+		request, err := s.RequestSellerLogin(ctx, auth.RequestSellerLoginInput{Username: seller.Username})
+		if err != nil {
+			t.Fatalf("RequestSellerLogin: %v", err)
+		}
+		session, err := s.CompleteSellerLogin(ctx, auth.CompleteSellerLoginInput{RequestID: request.ID.String(), Code: notifier.code(seller.PhoneNumber)})
+		if err != nil {
+			t.Fatalf("CompleteSellerLogin: %v", err)
+		}
+
+		if err := s.RevokeSessions(ctx, seller.ID.String()); err != nil {
+			t.Fatalf("RevokeSessions: %v", err)
+		}
+		if _, err := s.ParseAccessKey(ctx, session.AccessToken); err != auth.ErrSessionRevoked {
+			t.Errorf("ParseAccessKey: got %v, want %v", err, auth.ErrSessionRevoked)
+		}
+		if _, err := s.Refresh(ctx, session.RefreshToken); err != auth.ErrSessionRevoked {
+			t.Errorf("Refresh: got %v, want %v", err, auth.ErrSessionRevoked)
+		}
+	})
+
+	t.Run("SameAnswerForEveryone", func(t *testing.T) {
+		active, inactive := newSeller(true), newSeller(false)
+		s := newSellersService(t, newSellersRepository(active, inactive), &codes{})
+
+		for _, username := range []string{active.Username, inactive.Username, "nobody_" + uuid.NewString()} {
+			request, err := s.RequestSellerLogin(ctx, auth.RequestSellerLoginInput{Username: username})
+			if err != nil {
+				t.Fatalf("RequestSellerLogin(%s): %v", username, err)
+			}
+			if request.Seller != nil || request.Status != entities.SellerLoginPending {
+				t.Errorf("RequestSellerLogin(%s): got %+v", username, request)
+			}
+
+			completion := auth.CompleteSellerLoginInput{RequestID: request.ID.String()}
+			if _, err := s.CompleteSellerLogin(ctx, completion); err != auth.ErrLoginNotApproved {
+				t.Errorf("CompleteSellerLogin(%s): got %v, want %v", username, err, auth.ErrLoginNotApproved)
+			}
+			completion.Code = "000000"
+			if _, err := s.CompleteSellerLogin(ctx, completion); err != auth.ErrWrongCode {
+				t.Errorf("CompleteSellerLogin(%s): got %v, want %v", username, err, auth.ErrWrongCode)
+			}
+		}
+	})
+
+	t.Run("LimitsRequests", func(t *testing.T) {
+		seller := newSeller(true)
+		s := newSellersService(t, newSellersRepository(seller), &codes{})
+
+		for i := 0; i < limits.FreeAttempts; i++ {
+			if _, err := s.RequestSellerLogin(ctx, auth.RequestSellerLoginInput{Username: seller.Username, IP: "10.0.0.1"}); err != nil {
+				t.Fatalf("RequestSellerLogin: %v", err)
+			}
+		}
+		if _, err := s.RequestSellerLogin(ctx, auth.RequestSellerLoginInput{Username: seller.Username, IP: "10.0.0.2"}); err != auth.ErrLoginLocked {
+			t.Errorf("RequestSellerLogin: got %v, want %v", err, auth.ErrLoginLocked)
+		}
+	})
+
+	t.Run("LimitsCodeAttempts", func(t *testing.T) {
+		seller := newSeller(true)
+		notifier := &codes{}
+		s := newSellersService(t, newSellersRepository(seller), notifier)
+
+		request, err := s.RequestSellerLogin(ctx, auth.RequestSellerLoginInput{Username: seller.Username})
+		if err != nil {
+			t.Fatalf("RequestSellerLogin: %v", err)
+		}
+		code := notifier.code(seller.PhoneNumber)
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2*auth.SellerLoginMaxAttempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.CompleteSellerLogin(ctx, auth.CompleteSellerLoginInput{RequestID: request.ID.String(), Code: wrong})
+			}()
+		}
+		wg.Wait()
+
+		if _, err := s.CompleteSellerLogin(ctx, auth.CompleteSellerLoginInput{RequestID: request.ID.String(), Code: code}); err != auth.ErrTooManyAttempts {
+			t.Errorf("CompleteSellerLogin: got %v, want %v", err, auth.ErrTooManyAttempts)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
@@ -17,42 +18,190 @@ type (
 		Read(ctx context.Context, id string) (entities.Owner, error)
 		ReadByUsername(ctx context.Context, username string) (entities.Owner, error)
 		ReadAll(ctx context.Context) ([]entities.Owner, error) // TODO: add pagination
+		// Update returns ErrUsernameTaken if username belongs to someone else.
 		Update(ctx context.Context, owner entities.Owner) (entities.Owner, error)
+		// Delete removes owner with all data of the owner's business,
+		// audit log included.
 		Delete(ctx context.Context, id string) error
+		// Anonymize removes personal data of owner and the owner's
+		// sellers, so nobody can sign in as them. Stores, stock journal,
+		// receipts, audit log and other business records are kept, audit
+		// log has no personal data.
+		Anonymize(ctx context.Context, id string) error
 	}
 
+	// SellersRepository is used to sign sellers in, sellers themselves
+	// are managed by the sellers domain.
+	SellersRepository interface {
+		// Read and ReadByUsername return seller with owner and stores.
+		Read(ctx context.Context, id string) (entities.Seller, error)
+		ReadByUsername(ctx context.Context, username string) (entities.Seller, error)
+
+		CreateLoginRequest(ctx context.Context, request entities.SellerLoginRequest) error
+		ReadLoginRequest(ctx context.Context, id string) (entities.SellerLoginRequest, error)
+		// ReadLoginRequests returns unexpired pending requests of owner's sellers.
+		ReadLoginRequests(ctx context.Context, ownerID string) ([]entities.SellerLoginRequest, error)
+		// DecideLoginRequest moves a pending unexpired request of owner's seller to status.
+		DecideLoginRequest(ctx context.Context, ownerID, id, status string) error
+		// UseLoginRequest marks request as used if it still has status from.
+		UseLoginRequest(ctx context.Context, id, from string) error
+		// UseLoginAttempt counts an attempt to enter code of request, it
+		// returns ErrTooManyAttempts if max attempts are used already.
+		UseLoginAttempt(ctx context.Context, id string, max int) error
+	}
+
+	// TwoFactorRepository keeps TOTP secrets and hashed recovery codes
+	// of owners.
+	TwoFactorRepository interface {
+		// ReadTwoFactor returns ErrIdNotFound if owner does not exist.
+		ReadTwoFactor(ctx context.Context, ownerID string) (TwoFactorSettings, error)
+		// SetPendingSecret saves secret of an enrollment that is not confirmed yet.
+		SetPendingSecret(ctx context.Context, ownerID, secret string) error
+		// EnableTwoFactor turns 2FA on and replaces recovery codes.
+		EnableTwoFactor(ctx context.Context, ownerID string, recoveryHashes []string) error
+		ReplaceRecoveryCodes(ctx context.Context, ownerID string, recoveryHashes []string) error
+		// DisableTwoFactor removes secret and recovery codes.
+		DisableTwoFactor(ctx context.Context, ownerID string) error
+		// UseRecoveryCode returns ErrWrongCode for unknown and used codes.
+		UseRecoveryCode(ctx context.Context, ownerID, codeHash string) error
+	}
+
+	APIKeysRepository interface {
+		Create(ctx context.Context, key entities.APIKey) error
+		// ReadByOwner returns revoked keys too.
+		ReadByOwner(ctx context.Context, ownerID string) ([]entities.APIKey, error)
+		// ReadByHash returns ErrInvalidAPIKey if there is no such key.
+		ReadByHash(ctx context.Context, hash string) (entities.APIKey, error)
+		Revoke(ctx context.Context, ownerID, id string) error
+		Touch(ctx context.Context, id string, at time.Time) error
+	}
+
+	// Notifier delivers messages like one-time codes and password reset
+	// tokens to users.
+	Notifier interface {
+		Send(ctx context.Context, to, message string) error
+	}
+
+	// KeyValueRepository keeps sessions. Get returns ErrKeyNotFound for
+	// missing and expired keys, zero ttl means the key never expires.
 	KeyValueRepository interface {
 		Set(ctx context.Context, key, value string, ttl time.Duration) error
 		Get(ctx context.Context, key string) (string, error)
 		Delete(ctx context.Context, key string) error
+		// Swap sets key to new only if it is not expired and holds old,
+		// it reports whether it did. Of concurrent swaps of the same
+		// value only one succeeds.
+		Swap(ctx context.Context, key, old, new string, ttl time.Duration) (bool, error)
+		// Increment atomically adds one to the number under key, a
+		// missing, expired or not numeric value counts as zero. It
+		// returns the new number and resets ttl of the key.
+		Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
 	}
 
 	Service interface {
 		Register(ctx context.Context, input RegisterInput) (Session, error)
+		// Login returns ErrInvalidCredentials both for unknown usernames
+		// and wrong passwords, so it does not tell which usernames exist.
 		Login(ctx context.Context, input LoginInput) (Session, error)
+		// Refresh rotates tokens of the session, reuse of an old refresh
+		// token revokes the whole session.
 		Refresh(ctx context.Context, refreshToken string) (Session, error)
+		// Logout revokes the session access key belongs to.
+		Logout(ctx context.Context, accessKey AccessKey) error
+		// LogoutAll revokes every session of the user.
+		LogoutAll(ctx context.Context, accessKey AccessKey) error
+		// RevokeSessions revokes every session of the user when access
+		// of the user is taken away, like when a seller is deactivated.
+		RevokeSessions(ctx context.Context, userID string) error
 
 		ParseAccessKey(ctx context.Context, accessToken string) (AccessKey, error)
 		ParseRefreshKey(ctx context.Context, refreshToken string) (RefreshKey, error)
+		// LoginTwoFactor finishes login that returned a challenge token.
+		LoginTwoFactor(ctx context.Context, input TwoFactorLoginInput) (Session, error)
+		// EnrollTwoFactor starts TOTP setup, it is on only after ConfirmTwoFactor.
+		EnrollTwoFactor(ctx context.Context, accessKey AccessKey) (TwoFactorEnrollment, error)
+		// ConfirmTwoFactor turns 2FA on and returns recovery codes, they
+		// are shown only once.
+		ConfirmTwoFactor(ctx context.Context, accessKey AccessKey, input TwoFactorCodeInput) ([]string, error)
+		RegenerateRecoveryCodes(ctx context.Context, accessKey AccessKey, input TwoFactorCodeInput) ([]string, error)
+		DisableTwoFactor(ctx context.Context, accessKey AccessKey, input DisableTwoFactorInput) error
+
+		// CreateAPIKey returns the key itself, it is not stored and can't
+		// be shown again.
+		CreateAPIKey(ctx context.Context, accessKey AccessKey, input CreateAPIKeyInput) (CreatedAPIKey, error)
+		ReadAPIKeys(ctx context.Context, accessKey AccessKey) ([]entities.APIKey, error)
+		RevokeAPIKey(ctx context.Context, accessKey AccessKey, id string) error
+		// ParseAPIKey returns access key of the key's owner limited to its scopes.
+		ParseAPIKey(ctx context.Context, key string) (AccessKey, error)
+
+		// JWKS returns public keys tokens can be verified with.
+		JWKS(ctx context.Context) JWKSet
 		Me(ctx context.Context, accessKey AccessKey) (entities.Owner, error)
+
+		UpdateProfile(ctx context.Context, accessKey AccessKey, input UpdateProfileInput) (entities.Owner, error)
+		// DeleteAccount removes owner and everything the owner has, or
+		// only personal data when input asks to anonymize. It can't be
+		// undone.
+		DeleteAccount(ctx context.Context, accessKey AccessKey, input DeleteAccountInput) error
+		// ChangePassword revokes all sessions of the owner and starts a new one.
+		ChangePassword(ctx context.Context, accessKey AccessKey, input ChangePasswordInput) (Session, error)
+		// RequestPasswordReset sends single use reset token to the owner's
+		// phone. It does not tell whether the username exists.
+		RequestPasswordReset(ctx context.Context, input RequestPasswordResetInput) error
+		// ResetPassword revokes all sessions of the owner on success.
+		ResetPassword(ctx context.Context, input ResetPasswordInput) error
+
+		// RequestSellerLogin starts seller login, one-time code is sent
+		// to the seller if notifier is configured. Unknown and inactive
+		// sellers get a request that never completes, so answers do not
+		// tell which sellers exist.
+		RequestSellerLogin(ctx context.Context, input RequestSellerLoginInput) (entities.SellerLoginRequest, error)
+		CompleteSellerLogin(ctx context.Context, input CompleteSellerLoginInput) (Session, error)
+		ReadSellerLoginRequests(ctx context.Context, ownerID string) ([]entities.SellerLoginRequest, error)
+		ApproveSellerLogin(ctx context.Context, ownerID, requestID string) error
+		RejectSellerLogin(ctx context.Context, ownerID, requestID string) error
 	}
 
 	service struct {
-		ownersRepo OwnersRepository
-		log        *logging.Logger
-		val        *validation.Validator
-
-		secretKey []byte
+		ownersRepo  OwnersRepository
+		sellersRepo SellersRepository
+		twoFARepo   TwoFactorRepository
+		apiKeysRepo APIKeysRepository
+		kv          KeyValueRepository
+		notifier    Notifier // optional, without it sellers need owner approval and passwords can't be reset
+		log         *logging.Logger
+		val         *validation.Validator
+
+		hashCost int
+		limits   LoginLimits
+		keys     Keyring
+
+		// dummyOwner is compared with passwords of unknown usernames, so
+		// they take as long to reject as wrong passwords
+		dummyOwner entities.Owner
 	}
 )
 
 var _ Service = (*service)(nil)
 
-func NewService(ownersRepo OwnersRepository, log *logging.Logger, val *validation.Validator, secretKey []byte) service {
+func NewService(ownersRepo OwnersRepository, sellersRepo SellersRepository, twoFARepo TwoFactorRepository, apiKeysRepo APIKeysRepository, kv KeyValueRepository, notifier Notifier, log *logging.Logger, val *validation.Validator, hashCost int, limits LoginLimits, keys Keyring) service {
+	var dummyOwner entities.Owner
+	// cost is checked by AuthDependencies, failing here only leaves the hash empty
+	_ = dummyOwner.SetPassword(uuid.NewString(), hashCost)
+
 	return service{
-		ownersRepo: ownersRepo,
-		log:        log,
-		val:        val,
+		ownersRepo:  ownersRepo,
+		sellersRepo: sellersRepo,
+		twoFARepo:   twoFARepo,
+		apiKeysRepo: apiKeysRepo,
+		kv:          kv,
+		notifier:    notifier,
+		log:         log,
+		val:         val,
+		hashCost:    hashCost,
+		limits:      limits,
+		keys:        keys,
+		dummyOwner:  dummyOwner,
 	}
 }
 
@@ -60,7 +209,7 @@ func (s service) Register(ctx context.Context, input RegisterInput) (Session, er
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Register")).End()
 	defer s.log.Sync()
 
-	o, err := entities.NewOwner(input.PhoneNumber, input.FullName, input.Username, input.Password)
+	o, err := entities.NewOwner(input.PhoneNumber, input.FullName, input.Username, input.Password, s.hashCost)
 	if err != nil {
 		s.log.Debug("auth:Register - failed to create owner", logging.String("stage", "validation"), logging.Error("err", err))
 		return Session{}, err
@@ -80,7 +229,7 @@ func (s service) Register(ctx context.Context, input RegisterInput) (Session, er
 		return Session{}, ErrDefault
 	}
 
-	session, err := generateSession(o, s.secretKey)
+	session, err := s.startSession(ctx, ownerAccessKey(o))
 	if err != nil {
 		s.log.Error("auth:Register - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
 		return Session{}, ErrDefault
@@ -95,11 +244,23 @@ func (s service) Login(ctx context.Context, input LoginInput) (Session, error) {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Login")).End()
 	defer s.log.Sync()
 
+	limiter := s.loginLimiter(input.Username, input.IP)
+	if err := limiter.check(ctx); err != nil {
+		if err == ErrLoginLocked {
+			s.log.Debug("auth:Login - login is locked", logging.String("stage", "limiter"), logging.String("username", input.Username), logging.String("ip", input.IP))
+			return Session{}, err
+		}
+		s.log.Error("auth:Login - failed to check attempts", logging.String("stage", "limiter"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
 	o, err := s.ownersRepo.ReadByUsername(ctx, input.Username)
 	if err != nil {
 		if err == ErrUsernameNotFound {
 			s.log.Debug("auth:Login - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
-			return Session{}, err
+			_ = s.dummyOwner.ComparePassword(input.Password)
+			s.loginFailed(ctx, limiter)
+			return Session{}, ErrInvalidCredentials
 		}
 		s.log.Error("auth:Login - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
 		return Session{}, ErrDefault
@@ -107,10 +268,42 @@ func (s service) Login(ctx context.Context, input LoginInput) (Session, error) {
 
 	if err := o.ComparePassword(input.Password); err != nil {
 		s.log.Debug("auth:Login - wrong password", logging.String("stage", "validation"), logging.Error("err", err))
-		return Session{}, ErrWrongPassword
+		s.loginFailed(ctx, limiter)
+		return Session{}, ErrInvalidCredentials
+	}
+
+	if o.NeedsRehash(s.hashCost) {
+		// password is known only now, so old hashes are upgraded on login
+		if err := o.SetPassword(input.Password, s.hashCost); err != nil {
+			s.log.Error("auth:Login - failed to rehash password", logging.String("stage", "rehash"), logging.Error("err", err))
+		} else if _, err := s.ownersRepo.Update(ctx, o); err != nil {
+			s.log.Error("auth:Login - failed to save rehashed password", logging.String("stage", "repository"), logging.Error("err", err))
+		}
+	}
+
+	twoFA, err := s.twoFARepo.ReadTwoFactor(ctx, o.ID.String())
+	if err != nil {
+		s.log.Error("auth:Login - failed to read two factor settings", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+	if twoFA.Enabled {
+		challenge, err := s.startChallenge(ctx, o.ID.String())
+		if err != nil {
+			s.log.Error("auth:Login - failed to start challenge", logging.String("stage", "2fa"), logging.Error("err", err))
+			return Session{}, ErrDefault
+		}
+
+		s.log.Info("auth:Login - second factor required", logging.String("stage", "2fa"), logging.String("username", o.Username))
+		return Session{ChallengeToken: challenge}, nil
+	}
+
+	// with 2FA failures are forgotten only after the second factor,
+	// otherwise new challenges would allow to guess codes endlessly
+	if err := limiter.succeeded(ctx); err != nil {
+		s.log.Error("auth:Login - failed to reset attempts", logging.String("stage", "limiter"), logging.Error("err", err))
 	}
 
-	session, err := generateSession(o, s.secretKey)
+	session, err := s.startSession(ctx, ownerAccessKey(o))
 	if err != nil {
 		s.log.Error("auth:Login - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
 		return Session{}, ErrDefault
@@ -131,6 +324,20 @@ func (s service) Refresh(ctx context.Context, refreshToken string) (Session, err
 		return Session{}, ErrInvalidRefreshToken
 	}
 
+	refreshID, err := s.useRefreshKey(ctx, claims)
+	if err != nil {
+		if err == ErrSessionRevoked || err == ErrRefreshTokenReused {
+			s.log.Debug("auth:Refresh - refresh key cannot be used", logging.String("stage", "session"), logging.String("sessionID", claims.SessionID), logging.Error("err", err))
+			return Session{}, err
+		}
+		s.log.Error("auth:Refresh - failed to check session", logging.String("stage", "session"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	if claims.Role == RoleSeller {
+		return s.refreshSeller(ctx, claims, refreshID)
+	}
+
 	o, err := s.ownersRepo.Read(ctx, claims.UserID)
 	if err != nil {
 		if err == ErrIdNotFound {
@@ -141,7 +348,7 @@ func (s service) Refresh(ctx context.Context, refreshToken string) (Session, err
 		return Session{}, ErrDefault
 	}
 
-	session, err := generateSession(o, s.secretKey)
+	session, err := s.continueSession(ctx, ownerAccessKey(o), claims.SessionID, refreshID)
 	if err != nil {
 		s.log.Error("auth:Refresh - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
 		return Session{}, ErrDefault
@@ -152,32 +359,50 @@ func (s service) Refresh(ctx context.Context, refreshToken string) (Session, err
 	return session, nil
 }
 
-func generateSession(o entities.Owner, secretKey []byte) (Session, error) {
-	claims := AccessKey{
+func ownerAccessKey(o entities.Owner) AccessKey {
+	return AccessKey{
 		UserID: o.ID.String(),
 		Role:   RoleOwner,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(AccessKeyTTL).Unix(),
-		},
 	}
+}
 
-	accessToken, err := jwt.
-		NewWithClaims(jwt.SigningMethodHS256, claims).
-		SignedString(secretKey)
+func sellerAccessKey(seller entities.Seller) AccessKey {
+	key := AccessKey{
+		UserID:   seller.ID.String(),
+		Role:     RoleSeller,
+		StoreIDs: make([]string, 0, len(seller.Stores)),
+	}
+	if seller.Owner != nil {
+		key.OwnerID = seller.Owner.ID.String()
+	}
+	for _, store := range seller.Stores {
+		key.StoreIDs = append(key.StoreIDs, store.ID.String())
+	}
+	return key
+}
+
+func generateSession(claims AccessKey, refreshID string, keys Keyring) (Session, error) {
+	claims.StandardClaims = jwt.StandardClaims{
+		ExpiresAt: time.Now().Add(AccessKeyTTL).Unix(),
+	}
+
+	accessToken, err := keys.sign(claims)
 	if err != nil {
 		return Session{}, err
 	}
 
 	rClaims := RefreshKey{
-		UserID: o.ID.String(),
+		UserID:     claims.UserID,
+		Role:       claims.Role,
+		SessionID:  claims.SessionID,
+		Generation: claims.Generation,
 		StandardClaims: jwt.StandardClaims{
+			Id:        refreshID,
 			ExpiresAt: time.Now().Add(RefreshKeyTTL).Unix(),
 		},
 	}
 
-	refreshToken, err := jwt.
-		NewWithClaims(jwt.SigningMethodHS256, rClaims).
-		SignedString(secretKey)
+	refreshToken, err := keys.sign(rClaims)
 	if err != nil {
 		return Session{}, err
 	}
@@ -191,11 +416,25 @@ func generateSession(o entities.Owner, secretKey []byte) (Session, error) {
 func (s service) ParseAccessKey(ctx context.Context, key string) (AccessKey, error) {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ParseAccessKey")).End()
 	var claims AccessKey
-	_, err := jwt.ParseWithClaims(key, &claims, func(token *jwt.Token) (interface{}, error) {
-		return s.secretKey, nil
-	})
-	if err != nil {
-		return AccessKey{}, err
+	if _, err := jwt.ParseWithClaims(key, &claims, s.keys.keyFunc); err != nil {
+		// expired keys are refreshed by clients, others mean signing in again
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return AccessKey{}, ErrAccessTokenExpired
+		}
+		return AccessKey{}, ErrInvalidAccessToken
+	}
+	// only refresh keys have an id, they must not be used for access
+	if claims.Id != "" {
+		return AccessKey{}, ErrInvalidAccessToken
+	}
+
+	// token is valid until it expires, so revocation is checked on every use
+	if err := s.checkAccessKey(ctx, claims); err != nil {
+		if err == ErrSessionRevoked {
+			return AccessKey{}, err
+		}
+		s.log.Error("auth:ParseAccessKey - failed to check session", logging.String("stage", "session"), logging.Error("err", err))
+		return AccessKey{}, ErrDefault
 	}
 
 	return claims, nil
@@ -204,16 +443,19 @@ func (s service) ParseAccessKey(ctx context.Context, key string) (AccessKey, err
 func (s service) ParseRefreshKey(ctx context.Context, key string) (RefreshKey, error) {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ParseRefreshKey")).End()
 	var claims RefreshKey
-	_, err := jwt.ParseWithClaims(key, &claims, func(token *jwt.Token) (interface{}, error) {
-		return s.secretKey, nil
-	})
-	if err != nil {
+	if _, err := jwt.ParseWithClaims(key, &claims, s.keys.keyFunc); err != nil {
 		return RefreshKey{}, err
 	}
 
 	return claims, nil
 }
 
+func (s service) JWKS(ctx context.Context) JWKSet {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.JWKS")).End()
+
+	return s.keys.JWKS()
+}
+
 func (s service) Me(ctx context.Context, accessKey AccessKey) (entities.Owner, error) {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Me")).End()
 	defer s.log.Sync()
@@ -0,0 +1,276 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/internal/storage/memory"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/validation"
+)
+
+const password = "password"
+
+var limits = auth.LoginLimits{
+	FreeAttempts:   3,
+	IPFreeAttempts: 5,
+	BackoffBase:    time.Minute,
+	MaxLockout:     time.Hour,
+	Window:         time.Hour,
+}
+
+// twoFactorOff is TwoFactorRepository of owners without 2FA.
+type twoFactorOff struct {
+	auth.TwoFactorRepository
+}
+
+func (twoFactorOff) ReadTwoFactor(ctx context.Context, ownerID string) (auth.TwoFactorSettings, error) {
+	return auth.TwoFactorSettings{}, nil
+}
+
+// newService returns service that keeps owners and sessions in memory,
+// repositories it does not need for logins are left out.
+func newService(t *testing.T) auth.Service {
+	t.Helper()
+	return newSellersService(t, nil, nil)
+}
+
+// newSellersService is newService that also works with sellers.
+func newSellersService(t *testing.T, sellers auth.SellersRepository, notifier auth.Notifier) auth.Service {
+	t.Helper()
+	log, err := logging.NewLogger("fatal")
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	key, err := auth.NewSecretKey([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewSecretKey: %v", err)
+	}
+	keys, err := auth.NewKeyring(key)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	repo := memory.NewRepositories()
+	return auth.NewService(repo.Owners(), sellers, twoFactorOff{}, nil, memory.NewKeyValue(), notifier, log, validation.GetValidator(), entities.MinHashCost, limits, keys)
+}
+
+func register(t *testing.T, s auth.Service) (string, auth.Session) {
+	t.Helper()
+	username := "owner_" + uuid.NewString()
+	session, err := s.Register(context.Background(), auth.RegisterInput{
+		FullName:    "Test Owner",
+		Username:    username,
+		Password:    password,
+		PhoneNumber: "+996700000000",
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return username, session
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rotates", func(t *testing.T) {
+		s := newService(t)
+		_, first := register(t, s)
+
+		second, err := s.Refresh(ctx, first.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if second.RefreshToken == first.RefreshToken {
+			t.Errorf("Refresh: refresh token is not rotated")
+		}
+		third, err := s.Refresh(ctx, second.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh of rotated token: %v", err)
+		}
+		if _, err := s.ParseAccessKey(ctx, third.AccessToken); err != nil {
+			t.Errorf("ParseAccessKey: %v", err)
+		}
+	})
+
+	t.Run("ReuseRevokesSession", func(t *testing.T) {
+		s := newService(t)
+		_, first := register(t, s)
+
+		second, err := s.Refresh(ctx, first.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if _, err := s.Refresh(ctx, first.RefreshToken); err != auth.ErrRefreshTokenReused {
+			t.Fatalf("Refresh of used token: got %v, want %v", err, auth.ErrRefreshTokenReused)
+		}
+
+		// the thief may hold the newest token, it has to stop working too
+		if _, err := s.Refresh(ctx, second.RefreshToken); err != auth.ErrSessionRevoked {
+			t.Errorf("Refresh after reuse: got %v, want %v", err, auth.ErrSessionRevoked)
+		}
+		if _, err := s.ParseAccessKey(ctx, second.AccessToken); err != auth.ErrSessionRevoked {
+			t.Errorf("ParseAccessKey after reuse: got %v, want %v", err, auth.ErrSessionRevoked)
+		}
+	})
+
+	t.Run("ReuseKeepsOtherSessions", func(t *testing.T) {
+		s := newService(t)
+		username, first := register(t, s)
+		other, err := s.Login(ctx, auth.LoginInput{Username: username, Password: password})
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+
+		if _, err := s.Refresh(ctx, first.RefreshToken); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if _, err := s.Refresh(ctx, first.RefreshToken); err != auth.ErrRefreshTokenReused {
+			t.Fatalf("Refresh of used token: got %v, want %v", err, auth.ErrRefreshTokenReused)
+		}
+		if _, err := s.Refresh(ctx, other.RefreshToken); err != nil {
+			t.Errorf("Refresh of other session: %v", err)
+		}
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		s := newService(t)
+		_, session := register(t, s)
+
+		key, err := s.ParseAccessKey(ctx, session.AccessToken)
+		if err != nil {
+			t.Fatalf("ParseAccessKey: %v", err)
+		}
+		if err := s.Logout(ctx, key); err != nil {
+			t.Fatalf("Logout: %v", err)
+		}
+		if _, err := s.Refresh(ctx, session.RefreshToken); err != auth.ErrSessionRevoked {
+			t.Errorf("Refresh after logout: got %v, want %v", err, auth.ErrSessionRevoked)
+		}
+	})
+
+	t.Run("LogoutAll", func(t *testing.T) {
+		s := newService(t)
+		username, first := register(t, s)
+		second, err := s.Login(ctx, auth.LoginInput{Username: username, Password: password})
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+
+		key, err := s.ParseAccessKey(ctx, second.AccessToken)
+		if err != nil {
+			t.Fatalf("ParseAccessKey: %v", err)
+		}
+		if err := s.LogoutAll(ctx, key); err != nil {
+			t.Fatalf("LogoutAll: %v", err)
+		}
+		for _, session := range []auth.Session{first, second} {
+			if _, err := s.Refresh(ctx, session.RefreshToken); err != auth.ErrSessionRevoked {
+				t.Errorf("Refresh after logout: got %v, want %v", err, auth.ErrSessionRevoked)
+			}
+			if _, err := s.ParseAccessKey(ctx, session.AccessToken); err != auth.ErrSessionRevoked {
+				t.Errorf("ParseAccessKey after logout: got %v, want %v", err, auth.ErrSessionRevoked)
+			}
+		}
+	})
+
+	t.Run("RefreshTokenIsNotAccessToken", func(t *testing.T) {
+		s := newService(t)
+		_, session := register(t, s)
+
+		if _, err := s.ParseAccessKey(ctx, session.RefreshToken); err != auth.ErrInvalidAccessToken {
+			t.Errorf("ParseAccessKey: got %v, want %v", err, auth.ErrInvalidAccessToken)
+		}
+	})
+}
+
+func TestLoginLimits(t *testing.T) {
+	ctx := context.Background()
+
+	login := func(s auth.Service, username, password, ip string) error {
+		_, err := s.Login(ctx, auth.LoginInput{Username: username, Password: password, IP: ip})
+		return err
+	}
+	fail := func(t *testing.T, s auth.Service, username, ip string, times int) {
+		t.Helper()
+		for i := 0; i < times; i++ {
+			if err := login(s, username, "wrong password", ip); err != auth.ErrInvalidCredentials {
+				t.Fatalf("Login with wrong password: got %v, want %v", err, auth.ErrInvalidCredentials)
+			}
+		}
+	}
+
+	t.Run("LocksUsername", func(t *testing.T) {
+		s := newService(t)
+		username, _ := register(t, s)
+
+		fail(t, s, username, "10.0.0.1", limits.FreeAttempts)
+		// locked even with the right password and from another ip
+		if err := login(s, username, password, "10.0.0.2"); err != auth.ErrLoginLocked {
+			t.Errorf("Login: got %v, want %v", err, auth.ErrLoginLocked)
+		}
+	})
+
+	t.Run("LocksUnknownUsername", func(t *testing.T) {
+		s := newService(t)
+		username := "nobody_" + uuid.NewString()
+
+		// otherwise locks would tell which usernames exist
+		fail(t, s, username, "", limits.FreeAttempts)
+		if err := login(s, username, password, ""); err != auth.ErrLoginLocked {
+			t.Errorf("Login: got %v, want %v", err, auth.ErrLoginLocked)
+		}
+	})
+
+	t.Run("LocksIP", func(t *testing.T) {
+		s := newService(t)
+		username, _ := register(t, s)
+
+		// every username stays under its limit, the ip does not
+		for i := 0; i < limits.IPFreeAttempts; i++ {
+			fail(t, s, "nobody_"+uuid.NewString(), "10.0.0.1", 1)
+		}
+		if err := login(s, username, password, "10.0.0.1"); err != auth.ErrLoginLocked {
+			t.Errorf("Login from locked ip: got %v, want %v", err, auth.ErrLoginLocked)
+		}
+		if err := login(s, username, password, "10.0.0.2"); err != nil {
+			t.Errorf("Login from other ip: %v", err)
+		}
+	})
+
+	t.Run("SuccessResetsUsername", func(t *testing.T) {
+		s := newService(t)
+		username, _ := register(t, s)
+
+		fail(t, s, username, "", limits.FreeAttempts-1)
+		if err := login(s, username, password, ""); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		fail(t, s, username, "", limits.FreeAttempts-1)
+		if err := login(s, username, password, ""); err != nil {
+			t.Errorf("Login after reset: %v", err)
+		}
+	})
+
+	t.Run("SuccessKeepsIP", func(t *testing.T) {
+		s := newService(t)
+		username, _ := register(t, s)
+
+		fail(t, s, username, "10.0.0.1", limits.FreeAttempts-1)
+		if err := login(s, username, password, "10.0.0.1"); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		fail(t, s, "nobody_"+uuid.NewString(), "10.0.0.1", limits.IPFreeAttempts-limits.FreeAttempts+1)
+		if err := login(s, username, password, "10.0.0.1"); err != auth.ErrLoginLocked {
+			t.Errorf("Login from locked ip: got %v, want %v", err, auth.ErrLoginLocked)
+		}
+		if err := login(s, username, password, "10.0.0.2"); err != nil {
+			t.Errorf("Login from other ip: %v", err)
+		}
+	})
+}
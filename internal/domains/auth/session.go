@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+// Sessions live in KeyValueRepository:
+//
+//	session:<session id>  -> id of the last refresh token issued for it
+//	generation:<user id>  -> number that LogoutAll increments
+//
+// Tokens carry session id and generation, a token is valid only while
+// its session exists and its generation equals the current one.
+
+func (s service) Logout(ctx context.Context, accessKey AccessKey) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Logout")).End()
+	defer s.log.Sync()
+
+	if err := s.kv.Delete(ctx, sessionKeyPrefix+accessKey.SessionID); err != nil {
+		s.log.Error("auth:Logout - failed to delete session", logging.String("stage", "session"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:Logout - session revoked", logging.String("stage", "success"), logging.String("sessionID", accessKey.SessionID))
+	return nil
+}
+
+func (s service) LogoutAll(ctx context.Context, accessKey AccessKey) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.LogoutAll")).End()
+	defer s.log.Sync()
+
+	if err := s.revokeAll(ctx, accessKey.UserID); err != nil {
+		s.log.Error("auth:LogoutAll - failed to revoke sessions", logging.String("stage", "session"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:LogoutAll - all sessions revoked", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return nil
+}
+
+func (s service) RevokeSessions(ctx context.Context, userID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.RevokeSessions")).End()
+	defer s.log.Sync()
+
+	if err := s.revokeAll(ctx, userID); err != nil {
+		s.log.Error("auth:RevokeSessions - failed to revoke sessions", logging.String("stage", "session"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:RevokeSessions - all sessions revoked", logging.String("stage", "success"), logging.String("userID", userID))
+	return nil
+}
+
+// revokeAll invalidates every token of the user issued so far.
+func (s service) revokeAll(ctx context.Context, userID string) error {
+	gen, err := s.generation(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// generation must outlive every token, so it never expires
+	return s.kv.Set(ctx, generationKeyPrefix+userID, strconv.Itoa(gen+1), 0)
+}
+
+// startSession starts a new session and issues its first tokens.
+func (s service) startSession(ctx context.Context, claims AccessKey) (Session, error) {
+	sessionID, refreshID := uuid.NewString(), uuid.NewString()
+
+	session, err := s.continueSession(ctx, claims, sessionID, refreshID)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if err := s.kv.Set(ctx, sessionKeyPrefix+sessionID, refreshID, RefreshKeyTTL); err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// continueSession issues tokens of the session with refresh key refreshID,
+// it has to be remembered for the session already, see useRefreshKey.
+func (s service) continueSession(ctx context.Context, claims AccessKey, sessionID, refreshID string) (Session, error) {
+	gen, err := s.generation(ctx, claims.UserID)
+	if err != nil {
+		return Session{}, err
+	}
+	claims.SessionID, claims.Generation = sessionID, gen
+
+	return generateSession(claims, refreshID, s.keys)
+}
+
+// useRefreshKey replaces refresh key, that has to be the last one issued
+// for its session, with a new one and returns its id. An older key means
+// that it was stolen or leaked, so the whole session is revoked. The
+// replacement is a single swap, so of two refreshes with the same key
+// only one succeeds and the other one is seen as reuse.
+func (s service) useRefreshKey(ctx context.Context, claims RefreshKey) (string, error) {
+	if err := s.checkGeneration(ctx, claims.UserID, claims.Generation); err != nil {
+		return "", err
+	}
+
+	key := sessionKeyPrefix + claims.SessionID
+	next := uuid.NewString()
+	swapped, err := s.kv.Swap(ctx, key, claims.Id, next, RefreshKeyTTL)
+	if err != nil {
+		return "", err
+	}
+	if swapped {
+		return next, nil
+	}
+
+	if _, err := s.kv.Get(ctx, key); err != nil {
+		if err == ErrKeyNotFound {
+			return "", ErrSessionRevoked
+		}
+		return "", err
+	}
+
+	if err := s.kv.Delete(ctx, key); err != nil {
+		return "", err
+	}
+	s.log.Warn("auth:Refresh - refresh token reuse detected", logging.String("stage", "session"), logging.String("sessionID", claims.SessionID), logging.String("userID", claims.UserID))
+	return "", ErrRefreshTokenReused
+}
+
+func (s service) checkAccessKey(ctx context.Context, claims AccessKey) error {
+	if err := s.checkGeneration(ctx, claims.UserID, claims.Generation); err != nil {
+		return err
+	}
+
+	if _, err := s.kv.Get(ctx, sessionKeyPrefix+claims.SessionID); err != nil {
+		if err == ErrKeyNotFound {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s service) checkGeneration(ctx context.Context, userID string, gen int) error {
+	current, err := s.generation(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if gen != current {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s service) generation(ctx context.Context, userID string) (int, error) {
+	val, err := s.kv.Get(ctx, generationKeyPrefix+userID)
+	if err != nil {
+		if err == ErrKeyNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(val)
+}
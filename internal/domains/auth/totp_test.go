@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of RFC 4226 and RFC 6238 test vectors.
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("hotp(%d): got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	// RFC 6238 appendix B, codes are the last 6 of 8 digits
+	at := time.Unix(1111111109, 0)
+	step := at.Unix() / totpPeriod
+
+	cases := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		lastStep int64
+		step     int64
+		ok       bool
+	}{
+		{"RFCVector", rfcSecret, "081804", at, 0, step, true},
+		{"SecondRFCVector", rfcSecret, "050471", time.Unix(1111111111, 0), 0, step + 1, true},
+		{"LowercaseSecret", strings.ToLower(rfcSecret), "081804", at, 0, step, true},
+		{"PreviousPeriod", rfcSecret, "081804", at.Add(totpPeriod * time.Second), 0, step, true},
+		{"NextPeriod", rfcSecret, "081804", at.Add(-totpPeriod * time.Second), 0, step, true},
+		{"TooOld", rfcSecret, "081804", at.Add(2 * totpPeriod * time.Second), 0, 0, false},
+		{"Replayed", rfcSecret, "081804", at, step, 0, false},
+		{"AfterOlderStep", rfcSecret, "081804", at, step - 1, step, true},
+		{"WrongCode", rfcSecret, "000000", at, 0, 0, false},
+		{"WrongLength", rfcSecret, "81804", at, 0, 0, false},
+		{"MalformedSecret", "not base32!", "081804", at, 0, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			step, ok := verifyTOTP(c.secret, c.code, c.now, c.lastStep)
+			if ok != c.ok || step != c.step {
+				t.Errorf("verifyTOTP: got %d %v, want %d %v", step, ok, c.step, c.ok)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("DecodeString: %v", err)
+	}
+	if len(key) != 20 {
+		t.Errorf("key length: got %d, want 20", len(key))
+	}
+
+	now := time.Now()
+	code := hotp(key, now.Unix()/totpPeriod)
+	if _, ok := verifyTOTP(secret, code, now, 0); !ok {
+		t.Errorf("verifyTOTP: code of generated secret is rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	u, err := url.Parse(totpURI("john doe", rfcSecret))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/accounter:john doe" {
+		t.Errorf("uri: got %s", u)
+	}
+
+	q := u.Query()
+	want := map[string]string{"secret": rfcSecret, "issuer": "accounter", "algorithm": "SHA1", "digits": "6", "period": "30"}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s: got %q, want %q", k, q.Get(k), v)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	if len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("generateRecoveryCodes: got %d codes and %d hashes, want 10", len(codes), len(hashes))
+	}
+
+	format := regexp.MustCompile(`^[a-hjkmnp-z02-9]{5}-[a-hjkmnp-z02-9]{5}$`)
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not match %s", code, format)
+		}
+		if seen[code] {
+			t.Errorf("code %q is repeated", code)
+		}
+		seen[code] = true
+
+		if hashes[i] != hashRecoveryCode(code) {
+			t.Errorf("hash of %q: got %s, want %s", code, hashes[i], hashRecoveryCode(code))
+		}
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := hashRecoveryCode("k3j9d-x7q2m")
+	for _, code := range []string{"k3j9dx7q2m", "K3J9D-X7Q2M", "k3j9d x7q2m", " k3j9d-x7q2m "} {
+		if got := hashRecoveryCode(code); got != want {
+			t.Errorf("hashRecoveryCode(%q): got %s, want %s", code, got, want)
+		}
+	}
+	if hashRecoveryCode("k3j9d-x7q2n") == want {
+		t.Errorf("hashRecoveryCode: different codes have the same hash")
+	}
+}
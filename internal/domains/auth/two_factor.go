@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+func (s service) LoginTwoFactor(ctx context.Context, input TwoFactorLoginInput) (Session, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.LoginTwoFactor")).End()
+	defer s.log.Sync()
+
+	key := challengeKeyPrefix + hashCode(input.ChallengeToken)
+	ownerID, attempts, err := s.readChallenge(ctx, key)
+	if err != nil {
+		if err == ErrInvalidChallenge {
+			s.log.Debug("auth:LoginTwoFactor - challenge not found", logging.String("stage", "repository"))
+			return Session{}, err
+		}
+		s.log.Error("auth:LoginTwoFactor - failed to read challenge", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	o, err := s.ownersRepo.Read(ctx, ownerID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:LoginTwoFactor - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return Session{}, ErrInvalidChallenge
+		}
+		s.log.Error("auth:LoginTwoFactor - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	// wrong codes count as failed logins, so the lockout of Login also
+	// covers challenges started one after another
+	limiter := s.loginLimiter(o.Username, input.IP)
+	if err := limiter.check(ctx); err != nil {
+		if err == ErrLoginLocked {
+			s.log.Debug("auth:LoginTwoFactor - login is locked", logging.String("stage", "limiter"), logging.String("username", o.Username), logging.String("ip", input.IP))
+			return Session{}, err
+		}
+		s.log.Error("auth:LoginTwoFactor - failed to check attempts", logging.String("stage", "limiter"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	if err := s.checkSecondFactor(ctx, ownerID, input.Code, input.RecoveryCode); err != nil {
+		if err != ErrWrongCode {
+			s.log.Error("auth:LoginTwoFactor - failed to check code", logging.String("stage", "2fa"), logging.Error("err", err))
+			return Session{}, ErrDefault
+		}
+
+		s.log.Debug("auth:LoginTwoFactor - wrong code", logging.String("stage", "2fa"), logging.String("userID", ownerID))
+		s.loginFailed(ctx, limiter)
+		attempts++
+		if attempts >= TwoFactorMaxAttempts {
+			if err := s.kv.Delete(ctx, key); err != nil {
+				s.log.Error("auth:LoginTwoFactor - failed to delete challenge", logging.String("stage", "repository"), logging.Error("err", err))
+			}
+			return Session{}, ErrTooManyAttempts
+		}
+		// ttl is not extended, so attempts do not prolong the challenge
+		if err := s.kv.Set(ctx, key, ownerID+":"+strconv.Itoa(attempts), TwoFactorChallengeTTL); err != nil {
+			s.log.Error("auth:LoginTwoFactor - failed to count attempt", logging.String("stage", "repository"), logging.Error("err", err))
+		}
+		return Session{}, ErrWrongCode
+	}
+
+	if err := s.kv.Delete(ctx, key); err != nil {
+		s.log.Error("auth:LoginTwoFactor - failed to delete challenge", logging.String("stage", "repository"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	if err := limiter.succeeded(ctx); err != nil {
+		s.log.Error("auth:LoginTwoFactor - failed to reset attempts", logging.String("stage", "limiter"), logging.Error("err", err))
+	}
+
+	session, err := s.startSession(ctx, ownerAccessKey(o))
+	if err != nil {
+		s.log.Error("auth:LoginTwoFactor - failed to generate session", logging.String("stage", "jwt"), logging.Error("err", err))
+		return Session{}, ErrDefault
+	}
+
+	s.log.Info("auth:LoginTwoFactor - successfully logged in", logging.String("stage", "success"), logging.String("username", o.Username))
+	return session, nil
+}
+
+func (s service) EnrollTwoFactor(ctx context.Context, accessKey AccessKey) (TwoFactorEnrollment, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.EnrollTwoFactor")).End()
+	defer s.log.Sync()
+
+	o, err := s.ownersRepo.Read(ctx, accessKey.UserID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:EnrollTwoFactor - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return TwoFactorEnrollment{}, err
+		}
+		s.log.Error("auth:EnrollTwoFactor - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return TwoFactorEnrollment{}, ErrDefault
+	}
+
+	twoFA, err := s.twoFARepo.ReadTwoFactor(ctx, accessKey.UserID)
+	if err != nil {
+		s.log.Error("auth:EnrollTwoFactor - failed to read two factor settings", logging.String("stage", "repository"), logging.Error("err", err))
+		return TwoFactorEnrollment{}, ErrDefault
+	}
+	if twoFA.Enabled {
+		s.log.Debug("auth:EnrollTwoFactor - already enabled", logging.String("stage", "validation"), logging.String("userID", accessKey.UserID))
+		return TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		s.log.Error("auth:EnrollTwoFactor - failed to generate secret", logging.String("stage", "2fa"), logging.Error("err", err))
+		return TwoFactorEnrollment{}, ErrDefault
+	}
+
+	if err := s.twoFARepo.SetPendingSecret(ctx, accessKey.UserID, secret); err != nil {
+		if err == ErrTwoFactorEnabled {
+			return TwoFactorEnrollment{}, err
+		}
+		s.log.Error("auth:EnrollTwoFactor - failed to save secret", logging.String("stage", "repository"), logging.Error("err", err))
+		return TwoFactorEnrollment{}, ErrDefault
+	}
+
+	s.log.Info("auth:EnrollTwoFactor - enrollment started", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return TwoFactorEnrollment{Secret: secret, URI: totpURI(o.Username, secret)}, nil
+}
+
+func (s service) ConfirmTwoFactor(ctx context.Context, accessKey AccessKey, input TwoFactorCodeInput) ([]string, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ConfirmTwoFactor")).End()
+	defer s.log.Sync()
+
+	twoFA, err := s.twoFARepo.ReadTwoFactor(ctx, accessKey.UserID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:ConfirmTwoFactor - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return nil, err
+		}
+		s.log.Error("auth:ConfirmTwoFactor - failed to read two factor settings", logging.String("stage", "repository"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+	if twoFA.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if twoFA.Secret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	if err := s.checkTOTP(ctx, accessKey.UserID, twoFA.Secret, input.Code); err != nil {
+		if err == ErrWrongCode {
+			s.log.Debug("auth:ConfirmTwoFactor - wrong code", logging.String("stage", "2fa"), logging.String("userID", accessKey.UserID))
+			return nil, err
+		}
+		s.log.Error("auth:ConfirmTwoFactor - failed to check code", logging.String("stage", "2fa"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	codes, hashes, err := generateRecoveryCodes(TwoFactorRecoveryCodes)
+	if err != nil {
+		s.log.Error("auth:ConfirmTwoFactor - failed to generate recovery codes", logging.String("stage", "2fa"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	if err := s.twoFARepo.EnableTwoFactor(ctx, accessKey.UserID, hashes); err != nil {
+		s.log.Error("auth:ConfirmTwoFactor - failed to enable two factor", logging.String("stage", "repository"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	s.log.Info("auth:ConfirmTwoFactor - two factor enabled", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return codes, nil
+}
+
+func (s service) RegenerateRecoveryCodes(ctx context.Context, accessKey AccessKey, input TwoFactorCodeInput) ([]string, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.RegenerateRecoveryCodes")).End()
+	defer s.log.Sync()
+
+	if err := s.checkSecondFactor(ctx, accessKey.UserID, input.Code, ""); err != nil {
+		switch err {
+		case ErrWrongCode, ErrTwoFactorDisabled, ErrIdNotFound:
+			s.log.Debug("auth:RegenerateRecoveryCodes - code not accepted", logging.String("stage", "2fa"), logging.Error("err", err))
+			return nil, err
+		}
+		s.log.Error("auth:RegenerateRecoveryCodes - failed to check code", logging.String("stage", "2fa"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	codes, hashes, err := generateRecoveryCodes(TwoFactorRecoveryCodes)
+	if err != nil {
+		s.log.Error("auth:RegenerateRecoveryCodes - failed to generate recovery codes", logging.String("stage", "2fa"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	if err := s.twoFARepo.ReplaceRecoveryCodes(ctx, accessKey.UserID, hashes); err != nil {
+		s.log.Error("auth:RegenerateRecoveryCodes - failed to save recovery codes", logging.String("stage", "repository"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	s.log.Info("auth:RegenerateRecoveryCodes - recovery codes replaced", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return codes, nil
+}
+
+func (s service) DisableTwoFactor(ctx context.Context, accessKey AccessKey, input DisableTwoFactorInput) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.DisableTwoFactor")).End()
+	defer s.log.Sync()
+
+	o, err := s.ownersRepo.Read(ctx, accessKey.UserID)
+	if err != nil {
+		if err == ErrIdNotFound {
+			s.log.Debug("auth:DisableTwoFactor - owner not found", logging.String("stage", "repository"), logging.Error("err", err))
+			return err
+		}
+		s.log.Error("auth:DisableTwoFactor - failed to read owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := o.ComparePassword(input.Password); err != nil {
+		s.log.Debug("auth:DisableTwoFactor - wrong password", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrWrongPassword
+	}
+
+	if err := s.checkSecondFactor(ctx, accessKey.UserID, input.Code, input.RecoveryCode); err != nil {
+		switch err {
+		case ErrWrongCode, ErrTwoFactorDisabled:
+			s.log.Debug("auth:DisableTwoFactor - code not accepted", logging.String("stage", "2fa"), logging.Error("err", err))
+			return err
+		}
+		s.log.Error("auth:DisableTwoFactor - failed to check code", logging.String("stage", "2fa"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := s.twoFARepo.DisableTwoFactor(ctx, accessKey.UserID); err != nil {
+		s.log.Error("auth:DisableTwoFactor - failed to disable two factor", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("auth:DisableTwoFactor - two factor disabled", logging.String("stage", "success"), logging.String("userID", accessKey.UserID))
+	return nil
+}
+
+// startChallenge returns a token that proves the password was correct.
+func (s service) startChallenge(ctx context.Context, ownerID string) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.kv.Set(ctx, challengeKeyPrefix+hashCode(token), ownerID+":0", TwoFactorChallengeTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s service) readChallenge(ctx context.Context, key string) (string, int, error) {
+	val, err := s.kv.Get(ctx, key)
+	if err != nil {
+		if err == ErrKeyNotFound {
+			return "", 0, ErrInvalidChallenge
+		}
+		return "", 0, err
+	}
+
+	i := strings.LastIndexByte(val, ':')
+	if i < 0 {
+		return "", 0, ErrInvalidChallenge
+	}
+	attempts, err := strconv.Atoi(val[i+1:])
+	if err != nil {
+		return "", 0, err
+	}
+	return val[:i], attempts, nil
+}
+
+// checkSecondFactor accepts either a totp code or an unused recovery
+// code, recovery code is burned on success.
+func (s service) checkSecondFactor(ctx context.Context, ownerID, code, recoveryCode string) error {
+	twoFA, err := s.twoFARepo.ReadTwoFactor(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if !twoFA.Enabled {
+		return ErrTwoFactorDisabled
+	}
+
+	if code != "" {
+		return s.checkTOTP(ctx, ownerID, twoFA.Secret, code)
+	}
+	if recoveryCode != "" {
+		return s.twoFARepo.UseRecoveryCode(ctx, ownerID, hashRecoveryCode(recoveryCode))
+	}
+	return ErrWrongCode
+}
+
+// checkTOTP rejects codes of time steps that were already used.
+func (s service) checkTOTP(ctx context.Context, ownerID, secret, code string) error {
+	key := totpStepKeyPrefix + ownerID
+
+	var lastStep int64
+	val, err := s.kv.Get(ctx, key)
+	switch err {
+	case nil:
+		if lastStep, err = strconv.ParseInt(val, 10, 64); err != nil {
+			return err
+		}
+	case ErrKeyNotFound:
+	default:
+		return err
+	}
+
+	step, ok := verifyTOTP(secret, code, time.Now(), lastStep)
+	if !ok {
+		return ErrWrongCode
+	}
+
+	// older steps can't be accepted after the skew window anyway
+	ttl := time.Duration(totpPeriod*(2*totpSkew+1)) * time.Second
+	return s.kv.Set(ctx, key, strconv.FormatInt(step, 10), ttl)
+}
@@ -1,6 +1,6 @@
 package categories
 
-import "errors"
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 
 const (
 	PackageName = "internal/domains/categories/"
@@ -16,5 +16,9 @@ const (
 )
 
 var (
-	ErrDefault = errors.New("что-то пошло не так")
+	ErrDefault  = apperr.New(apperr.KindInternal, "categories.default", "что-то пошло не так")
+	ErrNotFound = apperr.New(apperr.KindNotFound, "categories.notFound", "категория не найдена")
+	// ErrParentDeleted is returned when category is restored while its
+	// store or parent category is still in trash.
+	ErrParentDeleted = apperr.New(apperr.KindConflict, "categories.parentDeleted", "сначала восстановите магазин или родительскую категорию")
 )
@@ -0,0 +1,14 @@
+package categories
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+// SortKey is the cursor key of category in a list sorted by sortBy.
+func SortKey(category entities.Category, sortBy string) *string {
+	switch sortBy {
+	case SortByName:
+		return &category.Name
+	case SortByArticle:
+		return category.Article
+	}
+	return entities.TimeKey(category.CreatedAt)
+}
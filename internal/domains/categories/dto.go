@@ -16,10 +16,18 @@ type (
 		StoreID          entities.OptField[string] `json:"storeID" validate:"uuid4"`
 		Text             entities.OptField[string] `json:"text" validate:"max=255"`
 		ParentCategoryID entities.OptField[string] `json:"parentCategoryID" validate:"uuid4"`
+		// Deleted lists categories in trash instead of live ones
+		Deleted entities.OptField[bool] `json:"deleted"`
 
 		// Pagination
 		PageNumber entities.OptField[uint64] `json:"pageNumber"`
 		PageSize   entities.OptField[uint]   `json:"pageSize"`
+		// Cursor continues a list from a page read before, PageNumber is
+		// ignored and sorting is taken from the cursor
+		Cursor entities.OptField[entities.Cursor] `json:"-"`
+		// WithTotal counts all categories matching filters when reading with
+		// Cursor, numbered pages are always counted
+		WithTotal entities.OptField[bool] `json:"withTotal"`
 
 		// Sorting
 		SortBy    entities.OptField[string] `json:"sortBy"`    // name, article, createdAt
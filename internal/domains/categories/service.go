@@ -2,10 +2,13 @@ package categories
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
 	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
 )
@@ -13,28 +16,78 @@ import (
 type (
 	CategoriesRepository interface {
 		Create(ctx context.Context, input entities.Category) (entities.Category, error)
+		// ReadBy returns categories sorted by SortBy and then by id in the
+		// same order, NULL articles go last in ascending order. With Cursor
+		// set it reads PageSize categories after the cursor, or before it
+		// for backward cursors, in the order of sorting.
 		ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Category, error)
+		// Count counts categories matching filters, pagination is ignored.
+		Count(ctx context.Context, filters ReadByInput) (uint64, error)
 		Update(ctx context.Context, changeset UpdateInput) (entities.Category, error)
+		// Delete moves category and its subcategories to trash, ReadBy
+		// does not show them unless asked for deleted categories.
 		Delete(ctx context.Context, id string) error
+		// Restore returns ErrNotFound if category is not in trash and
+		// ErrParentDeleted if its store or parent is.
+		Restore(ctx context.Context, id string) error
+		// Purge removes categories deleted before deletedBefore for good.
+		Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 	}
 
 	Service interface {
 		Create(ctx context.Context, input CreateInput) (entities.Category, error)
-		ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Category, error)
+		ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Category], error)
 		Update(ctx context.Context, changeset UpdateInput) (entities.Category, error)
 		Delete(ctx context.Context, id string) error
+		Restore(ctx context.Context, id string) error
+		Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 	}
 
 	service struct {
-		repo CategoriesRepository
-		log  *logging.Logger
+		repo  CategoriesRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
 	}
 )
 
 var _ Service = (*service)(nil)
 
-func NewService(repo CategoriesRepository, log *logging.Logger) service {
-	return service{repo: repo, log: log}
+func NewService(repo CategoriesRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// auditFields are fields of a category kept in audit log.
+func auditFields(category entities.Category) map[string]any {
+	fields := map[string]any{
+		"name":             category.Name,
+		"article":          nil,
+		"parentCategoryID": nil,
+	}
+	if category.Article != nil {
+		fields["article"] = *category.Article
+	}
+	if category.ParentCategory != nil {
+		fields["parentCategoryID"] = category.ParentCategory.ID.String()
+	}
+	if category.Store != nil {
+		fields["storeID"] = category.Store.ID.String()
+	}
+	return fields
+}
+
+// readOne returns category by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, id string) (entities.Category, error) {
+	filters := ReadByInput{}
+	filters.ID.Set(id)
+	list, err := s.repo.ReadBy(ctx, filters)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	if len(list) == 0 {
+		return entities.Category{}, ErrNotFound
+	}
+	return list[0], nil
 }
 
 func (s service) Create(ctx context.Context, input CreateInput) (entities.Category, error) {
@@ -48,7 +101,19 @@ func (s service) Create(ctx context.Context, input CreateInput) (entities.Catego
 	if input.ParentCategoryID != nil {
 		category.ParentCategory = &entities.Category{ID: uuid.MustParse(*input.ParentCategoryID)}
 	}
-	category, err := s.repo.Create(ctx, category)
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		category, err = s.repo.Create(ctx, category)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityCategory,
+			EntityID:   category.ID.String(),
+			After:      auditFields(category),
+		})
+	})
 	if err != nil {
 		s.log.Debug("categories:Create - failed to create category", logging.String("stage", "repository"), logging.Error("err", err))
 		return entities.Category{}, ErrDefault
@@ -58,31 +123,39 @@ func (s service) Create(ctx context.Context, input CreateInput) (entities.Catego
 	return category, nil
 }
 
-func (s service) ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Category, error) {
+func (s service) ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Category], error) {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
 	defer s.log.Sync()
 
 	// validate filters
 	pageNumber, ok := filters.PageNumber.Get()
 	if !ok {
-		filters.PageNumber.Set(1)
+		pageNumber = 1
+		filters.PageNumber.Set(pageNumber)
 	} else if pageNumber < 1 {
 		s.log.Debug("categories:ReadBy - pageNumber must be greater than 0", logging.String("stage", "validation"))
-		return nil, errors.New("номер страницы должен быть меньше 1")
+		return entities.Page[entities.Category]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "categories.pageNumberTooSmall", "номер страницы должен быть меньше 1")
 	}
 
 	pageSize, ok := filters.PageSize.Get()
 	if !ok {
-		filters.PageSize.Set(10)
+		pageSize = 10
+		filters.PageSize.Set(pageSize)
 	} else if pageSize < 1 || pageSize > 100 {
 		s.log.Debug("categories:ReadBy - pageSize must be between 1 and 100", logging.String("stage", "validation"))
-		return nil, errors.New("размер страницы должен быть между 1 и 100")
+		return entities.Page[entities.Category]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "categories.pageSizeOutOfRange", "размер страницы должен быть между 1 и 100")
 	}
 
 	text, _ := filters.Text.Get()
 	if len(text) > 255 {
 		s.log.Debug("categories:ReadBy - text must be less than 255 characters", logging.String("stage", "validation"))
-		return nil, errors.New("текст должен быть меньше 255 символов")
+		return entities.Page[entities.Category]{}, apperr.NewField(apperr.KindInvalid, "text", "categories.textTooLong", "текст должен быть меньше 255 символов")
+	}
+
+	cursor, byCursor := filters.Cursor.Get()
+	if byCursor {
+		filters.SortBy.Set(cursor.SortBy)
+		filters.SortOrder.Set(cursor.SortOrder)
 	}
 
 	sortBy, ok := filters.SortBy.Get()
@@ -91,10 +164,11 @@ func (s service) ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Ca
 		case SortByName, SortByArticle, SortByCreatedAt:
 		default:
 			s.log.Debug("categories:ReadBy - sortBy must be one of name, article, createdAt", logging.String("stage", "validation"))
-			return nil, errors.New("сортировка должна быть одной из name, article, createdAt")
+			return entities.Page[entities.Category]{}, apperr.NewField(apperr.KindInvalid, "sortBy", "categories.sortByUnknown", "сортировка должна быть одной из name, article, createdAt")
 		}
 	} else {
-		filters.SortBy.Set(SortByCreatedAt)
+		sortBy = SortByCreatedAt
+		filters.SortBy.Set(sortBy)
 	}
 
 	sortOrder, ok := filters.SortOrder.Get()
@@ -103,20 +177,60 @@ func (s service) ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Ca
 		case SortOrderAsc, SortOrderDesc:
 		default:
 			s.log.Debug("categories:ReadBy - sortOrder must be one of asc, desc", logging.String("stage", "validation"))
-			return nil, errors.New("сортировка должна быть одной из asc, desc")
+			return entities.Page[entities.Category]{}, apperr.NewField(apperr.KindInvalid, "sortOrder", "categories.sortOrderUnknown", "сортировка должна быть одной из asc, desc")
 		}
 	} else {
-		filters.SortOrder.Set(SortOrderDesc)
+		sortOrder = SortOrderDesc
+		filters.SortOrder.Set(sortOrder)
+	}
+
+	if byCursor {
+		// one more category tells if there is a page after this one
+		filters.PageNumber.Unset()
+		filters.PageSize.Set(pageSize + 1)
 	}
 
 	categories, err := s.repo.ReadBy(ctx, filters)
 	if err != nil {
 		s.log.Error("categories:ReadBy - failed to read categories", logging.String("stage", "repository"), logging.Error("err", err))
-		return nil, ErrDefault
+		return entities.Page[entities.Category]{}, ErrDefault
+	}
+
+	hasPrev, hasNext := pageNumber > 1, false
+	if byCursor {
+		var more bool
+		categories, more = entities.TrimPage(categories, int(pageSize), cursor.Backward)
+		hasPrev, hasNext = true, more
+		if cursor.Backward {
+			hasPrev, hasNext = more, true
+		}
+	}
+
+	var total *uint64
+	// numbered pages are always counted, clients show how many there are
+	if withTotal, _ := filters.WithTotal.Get(); withTotal || !byCursor {
+		count, err := s.repo.Count(ctx, filters)
+		if err != nil {
+			s.log.Error("categories:ReadBy - failed to count categories", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Page[entities.Category]{}, ErrDefault
+		}
+		total = &count
+		if !byCursor {
+			hasNext = pageNumber*uint64(pageSize) < count
+		}
+	}
+
+	page := entities.NewPage(categories, hasPrev, hasNext, func(category entities.Category) entities.Cursor {
+		return entities.Cursor{SortBy: sortBy, SortOrder: sortOrder, Key: SortKey(category, sortBy), ID: category.ID}
+	})
+	page.Total = total
+	page.PageSize = pageSize
+	if !byCursor {
+		page.PageNumber = pageNumber
 	}
 
 	s.log.Info("categories:ReadBy - categories read", logging.String("stage", "repository"), logging.Int("count", len(categories)))
-	return categories, nil
+	return page, nil
 }
 
 func (s service) Update(ctx context.Context, changeset UpdateInput) (entities.Category, error) {
@@ -127,17 +241,38 @@ func (s service) Update(ctx context.Context, changeset UpdateInput) (entities.Ca
 	name, ok := changeset.Name.Get()
 	if ok && len(name) > 255 {
 		s.log.Debug("categories:Update - name must be less than 255 characters", logging.String("stage", "validation"))
-		return entities.Category{}, errors.New("имя должно быть меньше 255 символов")
+		return entities.Category{}, apperr.NewField(apperr.KindValidation, "name", "categories.nameTooLong", "имя должно быть меньше 255 символов")
 	}
 
 	article, ok := changeset.Article.Get()
 	if ok && article != nil && len(*article) > 100 {
 		s.log.Debug("categories:Update - article must be less than 100 characters", logging.String("stage", "validation"))
-		return entities.Category{}, errors.New("артикул должен быть меньше 100 символов")
+		return entities.Category{}, apperr.NewField(apperr.KindValidation, "article", "categories.articleTooLong", "артикул должен быть меньше 100 символов")
 	}
 
-	c, err := s.repo.Update(ctx, changeset)
+	var c entities.Category
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, changeset.ID)
+		if err != nil {
+			return err
+		}
+		c, err = s.repo.Update(ctx, changeset)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityCategory,
+			EntityID:   changeset.ID,
+			Before:     auditFields(before),
+			After:      auditFields(c),
+		})
+	})
 	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("categories:Update - category not found", logging.String("stage", "repository"), logging.String("categoryID", changeset.ID))
+			return entities.Category{}, err
+		}
 		s.log.Error("categories:Update - failed to update category", logging.String("stage", "repository"), logging.Error("err", err))
 		return entities.Category{}, ErrDefault
 	}
@@ -150,7 +285,26 @@ func (s service) Delete(ctx context.Context, id string) error {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
 	defer s.log.Sync()
 
-	if err := s.repo.Delete(ctx, id); err != nil {
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err == ErrNotFound {
+			// nothing to delete
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityCategory,
+			EntityID:   id,
+			Before:     auditFields(before),
+		})
+	})
+	if err != nil {
 		s.log.Error("categories:Delete - failed to delete category", logging.String("stage", "repository"), logging.Error("err", err))
 		return ErrDefault
 	}
@@ -158,3 +312,53 @@ func (s service) Delete(ctx context.Context, id string) error {
 	s.log.Info("categories:Delete - category deleted", logging.String("stage", "repository"), logging.String("categoryID", id))
 	return nil
 }
+
+func (s service) Restore(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Restore")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		category, err := s.readOne(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionRestore,
+			EntityType: audit.EntityCategory,
+			EntityID:   id,
+			After:      auditFields(category),
+		})
+	})
+	if err != nil {
+		switch err {
+		case ErrNotFound:
+			s.log.Debug("categories:Restore - category is not in trash", logging.String("stage", "repository"), logging.String("categoryID", id))
+			return err
+		case ErrParentDeleted:
+			s.log.Debug("categories:Restore - parent is in trash", logging.String("stage", "repository"), logging.String("categoryID", id))
+			return err
+		}
+		s.log.Error("categories:Restore - failed to restore category", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("categories:Restore - category restored", logging.String("stage", "repository"), logging.String("categoryID", id))
+	return nil
+}
+
+func (s service) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Purge")).End()
+	defer s.log.Sync()
+
+	count, err := s.repo.Purge(ctx, deletedBefore)
+	if err != nil {
+		s.log.Error("categories:Purge - failed to purge categories", logging.String("stage", "repository"), logging.Error("err", err))
+		return 0, ErrDefault
+	}
+
+	s.log.Info("categories:Purge - categories purged", logging.String("stage", "repository"), logging.Int("count", int(count)))
+	return count, nil
+}
@@ -1,22 +1,46 @@
 package domains
 
 import (
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/items"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sales"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sellers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/transfers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/warehouses"
 )
 
 type DomainCombiner struct {
 	authService       auth.Service
 	storesService     stores.Service
 	categoriesService categories.Service
+	itemsService      items.Service
+	stockService      stock.Service
+	warehousesService warehouses.Service
+	transfersService  transfers.Service
+	salesService      sales.Service
+	sellersService    sellers.Service
+	policiesService   policies.Service
+	auditService      audit.Service
 }
 
 func NewDomainCombiner(
 	cD CommonDependencies,
 	aD AuthDependencies,
 	sD StoresDependencies,
-	categoryD CategoriesDependencies) (DomainCombiner, error) {
+	categoryD CategoriesDependencies,
+	iD ItemsDependencies,
+	stockD StockDependencies,
+	wD WarehousesDependencies,
+	tD TransfersDependencies,
+	salesD SalesDependencies,
+	sellersD SellersDependencies,
+	pD PoliciesDependencies,
+	auditD AuditDependencies) (DomainCombiner, error) {
 	if err := cD.Validate(); err != nil {
 		return DomainCombiner{}, err
 	}
@@ -33,10 +57,55 @@ func NewDomainCombiner(
 		return DomainCombiner{}, err
 	}
 
+	if err := iD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := stockD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := wD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := tD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := salesD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := sellersD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := pD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	if err := auditD.Validate(); err != nil {
+		return DomainCombiner{}, err
+	}
+
+	// stores, categories and every other audited domain record changes here
+	auditService := audit.NewService(auditD.AuditRepo, cD.Log)
+
+	authService := auth.NewService(aD.OwnersRepo, aD.SellersRepo, aD.TwoFARepo, aD.APIKeysRepo, aD.KV, aD.Notifier, cD.Log, cD.Val, aD.HashCost, aD.LoginLimits, aD.Keys)
+
 	return DomainCombiner{
-		authService:       auth.NewService(aD.OwnersRepo, cD.Log, cD.Val, aD.SecretKey),
-		storesService:     stores.NewService(sD.StoresRepo, cD.Log),
-		categoriesService: categories.NewService(categoryD.CategoriesRepo, cD.Log),
+		authService:       authService,
+		storesService:     stores.NewService(sD.StoresRepo, sD.UnitOfWork, auditService, cD.Log),
+		categoriesService: categories.NewService(categoryD.CategoriesRepo, categoryD.UnitOfWork, auditService, cD.Log),
+		itemsService:      items.NewService(iD.ItemsRepo, iD.UnitOfWork, auditService, cD.Log),
+		stockService:      stock.NewService(stockD.StockRepo, stockD.UnitOfWork, auditService, cD.Log),
+		warehousesService: warehouses.NewService(wD.WarehousesRepo, wD.UnitOfWork, auditService, cD.Log),
+		transfersService:  transfers.NewService(tD.TransfersRepo, tD.UnitOfWork, auditService, cD.Log),
+		salesService:      sales.NewService(salesD.SalesRepo, salesD.UnitOfWork, auditService, cD.Log),
+		sellersService:    sellers.NewService(sellersD.SellersRepo, sellersD.UnitOfWork, auditService, authService, cD.Log),
+		policiesService:   policies.NewService(pD.ResourcesRepo, cD.Log),
+		auditService:      auditService,
 	}, nil
 }
 
@@ -51,3 +120,35 @@ func (d DomainCombiner) StoresService() stores.Service {
 func (d DomainCombiner) CategoriesService() categories.Service {
 	return d.categoriesService
 }
+
+func (d DomainCombiner) ItemsService() items.Service {
+	return d.itemsService
+}
+
+func (d DomainCombiner) StockService() stock.Service {
+	return d.stockService
+}
+
+func (d DomainCombiner) WarehousesService() warehouses.Service {
+	return d.warehousesService
+}
+
+func (d DomainCombiner) TransfersService() transfers.Service {
+	return d.transfersService
+}
+
+func (d DomainCombiner) SalesService() sales.Service {
+	return d.salesService
+}
+
+func (d DomainCombiner) SellersService() sellers.Service {
+	return d.sellersService
+}
+
+func (d DomainCombiner) PoliciesService() policies.Service {
+	return d.policiesService
+}
+
+func (d DomainCombiner) AuditService() audit.Service {
+	return d.auditService
+}
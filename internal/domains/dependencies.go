@@ -4,9 +4,19 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/items"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sales"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sellers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/transfers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/warehouses"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/validation"
 )
@@ -33,8 +43,15 @@ func (d CommonDependencies) Validate() error {
 }
 
 type AuthDependencies struct {
-	OwnersRepo auth.OwnersRepository
-	SecretKey  []byte
+	OwnersRepo  auth.OwnersRepository
+	SellersRepo auth.SellersRepository
+	TwoFARepo   auth.TwoFactorRepository
+	APIKeysRepo auth.APIKeysRepository
+	KV          auth.KeyValueRepository
+	Notifier    auth.Notifier // optional
+	HashCost    int
+	LoginLimits auth.LoginLimits
+	Keys        auth.Keyring
 }
 
 func (d AuthDependencies) Validate() error {
@@ -45,10 +62,74 @@ func (d AuthDependencies) Validate() error {
 		}
 	}
 
-	if len(d.SecretKey) < 4 {
+	if isNil(d.SellersRepo) {
 		return DependencyError{
-			Dependency:       "AuthDependencies.SecretKey",
-			BrokenConstraint: "secret key must be at least 4 bytes long",
+			Dependency:       "AuthDependencies.SellersRepo",
+			BrokenConstraint: "sellers repository cannot be nil",
+		}
+	}
+
+	if isNil(d.TwoFARepo) {
+		return DependencyError{
+			Dependency:       "AuthDependencies.TwoFARepo",
+			BrokenConstraint: "two factor repository cannot be nil",
+		}
+	}
+
+	if isNil(d.APIKeysRepo) {
+		return DependencyError{
+			Dependency:       "AuthDependencies.APIKeysRepo",
+			BrokenConstraint: "api keys repository cannot be nil",
+		}
+	}
+
+	if isNil(d.KV) {
+		return DependencyError{
+			Dependency:       "AuthDependencies.KV",
+			BrokenConstraint: "key value repository cannot be nil",
+		}
+	}
+
+	if d.HashCost < entities.MinHashCost || d.HashCost > entities.MaxHashCost {
+		return DependencyError{
+			Dependency:       "AuthDependencies.HashCost",
+			BrokenConstraint: fmt.Sprintf("hash cost must be between %d and %d", entities.MinHashCost, entities.MaxHashCost),
+		}
+	}
+
+	if d.LoginLimits.FreeAttempts < 1 || d.LoginLimits.IPFreeAttempts < 1 {
+		return DependencyError{
+			Dependency:       "AuthDependencies.LoginLimits",
+			BrokenConstraint: "free attempts must be positive",
+		}
+	}
+
+	if d.LoginLimits.BackoffBase <= 0 || d.LoginLimits.MaxLockout < d.LoginLimits.BackoffBase || d.LoginLimits.Window <= 0 {
+		return DependencyError{
+			Dependency:       "AuthDependencies.LoginLimits",
+			BrokenConstraint: "durations must be positive and max lockout not less than backoff base",
+		}
+	}
+
+	if d.Keys.IsZero() {
+		return DependencyError{
+			Dependency:       "AuthDependencies.Keys",
+			BrokenConstraint: "keyring must have a signing key",
+		}
+	}
+
+	return nil
+}
+
+type AuditDependencies struct {
+	AuditRepo audit.AuditRepository
+}
+
+func (d AuditDependencies) Validate() error {
+	if isNil(d.AuditRepo) {
+		return DependencyError{
+			Dependency:       "AuditDependencies.AuditRepo",
+			BrokenConstraint: "audit repository cannot be nil",
 		}
 	}
 
@@ -57,6 +138,7 @@ func (d AuthDependencies) Validate() error {
 
 type StoresDependencies struct {
 	StoresRepo stores.StoresRepository
+	UnitOfWork uow.UnitOfWork
 }
 
 func (d StoresDependencies) Validate() error {
@@ -67,11 +149,19 @@ func (d StoresDependencies) Validate() error {
 		}
 	}
 
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "StoresDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
 	return nil
 }
 
 type CategoriesDependencies struct {
 	CategoriesRepo categories.CategoriesRepository
+	UnitOfWork     uow.UnitOfWork
 }
 
 func (d CategoriesDependencies) Validate() error {
@@ -82,6 +172,166 @@ func (d CategoriesDependencies) Validate() error {
 		}
 	}
 
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "CategoriesDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type ItemsDependencies struct {
+	ItemsRepo  items.ItemsRepository
+	UnitOfWork uow.UnitOfWork
+}
+
+func (d ItemsDependencies) Validate() error {
+	if isNil(d.ItemsRepo) {
+		return DependencyError{
+			Dependency:       "ItemsDependencies.ItemsRepo",
+			BrokenConstraint: "items repository cannot be nil",
+		}
+	}
+
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "ItemsDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type StockDependencies struct {
+	StockRepo  stock.StockRepository
+	UnitOfWork uow.UnitOfWork
+}
+
+func (d StockDependencies) Validate() error {
+	if isNil(d.StockRepo) {
+		return DependencyError{
+			Dependency:       "StockDependencies.StockRepo",
+			BrokenConstraint: "stock repository cannot be nil",
+		}
+	}
+
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "StockDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type WarehousesDependencies struct {
+	WarehousesRepo warehouses.WarehousesRepository
+	UnitOfWork     uow.UnitOfWork
+}
+
+func (d WarehousesDependencies) Validate() error {
+	if isNil(d.WarehousesRepo) {
+		return DependencyError{
+			Dependency:       "WarehousesDependencies.WarehousesRepo",
+			BrokenConstraint: "warehouses repository cannot be nil",
+		}
+	}
+
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "WarehousesDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type TransfersDependencies struct {
+	TransfersRepo transfers.TransfersRepository
+	UnitOfWork    uow.UnitOfWork
+}
+
+func (d TransfersDependencies) Validate() error {
+	if isNil(d.TransfersRepo) {
+		return DependencyError{
+			Dependency:       "TransfersDependencies.TransfersRepo",
+			BrokenConstraint: "transfers repository cannot be nil",
+		}
+	}
+
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "TransfersDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type SalesDependencies struct {
+	SalesRepo  sales.SalesRepository
+	UnitOfWork uow.UnitOfWork
+}
+
+func (d SalesDependencies) Validate() error {
+	if isNil(d.SalesRepo) {
+		return DependencyError{
+			Dependency:       "SalesDependencies.SalesRepo",
+			BrokenConstraint: "sales repository cannot be nil",
+		}
+	}
+
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "SalesDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type SellersDependencies struct {
+	SellersRepo sellers.SellersRepository
+	UnitOfWork  uow.UnitOfWork
+}
+
+func (d SellersDependencies) Validate() error {
+	if isNil(d.SellersRepo) {
+		return DependencyError{
+			Dependency:       "SellersDependencies.SellersRepo",
+			BrokenConstraint: "sellers repository cannot be nil",
+		}
+	}
+
+	if isNil(d.UnitOfWork) {
+		return DependencyError{
+			Dependency:       "SellersDependencies.UnitOfWork",
+			BrokenConstraint: "unit of work cannot be nil",
+		}
+	}
+
+	return nil
+}
+
+type PoliciesDependencies struct {
+	ResourcesRepo policies.ResourcesRepository
+}
+
+func (d PoliciesDependencies) Validate() error {
+	if isNil(d.ResourcesRepo) {
+		return DependencyError{
+			Dependency:       "PoliciesDependencies.ResourcesRepo",
+			BrokenConstraint: "resources repository cannot be nil",
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,23 @@
+package items
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/items/"
+
+	// Sorting
+	SortByCreatedAt = "createdAt"
+	SortByName      = "name"
+	SortByPrice     = "price"
+
+	// Sorting order
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+var (
+	ErrNotFound           = apperr.New(apperr.KindNotFound, "items.notFound", "товар не найден")
+	ErrHasMovements       = apperr.New(apperr.KindConflict, "items.hasMovements", "нельзя удалить товар, по которому есть движения или продажи")
+	ErrCategoryNotInStore = apperr.NewField(apperr.KindValidation, "categoryID", "items.categoryNotInStore", "категория не найдена в магазине товара")
+	ErrDefault            = apperr.New(apperr.KindInternal, "items.default", "что-то пошло не так")
+)
@@ -0,0 +1,45 @@
+package items
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+type (
+	CreateInput struct {
+		StoreID     string  `json:"storeID" validate:"required,uuid4"`
+		CategoryID  *string `json:"categoryID,omitempty" validate:"omitempty,uuid4"`
+		Name        string  `json:"name" validate:"required,max=255"`
+		Article     string  `json:"article" validate:"required,max=100"`
+		Description string  `json:"description"`
+		IconURL     string  `json:"iconURL"`
+		Color       string  `json:"color" validate:"required,max=6"`
+		Price       float64 `json:"price" validate:"required,gt=0"`
+	}
+
+	ReadByInput struct {
+		// if ID is set, other filters will be ignored
+		ID         entities.OptField[string]  `json:"id"`
+		StoreID    entities.OptField[string]  `json:"storeID"`
+		CategoryID entities.OptField[string]  `json:"categoryID"`
+		Text       entities.OptField[string]  `json:"text"`
+		Color      entities.OptField[string]  `json:"color"`
+		PriceFrom  entities.OptField[float64] `json:"priceFrom"`
+		PriceTo    entities.OptField[float64] `json:"priceTo"`
+
+		// Pagination
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+
+		// Sorting
+		SortBy    entities.OptField[string] `json:"sortBy"`    // name, price, createdAt
+		SortOrder entities.OptField[string] `json:"sortOrder"` // asc, desc
+	}
+
+	UpdateInput struct {
+		CategoryID  entities.OptField[*string] `json:"categoryID"`
+		Name        entities.OptField[string]  `json:"name"`
+		Article     entities.OptField[string]  `json:"article"`
+		Description entities.OptField[string]  `json:"description"`
+		IconURL     entities.OptField[string]  `json:"iconURL"`
+		Color       entities.OptField[string]  `json:"color"`
+		Price       entities.OptField[float64] `json:"price"`
+	}
+)
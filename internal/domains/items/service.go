@@ -0,0 +1,358 @@
+package items
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	ItemsRepository interface {
+		// Create and Update are ErrCategoryNotInStore when category is
+		// not in the store of the item.
+		Create(ctx context.Context, item entities.Item) (entities.Item, error)
+		ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Item, error)
+		// Count counts items matching filters, pagination is ignored.
+		Count(ctx context.Context, filters ReadByInput) (uint64, error)
+		Update(ctx context.Context, id string, changeset UpdateInput) (entities.Item, error)
+		// Delete is ErrHasMovements when sizes of the item are in the
+		// stock journal or in receipts.
+		Delete(ctx context.Context, id string) error
+	}
+
+	Service interface {
+		Create(ctx context.Context, input CreateInput) (entities.Item, error)
+		ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Item], error)
+		Update(ctx context.Context, id string, changeset UpdateInput) (entities.Item, error)
+		Delete(ctx context.Context, id string) error
+	}
+
+	service struct {
+		repo  ItemsRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo ItemsRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// auditFields are fields of an item kept in audit log.
+func auditFields(item entities.Item) map[string]any {
+	categoryID := ""
+	if item.Category != nil {
+		categoryID = item.Category.ID.String()
+	}
+	return map[string]any{
+		"categoryID":  categoryID,
+		"name":        item.Name,
+		"article":     item.Article,
+		"description": item.Description,
+		"iconURL":     item.IconURL,
+		"color":       item.Color,
+		"price":       item.Price,
+	}
+}
+
+// readOne returns item by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, id string) (entities.Item, error) {
+	filter := ReadByInput{}
+	filter.ID.Set(id)
+	list, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		return entities.Item{}, err
+	}
+	if len(list) == 0 {
+		return entities.Item{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (s service) Create(ctx context.Context, input CreateInput) (entities.Item, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Create")).End()
+	defer s.log.Sync()
+
+	storeID, err := uuid.Parse(input.StoreID)
+	if err != nil {
+		s.log.Debug("items:Create - failed to parse store id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Item{}, apperr.NewField(apperr.KindValidation, "storeID", "items.storeIDInvalid", "id магазина не валиден")
+	}
+
+	var category *entities.Category
+	if input.CategoryID != nil {
+		categoryID, err := uuid.Parse(*input.CategoryID)
+		if err != nil {
+			s.log.Debug("items:Create - failed to parse category id", logging.String("stage", "validation"), logging.Error("err", err))
+			return entities.Item{}, apperr.NewField(apperr.KindValidation, "categoryID", "items.categoryIDInvalid", "id категории не валиден")
+		}
+		category = &entities.Category{ID: categoryID}
+	}
+
+	if input.Price <= 0 {
+		s.log.Debug("items:Create - price must be greater than 0", logging.String("stage", "validation"))
+		return entities.Item{}, apperr.NewField(apperr.KindValidation, "price", "items.priceNotPositive", "цена должна быть больше 0")
+	}
+
+	item := entities.NewItem(
+		&entities.Store{ID: storeID},
+		category,
+		input.Name,
+		input.Article,
+		input.Description,
+		input.IconURL,
+		input.Color,
+		input.Price,
+	)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		item, err = s.repo.Create(ctx, item)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityItem,
+			EntityID:   item.ID.String(),
+			After:      auditFields(item),
+		})
+	})
+	if err != nil {
+		if err == ErrCategoryNotInStore {
+			s.log.Debug("items:Create - category is from another store", logging.String("stage", "repository"), logging.String("categoryID", *input.CategoryID))
+			return entities.Item{}, err
+		}
+		s.log.Error("items:Create - failed to create item", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Item{}, ErrDefault
+	}
+
+	s.log.Info("items:Create - item created", logging.String("stage", "repository"), logging.String("itemID", item.ID.String()))
+	return item, nil
+}
+
+func (s service) ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Item], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	// validate filters
+	pageNumber, ok := filters.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filters.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("items:ReadBy - pageNumber must be greater than 0", logging.String("stage", "validation"))
+		return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "items.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filters.PageSize.Get()
+	if !ok {
+		pageSize = 10
+		filters.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("items:ReadBy - pageSize must be between 1 and 100", logging.String("stage", "validation"))
+		return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "items.pageSizeOutOfRange", "размер страницы должен быть между 1 и 100")
+	}
+
+	text, _ := filters.Text.Get()
+	if len(text) > 255 {
+		s.log.Debug("items:ReadBy - text must be less than 255 characters", logging.String("stage", "validation"))
+		return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "text", "items.textTooLong", "текст должен быть меньше 255 символов")
+	}
+
+	priceFrom, okFrom := filters.PriceFrom.Get()
+	priceTo, okTo := filters.PriceTo.Get()
+	if (okFrom && priceFrom < 0) || (okTo && priceTo < 0) {
+		s.log.Debug("items:ReadBy - price range must not be negative", logging.String("stage", "validation"))
+		return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "priceFrom", "items.priceRangeNegative", "диапазон цен не может быть отрицательным")
+	}
+	if okFrom && okTo && priceFrom > priceTo {
+		s.log.Debug("items:ReadBy - priceFrom must not be greater than priceTo", logging.String("stage", "validation"))
+		return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "priceFrom", "items.priceRangeReversed", "минимальная цена не может быть больше максимальной")
+	}
+
+	sortBy, ok := filters.SortBy.Get()
+	if ok {
+		switch sortBy {
+		case SortByName, SortByPrice, SortByCreatedAt:
+		default:
+			s.log.Debug("items:ReadBy - sortBy must be one of name, price, createdAt", logging.String("stage", "validation"))
+			return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "sortBy", "items.sortByUnknown", "сортировка должна быть одной из name, price, createdAt")
+		}
+	} else {
+		filters.SortBy.Set(SortByCreatedAt)
+	}
+
+	sortOrder, ok := filters.SortOrder.Get()
+	if ok {
+		switch sortOrder {
+		case SortOrderAsc, SortOrderDesc:
+		default:
+			s.log.Debug("items:ReadBy - sortOrder must be one of asc, desc", logging.String("stage", "validation"))
+			return entities.Page[entities.Item]{}, apperr.NewField(apperr.KindInvalid, "sortOrder", "items.sortOrderUnknown", "сортировка должна быть одной из asc, desc")
+		}
+	} else {
+		filters.SortOrder.Set(SortOrderDesc)
+	}
+
+	items, err := s.repo.ReadBy(ctx, filters)
+	if err != nil {
+		s.log.Error("items:ReadBy - failed to read items", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Item]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		s.log.Error("items:ReadBy - failed to count items", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Item]{}, ErrDefault
+	}
+
+	s.log.Info("items:ReadBy - items read", logging.String("stage", "repository"), logging.Int("count", len(items)))
+	return entities.NumberedPage(items, pageNumber, pageSize, total), nil
+}
+
+func (s service) Update(ctx context.Context, id string, changeset UpdateInput) (entities.Item, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Update")).End()
+	defer s.log.Sync()
+
+	// validate changeset
+	countChanges := 0
+	categoryID, ok := changeset.CategoryID.Get()
+	if ok {
+		countChanges++
+		if categoryID != nil {
+			if _, err := uuid.Parse(*categoryID); err != nil {
+				s.log.Debug("items:Update - invalid category id", logging.String("stage", "validation"), logging.Error("err", err))
+				return entities.Item{}, apperr.NewField(apperr.KindValidation, "categoryID", "items.categoryIDInvalid", "id категории не валиден")
+			}
+		}
+	}
+
+	name, ok := changeset.Name.Get()
+	if ok {
+		countChanges++
+		if len(name) == 0 || len(name) > 255 {
+			s.log.Debug("items:Update - invalid name", logging.String("stage", "validation"), logging.String("name", name))
+			return entities.Item{}, apperr.NewField(apperr.KindValidation, "name", "items.nameLength", "название товара должно содержать от 1 до 255 символов")
+		}
+	}
+
+	article, ok := changeset.Article.Get()
+	if ok {
+		countChanges++
+		if len(article) == 0 || len(article) > 100 {
+			s.log.Debug("items:Update - invalid article", logging.String("stage", "validation"), logging.String("article", article))
+			return entities.Item{}, apperr.NewField(apperr.KindValidation, "article", "items.articleLength", "артикул должен содержать от 1 до 100 символов")
+		}
+	}
+
+	if _, ok := changeset.Description.Get(); ok {
+		countChanges++
+	}
+
+	if _, ok := changeset.IconURL.Get(); ok {
+		countChanges++
+	}
+
+	color, ok := changeset.Color.Get()
+	if ok {
+		countChanges++
+		if len(color) == 0 || len(color) > 6 {
+			s.log.Debug("items:Update - invalid color", logging.String("stage", "validation"), logging.String("color", color))
+			return entities.Item{}, apperr.NewField(apperr.KindValidation, "color", "items.colorLength", "цвет должен содержать от 1 до 6 символов")
+		}
+	}
+
+	price, ok := changeset.Price.Get()
+	if ok {
+		countChanges++
+		if price <= 0 {
+			s.log.Debug("items:Update - invalid price", logging.String("stage", "validation"), logging.Float64("price", price))
+			return entities.Item{}, apperr.NewField(apperr.KindValidation, "price", "items.priceNotPositive", "цена должна быть больше 0")
+		}
+	}
+
+	if countChanges == 0 {
+		s.log.Debug("items:Update - no changes", logging.String("stage", "validation"))
+		return entities.Item{}, apperr.New(apperr.KindValidation, "items.noChanges", "не переданы изменения")
+	}
+
+	var item entities.Item
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err != nil {
+			return err
+		}
+		item, err = s.repo.Update(ctx, id, changeset)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityItem,
+			EntityID:   id,
+			Before:     auditFields(before),
+			After:      auditFields(item),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("items:Update - item not found", logging.String("stage", "repository"), logging.String("itemID", id))
+			return entities.Item{}, err
+		}
+		if err == ErrCategoryNotInStore {
+			s.log.Debug("items:Update - category is from another store", logging.String("stage", "repository"), logging.String("itemID", id))
+			return entities.Item{}, err
+		}
+		s.log.Error("items:Update - failed to update item", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Item{}, ErrDefault
+	}
+
+	s.log.Info("items:Update - item updated", logging.String("stage", "repository"), logging.String("itemID", item.ID.String()))
+	return item, nil
+}
+
+func (s service) Delete(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err == ErrNotFound {
+			// nothing to delete
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityItem,
+			EntityID:   id,
+			Before:     auditFields(before),
+		})
+	})
+	if err != nil {
+		if err == ErrHasMovements {
+			s.log.Debug("items:Delete - item has movements", logging.String("stage", "repository"), logging.String("itemID", id))
+			return err
+		}
+		s.log.Error("items:Delete - failed to delete item", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("items:Delete - item deleted", logging.String("stage", "repository"), logging.String("itemID", id))
+	return nil
+}
@@ -0,0 +1,17 @@
+package policies
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/policies/"
+
+	ActionRead   = "read"   // view resource
+	ActionManage = "manage" // create, update or delete resource
+	ActionSell   = "sell"   // create receipts and returns in a store
+)
+
+var (
+	ErrForbidden = apperr.New(apperr.KindForbidden, "policies.forbidden", "недостаточно прав для этого действия")
+	ErrNotFound  = apperr.New(apperr.KindNotFound, "policies.notFound", "ресурс не найден")
+	ErrDefault   = apperr.New(apperr.KindInternal, "policies.default", "что-то пошло не так")
+)
@@ -0,0 +1,83 @@
+package policies
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+
+// Functions in this file are pure, they decide only by the access key
+// and already loaded resource attributes.
+
+// StoreRef is what policies need to know about a store.
+type StoreRef struct {
+	ID      string
+	OwnerID string
+}
+
+// CreateStore allows only owners to open new stores.
+func CreateStore(key auth.AccessKey) error {
+	if key.Role != auth.RoleOwner {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Store lets owner do anything with their store and lets sellers
+// assigned to the store only read it and sell in it.
+func Store(key auth.AccessKey, store StoreRef, action string) error {
+	switch key.Role {
+	case auth.RoleOwner:
+		if key.UserID == store.OwnerID {
+			return nil
+		}
+	case auth.RoleSeller:
+		allowed := action == ActionRead || action == ActionSell
+		if allowed && key.OwnerID == store.OwnerID && contains(key.StoreIDs, store.ID) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// Warehouse lets owner do anything with their warehouse and lets their
+// sellers only read it.
+func Warehouse(key auth.AccessKey, ownerID, action string) error {
+	switch key.Role {
+	case auth.RoleOwner:
+		if key.UserID == ownerID {
+			return nil
+		}
+	case auth.RoleSeller:
+		if action == ActionRead && key.OwnerID == ownerID {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// Category follows the rules of the store category belongs to.
+func Category(key auth.AccessKey, store StoreRef, action string) error {
+	return Store(key, store, action)
+}
+
+// Item follows the rules of the store item is sold in.
+func Item(key auth.AccessKey, store StoreRef, action string) error {
+	return Store(key, store, action)
+}
+
+// Size follows the rules of the store item of the size is sold in.
+func Size(key auth.AccessKey, store StoreRef, action string) error {
+	return Store(key, store, action)
+}
+
+// Receipt follows the rules of the store receipt was made in, returns
+// against it are made with ActionSell.
+func Receipt(key auth.AccessKey, store StoreRef, action string) error {
+	return Store(key, store, action)
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package policies
+
+import (
+	"testing"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+)
+
+var (
+	store = StoreRef{ID: "store", OwnerID: "owner"}
+
+	owner        = auth.AccessKey{UserID: "owner", Role: auth.RoleOwner}
+	foreignOwner = auth.AccessKey{UserID: "stranger", Role: auth.RoleOwner}
+	seller       = auth.AccessKey{UserID: "seller", Role: auth.RoleSeller, OwnerID: "owner", StoreIDs: []string{"other", "store"}}
+	otherSeller  = auth.AccessKey{UserID: "seller", Role: auth.RoleSeller, OwnerID: "owner", StoreIDs: []string{"other"}}
+	// foreignSeller claims the store, but works for another owner
+	foreignSeller = auth.AccessKey{UserID: "seller", Role: auth.RoleSeller, OwnerID: "stranger", StoreIDs: []string{"store"}}
+	unknownRole   = auth.AccessKey{UserID: "owner", Role: "admin", OwnerID: "owner", StoreIDs: []string{"store"}}
+)
+
+type storeCase struct {
+	name   string
+	key    auth.AccessKey
+	action string
+	err    error
+}
+
+var storeCases = []storeCase{
+	{"OwnerReads", owner, ActionRead, nil},
+	{"OwnerManages", owner, ActionManage, nil},
+	{"OwnerSells", owner, ActionSell, nil},
+	{"ForeignOwnerReads", foreignOwner, ActionRead, ErrForbidden},
+	{"ForeignOwnerManages", foreignOwner, ActionManage, ErrForbidden},
+	{"ForeignOwnerSells", foreignOwner, ActionSell, ErrForbidden},
+	{"SellerReads", seller, ActionRead, nil},
+	{"SellerSells", seller, ActionSell, nil},
+	{"SellerManages", seller, ActionManage, ErrForbidden},
+	{"SellerWithoutStoreReads", otherSeller, ActionRead, ErrForbidden},
+	{"SellerWithoutStoreSells", otherSeller, ActionSell, ErrForbidden},
+	{"ForeignSellerReads", foreignSeller, ActionRead, ErrForbidden},
+	{"ForeignSellerSells", foreignSeller, ActionSell, ErrForbidden},
+	{"UnknownRoleReads", unknownRole, ActionRead, ErrForbidden},
+	{"OwnerUnknownAction", owner, "delete", nil},
+	{"SellerUnknownAction", seller, "delete", ErrForbidden},
+}
+
+func TestStore(t *testing.T) {
+	for _, c := range storeCases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := Store(c.key, store, c.action); err != c.err {
+				t.Errorf("Store: got %v, want %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestCategory(t *testing.T) {
+	for _, c := range storeCases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := Category(c.key, store, c.action); err != c.err {
+				t.Errorf("Category: got %v, want %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestCreateStore(t *testing.T) {
+	cases := []struct {
+		name string
+		key  auth.AccessKey
+		err  error
+	}{
+		{"Owner", owner, nil},
+		{"ForeignOwner", foreignOwner, nil},
+		{"Seller", seller, ErrForbidden},
+		{"SellerWithoutStore", otherSeller, ErrForbidden},
+		{"UnknownRole", unknownRole, ErrForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := CreateStore(c.key); err != c.err {
+				t.Errorf("CreateStore: got %v, want %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestWarehouse(t *testing.T) {
+	cases := []struct {
+		name   string
+		key    auth.AccessKey
+		action string
+		err    error
+	}{
+		{"OwnerReads", owner, ActionRead, nil},
+		{"OwnerManages", owner, ActionManage, nil},
+		{"ForeignOwnerReads", foreignOwner, ActionRead, ErrForbidden},
+		{"SellerReads", seller, ActionRead, nil},
+		{"SellerWithoutStoreReads", otherSeller, ActionRead, nil},
+		{"SellerManages", seller, ActionManage, ErrForbidden},
+		{"SellerSells", seller, ActionSell, ErrForbidden},
+		{"ForeignSellerReads", foreignSeller, ActionRead, ErrForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := Warehouse(c.key, "owner", c.action); err != c.err {
+				t.Errorf("Warehouse: got %v, want %v", err, c.err)
+			}
+		})
+	}
+}
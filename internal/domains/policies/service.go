@@ -0,0 +1,209 @@
+package policies
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	// ResourcesRepository loads attributes of resources needed by
+	// policies. Both methods return ErrNotFound for missing rows.
+	ResourcesRepository interface {
+		StoreOwner(ctx context.Context, storeID string) (string, error)
+		CategoryStore(ctx context.Context, categoryID string) (StoreRef, error)
+		ItemStore(ctx context.Context, itemID string) (StoreRef, error)
+		ReceiptStore(ctx context.Context, receiptID string) (StoreRef, error)
+		WarehouseOwner(ctx context.Context, warehouseID string) (string, error)
+		SizeStore(ctx context.Context, sizeID int64) (StoreRef, error)
+	}
+
+	// Service loads resources by id and applies pure policies to them.
+	// It returns nil, ErrForbidden, ErrNotFound or ErrDefault.
+	Service interface {
+		AuthorizeStore(ctx context.Context, key auth.AccessKey, storeID, action string) error
+		AuthorizeCategory(ctx context.Context, key auth.AccessKey, categoryID, action string) error
+		AuthorizeItem(ctx context.Context, key auth.AccessKey, itemID, action string) error
+		AuthorizeReceipt(ctx context.Context, key auth.AccessKey, receiptID, action string) error
+		AuthorizeWarehouse(ctx context.Context, key auth.AccessKey, warehouseID, action string) error
+		AuthorizeSize(ctx context.Context, key auth.AccessKey, sizeID, action string) error
+	}
+
+	service struct {
+		repo ResourcesRepository
+		log  *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo ResourcesRepository, log *logging.Logger) service {
+	return service{repo: repo, log: log}
+}
+
+func (s service) AuthorizeStore(ctx context.Context, key auth.AccessKey, storeID, action string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AuthorizeStore")).End()
+	defer s.log.Sync()
+
+	if _, err := uuid.Parse(storeID); err != nil {
+		s.log.Debug("policies:AuthorizeStore - failed to parse store id", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrNotFound
+	}
+
+	ownerID, err := s.repo.StoreOwner(ctx, storeID)
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("policies:AuthorizeStore - store not found", logging.String("stage", "repository"), logging.String("storeID", storeID))
+			return err
+		}
+		s.log.Error("policies:AuthorizeStore - failed to read store owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := Store(key, StoreRef{ID: storeID, OwnerID: ownerID}, action); err != nil {
+		s.log.Debug("policies:AuthorizeStore - access denied", logging.String("stage", "policy"), logging.String("userID", key.UserID), logging.String("storeID", storeID), logging.String("action", action))
+		return err
+	}
+
+	return nil
+}
+
+func (s service) AuthorizeCategory(ctx context.Context, key auth.AccessKey, categoryID, action string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AuthorizeCategory")).End()
+	defer s.log.Sync()
+
+	if _, err := uuid.Parse(categoryID); err != nil {
+		s.log.Debug("policies:AuthorizeCategory - failed to parse category id", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrNotFound
+	}
+
+	store, err := s.repo.CategoryStore(ctx, categoryID)
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("policies:AuthorizeCategory - category not found", logging.String("stage", "repository"), logging.String("categoryID", categoryID))
+			return err
+		}
+		s.log.Error("policies:AuthorizeCategory - failed to read category store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := Category(key, store, action); err != nil {
+		s.log.Debug("policies:AuthorizeCategory - access denied", logging.String("stage", "policy"), logging.String("userID", key.UserID), logging.String("categoryID", categoryID), logging.String("action", action))
+		return err
+	}
+
+	return nil
+}
+
+func (s service) AuthorizeItem(ctx context.Context, key auth.AccessKey, itemID, action string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AuthorizeItem")).End()
+	defer s.log.Sync()
+
+	if _, err := uuid.Parse(itemID); err != nil {
+		s.log.Debug("policies:AuthorizeItem - failed to parse item id", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrNotFound
+	}
+
+	store, err := s.repo.ItemStore(ctx, itemID)
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("policies:AuthorizeItem - item not found", logging.String("stage", "repository"), logging.String("itemID", itemID))
+			return err
+		}
+		s.log.Error("policies:AuthorizeItem - failed to read item store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := Item(key, store, action); err != nil {
+		s.log.Debug("policies:AuthorizeItem - access denied", logging.String("stage", "policy"), logging.String("userID", key.UserID), logging.String("itemID", itemID), logging.String("action", action))
+		return err
+	}
+
+	return nil
+}
+
+func (s service) AuthorizeReceipt(ctx context.Context, key auth.AccessKey, receiptID, action string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AuthorizeReceipt")).End()
+	defer s.log.Sync()
+
+	if _, err := uuid.Parse(receiptID); err != nil {
+		s.log.Debug("policies:AuthorizeReceipt - failed to parse receipt id", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrNotFound
+	}
+
+	store, err := s.repo.ReceiptStore(ctx, receiptID)
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("policies:AuthorizeReceipt - receipt not found", logging.String("stage", "repository"), logging.String("receiptID", receiptID))
+			return err
+		}
+		s.log.Error("policies:AuthorizeReceipt - failed to read receipt store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := Receipt(key, store, action); err != nil {
+		s.log.Debug("policies:AuthorizeReceipt - access denied", logging.String("stage", "policy"), logging.String("userID", key.UserID), logging.String("receiptID", receiptID), logging.String("action", action))
+		return err
+	}
+
+	return nil
+}
+
+func (s service) AuthorizeWarehouse(ctx context.Context, key auth.AccessKey, warehouseID, action string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AuthorizeWarehouse")).End()
+	defer s.log.Sync()
+
+	if _, err := uuid.Parse(warehouseID); err != nil {
+		s.log.Debug("policies:AuthorizeWarehouse - failed to parse warehouse id", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrNotFound
+	}
+
+	ownerID, err := s.repo.WarehouseOwner(ctx, warehouseID)
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("policies:AuthorizeWarehouse - warehouse not found", logging.String("stage", "repository"), logging.String("warehouseID", warehouseID))
+			return err
+		}
+		s.log.Error("policies:AuthorizeWarehouse - failed to read warehouse owner", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := Warehouse(key, ownerID, action); err != nil {
+		s.log.Debug("policies:AuthorizeWarehouse - access denied", logging.String("stage", "policy"), logging.String("userID", key.UserID), logging.String("warehouseID", warehouseID), logging.String("action", action))
+		return err
+	}
+
+	return nil
+}
+
+func (s service) AuthorizeSize(ctx context.Context, key auth.AccessKey, sizeID, action string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AuthorizeSize")).End()
+	defer s.log.Sync()
+
+	id, err := strconv.ParseInt(sizeID, 10, 64)
+	if err != nil {
+		s.log.Debug("policies:AuthorizeSize - failed to parse size id", logging.String("stage", "validation"), logging.Error("err", err))
+		return ErrNotFound
+	}
+
+	store, err := s.repo.SizeStore(ctx, id)
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("policies:AuthorizeSize - size not found", logging.String("stage", "repository"), logging.Int64("sizeID", id))
+			return err
+		}
+		s.log.Error("policies:AuthorizeSize - failed to read size store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	if err := Size(key, store, action); err != nil {
+		s.log.Debug("policies:AuthorizeSize - access denied", logging.String("stage", "policy"), logging.String("userID", key.UserID), logging.Int64("sizeID", id), logging.String("action", action))
+		return err
+	}
+
+	return nil
+}
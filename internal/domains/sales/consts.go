@@ -0,0 +1,19 @@
+package sales
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/sales/"
+)
+
+var (
+	ErrNotFound          = apperr.New(apperr.KindNotFound, "sales.notFound", "чек не найден")
+	ErrNotInStore        = apperr.New(apperr.KindValidation, "sales.notInStore", "товар не продаётся в этом магазине")
+	ErrDiscountTooBig    = apperr.New(apperr.KindValidation, "sales.discountTooBig", "скидка не может быть больше суммы позиции")
+	ErrInsufficientStock = apperr.New(apperr.KindConflict, "sales.insufficientStock", "недостаточно товара на складе")
+	ErrLineNotFound      = apperr.New(apperr.KindNotFound, "sales.lineNotFound", "позиция чека не найдена")
+	ErrReturnTooMuch     = apperr.New(apperr.KindConflict, "sales.returnTooMuch", "нельзя вернуть больше, чем было продано")
+	ErrRefundTooBig      = apperr.New(apperr.KindConflict, "sales.refundTooBig", "сумма возврата больше оплаченной суммы")
+	ErrWrongWarehouse    = apperr.New(apperr.KindValidation, "sales.wrongWarehouse", "склад не принадлежит владельцу магазина")
+	ErrDefault           = apperr.New(apperr.KindInternal, "sales.default", "что-то пошло не так")
+)
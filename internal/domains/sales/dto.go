@@ -0,0 +1,47 @@
+package sales
+
+import (
+	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type (
+	CreateInput struct {
+		StoreID  string            `json:"storeID" validate:"required,uuid4"`
+		SellerID *string           `json:"-"` // set from session, nil for owners
+		Lines    []CreateLineInput `json:"lines" validate:"required,min=1,dive"`
+	}
+
+	CreateLineInput struct {
+		SizeID   int64   `json:"sizeID" validate:"required"`
+		Quantity int64   `json:"quantity" validate:"required,gt=0"`
+		Discount float64 `json:"discount" validate:"min=0"`
+	}
+
+	ReadByInput struct {
+		// if ID is set, other filters except OwnerID and StoreIDs
+		// will be ignored
+		ID       entities.OptField[string]    `json:"id"`
+		OwnerID  entities.OptField[string]    `json:"ownerID"`
+		StoreIDs entities.OptField[[]string]  `json:"storeIDs"` // used to show sellers only their stores
+		StoreID  entities.OptField[string]    `json:"storeID"`
+		SellerID entities.OptField[string]    `json:"sellerID"`
+		From     entities.OptField[time.Time] `json:"from"`
+		To       entities.OptField[time.Time] `json:"to"`
+
+		// Pagination
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+	}
+
+	ReturnInput struct {
+		ReceiptID   string   `json:"-"`
+		SellerID    *string  `json:"-"` // set from session, nil for owners
+		LineID      int64    `json:"lineID" validate:"required"`
+		WarehouseID string   `json:"warehouseID" validate:"required,uuid4"`
+		Quantity    int64    `json:"quantity" validate:"required,gt=0"`
+		Refund      *float64 `json:"refund" validate:"omitempty,min=0"` // proportional part of the line total if nil
+		Reason      string   `json:"reason" validate:"required,max=500"`
+	}
+)
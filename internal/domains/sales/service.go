@@ -0,0 +1,284 @@
+package sales
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	SalesRepository interface {
+		// Create fills prices of the lines, saves the receipt and
+		// decrements stock in a single transaction.
+		Create(ctx context.Context, receipt entities.Receipt) (entities.Receipt, error)
+		ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Receipt, error)
+		// Count counts receipts matching filters, pagination is ignored.
+		Count(ctx context.Context, filters ReadByInput) (uint64, error)
+
+		// CreateReturn checks returned quantity and refund against what
+		// was sold and already returned, puts goods back to the warehouse
+		// and saves the return in a single transaction. Refund is filled
+		// by the repository when it is negative.
+		CreateReturn(ctx context.Context, ret entities.ReceiptReturn, receiptID uuid.UUID) (entities.ReceiptReturn, error)
+		ReadReturns(ctx context.Context, receiptID string) ([]entities.ReceiptReturn, error)
+	}
+
+	Service interface {
+		Create(ctx context.Context, input CreateInput) (entities.Receipt, error)
+		ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Receipt], error)
+		Return(ctx context.Context, input ReturnInput) (entities.ReceiptReturn, error)
+		ReadReturns(ctx context.Context, receiptID string) ([]entities.ReceiptReturn, error)
+	}
+
+	service struct {
+		repo  SalesRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo SalesRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// receiptFields are fields of a receipt kept in audit log, the seller
+// is the actor of the entry.
+func receiptFields(receipt entities.Receipt) map[string]any {
+	fields := map[string]any{"total": receipt.Total, "lines": len(receipt.Lines)}
+	if receipt.Store != nil {
+		fields["storeID"] = receipt.Store.ID.String()
+	}
+	return fields
+}
+
+// returnFields are fields of a return kept in audit log. Reason is
+// free text and is left out.
+func returnFields(ret entities.ReceiptReturn, receiptID uuid.UUID) map[string]any {
+	fields := map[string]any{"receiptID": receiptID.String(), "quantity": ret.Quantity, "refund": ret.Refund}
+	if ret.Line != nil {
+		fields["lineID"] = ret.Line.ID
+	}
+	if ret.Warehouse != nil {
+		fields["warehouseID"] = ret.Warehouse.ID.String()
+	}
+	return fields
+}
+
+func (s service) Create(ctx context.Context, input CreateInput) (entities.Receipt, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Create")).End()
+	defer s.log.Sync()
+
+	storeID, err := uuid.Parse(input.StoreID)
+	if err != nil {
+		s.log.Debug("sales:Create - failed to parse store id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Receipt{}, apperr.NewField(apperr.KindValidation, "storeID", "sales.storeIDInvalid", "id магазина не валиден")
+	}
+
+	var seller *entities.Seller
+	if input.SellerID != nil {
+		sellerID, err := uuid.Parse(*input.SellerID)
+		if err != nil {
+			s.log.Debug("sales:Create - failed to parse seller id", logging.String("stage", "validation"), logging.Error("err", err))
+			return entities.Receipt{}, apperr.NewField(apperr.KindValidation, "sellerID", "sales.sellerIDInvalid", "id продавца не валиден")
+		}
+		seller = &entities.Seller{ID: sellerID}
+	}
+
+	if len(input.Lines) == 0 {
+		s.log.Debug("sales:Create - no lines", logging.String("stage", "validation"))
+		return entities.Receipt{}, apperr.NewField(apperr.KindValidation, "lines", "sales.linesEmpty", "чек должен содержать хотя бы одну позицию")
+	}
+	lines := make([]entities.ReceiptLine, 0, len(input.Lines))
+	for _, l := range input.Lines {
+		if l.Quantity <= 0 {
+			s.log.Debug("sales:Create - invalid quantity", logging.String("stage", "validation"), logging.Int64("sizeID", l.SizeID))
+			return entities.Receipt{}, entities.ErrMovementQuantity
+		}
+		if l.Discount < 0 {
+			s.log.Debug("sales:Create - negative discount", logging.String("stage", "validation"), logging.Int64("sizeID", l.SizeID))
+			return entities.Receipt{}, apperr.NewField(apperr.KindValidation, "discount", "sales.discountNegative", "скидка не может быть отрицательной")
+		}
+		lines = append(lines, entities.ReceiptLine{
+			Size:     &entities.Size{ID: l.SizeID},
+			Quantity: l.Quantity,
+			Discount: l.Discount,
+		})
+	}
+
+	receipt := entities.NewReceipt(&entities.Store{ID: storeID}, seller, lines)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		receipt, err = s.repo.Create(ctx, receipt)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityReceipt,
+			EntityID:   receipt.ID.String(),
+			After:      receiptFields(receipt),
+		})
+	})
+	if err != nil {
+		switch err {
+		case ErrNotInStore, ErrDiscountTooBig, ErrInsufficientStock:
+			s.log.Debug("sales:Create - sale rejected", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Receipt{}, err
+		}
+		s.log.Error("sales:Create - failed to create receipt", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Receipt{}, ErrDefault
+	}
+
+	s.log.Info("sales:Create - receipt created", logging.String("stage", "repository"), logging.String("receiptID", receipt.ID.String()), logging.Float64("total", receipt.Total))
+	return receipt, nil
+}
+
+func (s service) ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Receipt], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	pageNumber, ok := filters.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filters.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("sales:ReadBy - pageNumber must be greater than 0", logging.String("stage", "validation"))
+		return entities.Page[entities.Receipt]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "sales.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filters.PageSize.Get()
+	if !ok {
+		pageSize = 10
+		filters.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("sales:ReadBy - pageSize must be between 1 and 100", logging.String("stage", "validation"))
+		return entities.Page[entities.Receipt]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "sales.pageSizeOutOfRange", "размер страницы должен быть между 1 и 100")
+	}
+
+	from, okFrom := filters.From.Get()
+	to, okTo := filters.To.Get()
+	if okFrom && okTo && from.After(to) {
+		s.log.Debug("sales:ReadBy - from is after to", logging.String("stage", "validation"))
+		return entities.Page[entities.Receipt]{}, apperr.NewField(apperr.KindInvalid, "from", "sales.periodReversed", "начало периода не может быть позже конца")
+	}
+
+	receipts, err := s.repo.ReadBy(ctx, filters)
+	if err != nil {
+		s.log.Error("sales:ReadBy - failed to read receipts", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Receipt]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		s.log.Error("sales:ReadBy - failed to count receipts", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Receipt]{}, ErrDefault
+	}
+
+	s.log.Info("sales:ReadBy - receipts read", logging.String("stage", "repository"), logging.Int("count", len(receipts)))
+	return entities.NumberedPage(receipts, pageNumber, pageSize, total), nil
+}
+
+func (s service) Return(ctx context.Context, input ReturnInput) (entities.ReceiptReturn, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Return")).End()
+	defer s.log.Sync()
+
+	receiptID, err := uuid.Parse(input.ReceiptID)
+	if err != nil {
+		s.log.Debug("sales:Return - failed to parse receipt id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.ReceiptReturn{}, ErrNotFound
+	}
+
+	warehouseID, err := uuid.Parse(input.WarehouseID)
+	if err != nil {
+		s.log.Debug("sales:Return - failed to parse warehouse id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.ReceiptReturn{}, apperr.NewField(apperr.KindValidation, "warehouseID", "sales.warehouseIDInvalid", "id склада не валиден")
+	}
+
+	var seller *entities.Seller
+	if input.SellerID != nil {
+		sellerID, err := uuid.Parse(*input.SellerID)
+		if err != nil {
+			s.log.Debug("sales:Return - failed to parse seller id", logging.String("stage", "validation"), logging.Error("err", err))
+			return entities.ReceiptReturn{}, apperr.NewField(apperr.KindValidation, "sellerID", "sales.sellerIDInvalid", "id продавца не валиден")
+		}
+		seller = &entities.Seller{ID: sellerID}
+	}
+
+	if input.Quantity <= 0 {
+		s.log.Debug("sales:Return - invalid quantity", logging.String("stage", "validation"))
+		return entities.ReceiptReturn{}, entities.ErrMovementQuantity
+	}
+
+	refund := -1.0
+	if input.Refund != nil {
+		if *input.Refund < 0 {
+			s.log.Debug("sales:Return - negative refund", logging.String("stage", "validation"))
+			return entities.ReceiptReturn{}, apperr.NewField(apperr.KindValidation, "refund", "sales.refundNegative", "сумма возврата не может быть отрицательной")
+		}
+		refund = *input.Refund
+	}
+
+	ret := entities.NewReceiptReturn(
+		&entities.ReceiptLine{ID: input.LineID},
+		&entities.Warehouse{ID: warehouseID},
+		seller,
+		input.Quantity,
+		refund,
+		input.Reason,
+	)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		ret, err = s.repo.CreateReturn(ctx, ret, receiptID)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityReturn,
+			EntityID:   ret.ID.String(),
+			After:      returnFields(ret, receiptID),
+		})
+	})
+	if err != nil {
+		switch err {
+		case ErrLineNotFound, ErrReturnTooMuch, ErrRefundTooBig, ErrWrongWarehouse:
+			s.log.Debug("sales:Return - return rejected", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.ReceiptReturn{}, err
+		}
+		s.log.Error("sales:Return - failed to create return", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.ReceiptReturn{}, ErrDefault
+	}
+
+	s.log.Info("sales:Return - return created", logging.String("stage", "repository"), logging.String("returnID", ret.ID.String()), logging.Float64("refund", ret.Refund))
+	return ret, nil
+}
+
+func (s service) ReadReturns(ctx context.Context, receiptID string) ([]entities.ReceiptReturn, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadReturns")).End()
+	defer s.log.Sync()
+
+	if _, err := uuid.Parse(receiptID); err != nil {
+		s.log.Debug("sales:ReadReturns - failed to parse receipt id", logging.String("stage", "validation"), logging.Error("err", err))
+		return nil, ErrNotFound
+	}
+
+	returns, err := s.repo.ReadReturns(ctx, receiptID)
+	if err != nil {
+		s.log.Error("sales:ReadReturns - failed to read returns", logging.String("stage", "repository"), logging.Error("err", err))
+		return nil, ErrDefault
+	}
+
+	s.log.Info("sales:ReadReturns - returns read", logging.String("stage", "repository"), logging.Int("count", len(returns)))
+	return returns, nil
+}
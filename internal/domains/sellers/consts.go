@@ -0,0 +1,21 @@
+package sellers
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/sellers/"
+
+	SortByCreatedAt = "createdAt"
+	SortByFullName  = "fullName"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+var (
+	ErrNotFound      = apperr.New(apperr.KindNotFound, "sellers.notFound", "продавец не найден")
+	ErrUsernameTaken = apperr.New(apperr.KindConflict, "sellers.usernameTaken", "имя пользователя уже занято")
+	ErrStoreMismatch = apperr.New(apperr.KindValidation, "sellers.storeMismatch", "продавец и магазин должны принадлежать одному владельцу")
+	ErrHasReceipts   = apperr.New(apperr.KindConflict, "sellers.hasReceipts", "продавец уже оформлял продажи, его можно только деактивировать")
+	ErrDefault       = apperr.New(apperr.KindInternal, "sellers.default", "что-то пошло не так")
+)
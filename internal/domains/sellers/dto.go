@@ -0,0 +1,31 @@
+package sellers
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+type (
+	CreateInput struct {
+		OwnerID     string `json:"ownerID" validate:"required"`
+		Username    string `json:"username" validate:"required,min=6,max=500"`
+		FullName    string `json:"fullName" validate:"required"`
+		PhoneNumber string `json:"phoneNumber" validate:"max=500"`
+		// StoreIDs are assigned together with creation, the seller is not
+		// created if any of them can not be assigned.
+		StoreIDs []string `json:"storeIDs" validate:"max=100"`
+	}
+
+	ReadByInput struct {
+		// if ID is set, other filters will be ignored
+		ID       entities.OptField[string] `json:"id"`
+		OwnerID  entities.OptField[string] `json:"ownerID"`
+		StoreID  entities.OptField[string] `json:"storeID"` // sellers assigned to this store
+		IsActive entities.OptField[bool]   `json:"isActive"`
+
+		// Pagination
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+
+		// Sorting
+		SortBy    entities.OptField[string] `json:"sortBy"`    // fullName, createdAt
+		SortOrder entities.OptField[string] `json:"sortOrder"` // asc, desc
+	}
+)
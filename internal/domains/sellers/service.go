@@ -0,0 +1,353 @@
+package sellers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	// Every mutating method takes ownerID, rows of other owners are
+	// treated as not existing.
+	SellersRepository interface {
+		Create(ctx context.Context, seller entities.Seller) (entities.Seller, error)
+		ReadBy(ctx context.Context, filter ReadByInput) ([]entities.Seller, error)
+		// Count counts sellers matching filters, pagination is ignored.
+		Count(ctx context.Context, filter ReadByInput) (uint64, error)
+		SetActive(ctx context.Context, ownerID, id string, active bool) error
+		Delete(ctx context.Context, ownerID, id string) error
+		AssignStore(ctx context.Context, ownerID, sellerID, storeID string) error
+		UnassignStore(ctx context.Context, ownerID, sellerID, storeID string) error
+	}
+
+	Service interface {
+		Create(ctx context.Context, input CreateInput) (entities.Seller, error)
+		ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.Seller], error)
+		Activate(ctx context.Context, ownerID, id string) error
+		// Deactivate keeps the seller and their receipts but forbids them to work.
+		Deactivate(ctx context.Context, ownerID, id string) error
+		Delete(ctx context.Context, ownerID, id string) error
+		AssignStore(ctx context.Context, ownerID, sellerID, storeID string) error
+		UnassignStore(ctx context.Context, ownerID, sellerID, storeID string) error
+	}
+
+	// Sessions revokes tokens of sellers whose access is taken away,
+	// auth.Service is one. Tokens carry stores of the seller, so they
+	// have to be revoked even when only a store is unassigned.
+	Sessions interface {
+		RevokeSessions(ctx context.Context, userID string) error
+	}
+
+	service struct {
+		repo     SellersRepository
+		uow      uow.UnitOfWork
+		audit    audit.Recorder
+		sessions Sessions
+		log      *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo SellersRepository, uow uow.UnitOfWork, audit audit.Recorder, sessions Sessions, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, sessions: sessions, log: log}
+}
+
+// auditFields are fields of a seller kept in audit log. Names and phone
+// numbers are personal data and are left out, so that the log has
+// nothing to anonymize.
+func auditFields(seller entities.Seller) map[string]any {
+	return map[string]any{"isActive": seller.IsActive}
+}
+
+// readOne returns seller of ownerID by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, ownerID, id string) (entities.Seller, error) {
+	filter := ReadByInput{}
+	filter.ID.Set(id)
+	filter.OwnerID.Set(ownerID)
+	list, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		return entities.Seller{}, err
+	}
+	if len(list) == 0 {
+		return entities.Seller{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (s service) Create(ctx context.Context, input CreateInput) (entities.Seller, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Create")).End()
+	defer s.log.Sync()
+
+	ownerID, err := uuid.Parse(input.OwnerID)
+	if err != nil {
+		s.log.Debug("sellers:Create - failed to parse owner id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Seller{}, apperr.NewField(apperr.KindValidation, "ownerID", "sellers.ownerIDInvalid", "id владельца не валиден")
+	}
+	if len(input.Username) < 6 {
+		s.log.Debug("sellers:Create - invalid username", logging.String("stage", "validation"), logging.String("username", input.Username))
+		return entities.Seller{}, apperr.NewField(apperr.KindValidation, "username", "sellers.usernameTooShort", "имя пользователя должно содержать минимум 6 символов")
+	}
+	for _, storeID := range input.StoreIDs {
+		if _, err := uuid.Parse(storeID); err != nil {
+			s.log.Debug("sellers:Create - failed to parse store id", logging.String("stage", "validation"), logging.String("storeID", storeID))
+			return entities.Seller{}, apperr.NewField(apperr.KindValidation, "storeID", "sellers.storeIDInvalid", "id магазина не валиден")
+		}
+	}
+
+	seller := entities.NewSeller(&entities.Owner{ID: ownerID}, input.Username, input.FullName, input.PhoneNumber)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		seller, err = s.repo.Create(ctx, seller)
+		if err != nil {
+			return err
+		}
+		for _, storeID := range input.StoreIDs {
+			if err := s.repo.AssignStore(ctx, ownerID.String(), seller.ID.String(), storeID); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID.String(),
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntitySeller,
+			EntityID:   seller.ID.String(),
+			After:      map[string]any{"isActive": seller.IsActive, "storeIDs": input.StoreIDs},
+		})
+	})
+	if err != nil {
+		switch err {
+		case ErrUsernameTaken:
+			s.log.Debug("sellers:Create - username taken", logging.String("stage", "repository"), logging.String("username", input.Username))
+			return entities.Seller{}, err
+		case ErrStoreMismatch:
+			s.log.Debug("sellers:Create - store and seller owners differ", logging.String("stage", "repository"), logging.String("ownerID", ownerID.String()))
+			return entities.Seller{}, err
+		}
+		s.log.Error("sellers:Create - failed to create seller", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Seller{}, ErrDefault
+	}
+
+	s.log.Info("sellers:Create - seller created", logging.String("stage", "repository"), logging.String("sellerID", seller.ID.String()), logging.String("ownerID", ownerID.String()))
+	return seller, nil
+}
+
+func (s service) ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.Seller], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	// validate filters
+	pageNumber, ok := filter.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filter.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("sellers:ReadBy - invalid page number", logging.String("stage", "validation"), logging.Uint64("pageNumber", pageNumber))
+		return entities.Page[entities.Seller]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "sellers.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filter.PageSize.Get()
+	if !ok {
+		pageSize = 10
+		filter.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("sellers:ReadBy - invalid page size", logging.String("stage", "validation"), logging.Uint("pageSize", pageSize))
+		return entities.Page[entities.Seller]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "sellers.pageSizeOutOfRange", "размер страницы должен быть в диапазоне от 1 до 100")
+	}
+
+	sortBy, ok := filter.SortBy.Get()
+	if ok {
+		switch sortBy {
+		case SortByFullName, SortByCreatedAt:
+		default:
+			s.log.Debug("sellers:ReadBy - invalid sortBy", logging.String("stage", "validation"), logging.String("sortBy", sortBy))
+			return entities.Page[entities.Seller]{}, apperr.NewField(apperr.KindInvalid, "sortBy", "sellers.sortByUnknown", "сортировка должна быть одной из fullName, createdAt")
+		}
+	}
+
+	sortOrder, ok := filter.SortOrder.Get()
+	if ok {
+		switch sortOrder {
+		case SortOrderAsc, SortOrderDesc:
+		default:
+			s.log.Debug("sellers:ReadBy - invalid sortOrder", logging.String("stage", "validation"), logging.String("sortOrder", sortOrder))
+			return entities.Page[entities.Seller]{}, apperr.NewField(apperr.KindInvalid, "sortOrder", "sellers.sortOrderUnknown", "сортировка должна быть одной из asc, desc")
+		}
+	}
+
+	sellers, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		s.log.Error("sellers:ReadBy - failed to read sellers", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Seller]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		s.log.Error("sellers:ReadBy - failed to count sellers", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Seller]{}, ErrDefault
+	}
+
+	s.log.Info("sellers:ReadBy - sellers read", logging.String("stage", "repository"), logging.Int("count", len(sellers)))
+	return entities.NumberedPage(sellers, pageNumber, pageSize, total), nil
+}
+
+func (s service) Activate(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Activate")).End()
+	defer s.log.Sync()
+
+	return s.setActive(ctx, ownerID, id, true)
+}
+
+func (s service) Deactivate(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Deactivate")).End()
+	defer s.log.Sync()
+
+	return s.setActive(ctx, ownerID, id, false)
+}
+
+func (s service) setActive(ctx context.Context, ownerID, id string, active bool) error {
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetActive(ctx, ownerID, id, active); err != nil {
+			return err
+		}
+		if !active {
+			if err := s.sessions.RevokeSessions(ctx, id); err != nil {
+				return err
+			}
+		}
+		after := before
+		after.IsActive = active
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntitySeller,
+			EntityID:   id,
+			Before:     auditFields(before),
+			After:      auditFields(after),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("sellers:SetActive - seller not found", logging.String("stage", "repository"), logging.String("sellerID", id))
+			return err
+		}
+		s.log.Error("sellers:SetActive - failed to change seller state", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("sellers:SetActive - seller state changed", logging.String("stage", "repository"), logging.String("sellerID", id), logging.Bool("active", active))
+	return nil
+}
+
+func (s service) Delete(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, ownerID, id); err != nil {
+			return err
+		}
+		if err := s.sessions.RevokeSessions(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntitySeller,
+			EntityID:   id,
+			Before:     auditFields(before),
+		})
+	})
+	if err != nil {
+		switch err {
+		case ErrNotFound, ErrHasReceipts:
+			s.log.Debug("sellers:Delete - seller cannot be deleted", logging.String("stage", "repository"), logging.String("sellerID", id), logging.Error("err", err))
+			return err
+		}
+		s.log.Error("sellers:Delete - failed to delete seller", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("sellers:Delete - seller deleted", logging.String("stage", "repository"), logging.String("sellerID", id))
+	return nil
+}
+
+func (s service) AssignStore(ctx context.Context, ownerID, sellerID, storeID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.AssignStore")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.AssignStore(ctx, ownerID, sellerID, storeID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionLink,
+			EntityType: audit.EntitySeller,
+			EntityID:   sellerID,
+			After:      map[string]any{"storeID": storeID},
+		})
+	})
+	if err != nil {
+		if err == ErrStoreMismatch {
+			s.log.Debug("sellers:AssignStore - store and seller owners differ", logging.String("stage", "repository"), logging.String("sellerID", sellerID), logging.String("storeID", storeID))
+			return err
+		}
+		s.log.Error("sellers:AssignStore - failed to assign store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("sellers:AssignStore - store assigned", logging.String("stage", "repository"), logging.String("sellerID", sellerID), logging.String("storeID", storeID))
+	return nil
+}
+
+func (s service) UnassignStore(ctx context.Context, ownerID, sellerID, storeID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.UnassignStore")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// sessions of sellers of other owners must not be touched
+		if _, err := s.readOne(ctx, ownerID, sellerID); err != nil {
+			return err
+		}
+		if err := s.repo.UnassignStore(ctx, ownerID, sellerID, storeID); err != nil {
+			return err
+		}
+		if err := s.sessions.RevokeSessions(ctx, sellerID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionUnlink,
+			EntityType: audit.EntitySeller,
+			EntityID:   sellerID,
+			After:      map[string]any{"storeID": storeID},
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("sellers:UnassignStore - seller not found", logging.String("stage", "repository"), logging.String("sellerID", sellerID))
+			return err
+		}
+		s.log.Error("sellers:UnassignStore - failed to unassign store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("sellers:UnassignStore - store unassigned", logging.String("stage", "repository"), logging.String("sellerID", sellerID), logging.String("storeID", storeID))
+	return nil
+}
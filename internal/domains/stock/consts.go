@@ -0,0 +1,28 @@
+package stock
+
+import (
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+)
+
+const (
+	PackageName = "internal/domains/stock/"
+)
+
+// manualMovements are the only movements that may be recorded directly,
+// sales, returns and transfers are recorded by their documents.
+var manualMovements = map[string]bool{
+	entities.MovementReceipt:    true,
+	entities.MovementWriteOff:   true,
+	entities.MovementCorrection: true,
+}
+
+var (
+	ErrSizeExists = apperr.New(apperr.KindConflict, "stock.sizeExists", "такой размер этого товара уже есть на складе")
+	ErrNotFound   = apperr.New(apperr.KindNotFound, "stock.notFound", "размер не найден")
+
+	ErrInsufficientStock = apperr.New(apperr.KindConflict, "stock.insufficientStock", "недостаточно товара на складе")
+	ErrHasMovements      = apperr.New(apperr.KindConflict, "stock.hasMovements", "нельзя удалить размер, по которому есть движения товара")
+	ErrNotManual         = apperr.NewField(apperr.KindValidation, "type", "stock.notManual", "продажи, возвраты и перемещения записываются только их документами")
+	ErrDefault           = apperr.New(apperr.KindInternal, "stock.default", "что-то пошло не так")
+)
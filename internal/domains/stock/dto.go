@@ -0,0 +1,47 @@
+package stock
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+type (
+	CreateInput struct {
+		ItemID      string  `json:"itemID" validate:"required,uuid4"`
+		WarehouseID string  `json:"warehouseID" validate:"required,uuid4"`
+		SizeNumber  *string `json:"sizeNumber,omitempty" validate:"omitempty,max=50"`
+		SizeSymbol  *string `json:"sizeSymbol,omitempty" validate:"omitempty,max=10"`
+		Quantity    int64   `json:"quantity" validate:"min=0"`
+		Cost        float64 `json:"cost" validate:"min=0"`
+	}
+
+	ReadByInput struct {
+		// if ID is set, other filters will be ignored
+		ID          entities.OptField[int64]  `json:"id"`
+		ItemID      entities.OptField[string] `json:"itemID"`
+		WarehouseID entities.OptField[string] `json:"warehouseID"`
+
+		// Pagination
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+	}
+
+	// Quantity can not be updated directly,
+	// it is changed only through movements.
+	UpdateInput struct {
+		Cost entities.OptField[float64] `json:"cost"`
+	}
+
+	MoveInput struct {
+		SizeID   int64  `json:"sizeID" validate:"required"`
+		Type     string `json:"type" validate:"required,oneof=receipt writeOff correction"`
+		Quantity int64  `json:"quantity" validate:"required"` // absolute value, except for corrections
+		Comment  string `json:"comment" validate:"max=500"`
+	}
+
+	MovementsReadByInput struct {
+		ItemID entities.OptField[string] `json:"itemID"`
+		SizeID entities.OptField[int64]  `json:"sizeID"`
+
+		// Pagination
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+	}
+)
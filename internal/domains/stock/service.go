@@ -0,0 +1,349 @@
+package stock
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	StockRepository interface {
+		Create(ctx context.Context, size entities.Size) (entities.Size, error)
+		ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Size, error)
+		// Count counts sizes matching filters, pagination is ignored.
+		Count(ctx context.Context, filters ReadByInput) (uint64, error)
+		Update(ctx context.Context, id int64, changeset UpdateInput) (entities.Size, error)
+		Delete(ctx context.Context, id int64) error
+
+		// Move appends the movement to the journal and updates
+		// materialized quantity of the size in one transaction.
+		Move(ctx context.Context, movement entities.Movement) (entities.Movement, error)
+		ReadMovements(ctx context.Context, filters MovementsReadByInput) ([]entities.Movement, error)
+		// CountMovements counts movements matching filters, pagination is ignored.
+		CountMovements(ctx context.Context, filters MovementsReadByInput) (uint64, error)
+	}
+
+	Service interface {
+		Create(ctx context.Context, input CreateInput) (entities.Size, error)
+		ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Size], error)
+		Update(ctx context.Context, id int64, changeset UpdateInput) (entities.Size, error)
+		Delete(ctx context.Context, id int64) error
+
+		// Move records receipts, write-offs and corrections, other types
+		// are ErrNotManual.
+		Move(ctx context.Context, input MoveInput) (entities.Movement, error)
+		// ReadMovements returns history of movements with running balance, newest first.
+		ReadMovements(ctx context.Context, filters MovementsReadByInput) (entities.Page[entities.Movement], error)
+	}
+
+	service struct {
+		repo  StockRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo StockRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// auditFields are fields of a size kept in audit log. Quantity is not
+// among them, its history is the stock journal.
+func auditFields(size entities.Size) map[string]any {
+	fields := map[string]any{"cost": size.Cost}
+	if size.Item != nil {
+		fields["itemID"] = size.Item.ID.String()
+	}
+	if size.Warehouse != nil {
+		fields["warehouseID"] = size.Warehouse.ID.String()
+	}
+	if size.SizeNumber != nil {
+		fields["sizeNumber"] = *size.SizeNumber
+	}
+	if size.SizeSymbol != nil {
+		fields["sizeSymbol"] = *size.SizeSymbol
+	}
+	return fields
+}
+
+// readOne returns size by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, id int64) (entities.Size, error) {
+	filter := ReadByInput{}
+	filter.ID.Set(id)
+	list, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		return entities.Size{}, err
+	}
+	if len(list) == 0 {
+		return entities.Size{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (s service) Create(ctx context.Context, input CreateInput) (entities.Size, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Create")).End()
+	defer s.log.Sync()
+
+	itemID, err := uuid.Parse(input.ItemID)
+	if err != nil {
+		s.log.Debug("stock:Create - failed to parse item id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Size{}, apperr.NewField(apperr.KindValidation, "itemID", "stock.itemIDInvalid", "id товара не валиден")
+	}
+	warehouseID, err := uuid.Parse(input.WarehouseID)
+	if err != nil {
+		s.log.Debug("stock:Create - failed to parse warehouse id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Size{}, apperr.NewField(apperr.KindValidation, "warehouseID", "stock.warehouseIDInvalid", "id склада не валиден")
+	}
+	if input.Quantity < 0 || input.Cost < 0 {
+		s.log.Debug("stock:Create - quantity and cost must not be negative", logging.String("stage", "validation"))
+		return entities.Size{}, apperr.New(apperr.KindValidation, "stock.quantityOrCostNegative", "количество и себестоимость не могут быть отрицательными")
+	}
+
+	size, err := entities.NewSize(
+		&entities.Item{ID: itemID},
+		&entities.Warehouse{ID: warehouseID},
+		input.SizeNumber,
+		input.SizeSymbol,
+		input.Quantity,
+		input.Cost,
+	)
+	if err != nil {
+		s.log.Debug("stock:Create - failed to create size", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Size{}, err
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		size, err = s.repo.Create(ctx, size)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntitySize,
+			EntityID:   strconv.FormatInt(size.ID, 10),
+			After:      auditFields(size),
+		})
+	})
+	if err != nil {
+		if err == ErrSizeExists || err == entities.ErrSizeExclusive {
+			s.log.Debug("stock:Create - size violates constraints", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Size{}, err
+		}
+		s.log.Error("stock:Create - failed to create size", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Size{}, ErrDefault
+	}
+
+	s.log.Info("stock:Create - size created", logging.String("stage", "repository"), logging.Int64("sizeID", size.ID))
+	return size, nil
+}
+
+func (s service) ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Size], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	// validate filters
+	pageNumber, ok := filters.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filters.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("stock:ReadBy - pageNumber must be greater than 0", logging.String("stage", "validation"))
+		return entities.Page[entities.Size]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "stock.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filters.PageSize.Get()
+	if !ok {
+		pageSize = 50
+		filters.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("stock:ReadBy - pageSize must be between 1 and 100", logging.String("stage", "validation"))
+		return entities.Page[entities.Size]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "stock.pageSizeOutOfRange", "размер страницы должен быть между 1 и 100")
+	}
+
+	sizes, err := s.repo.ReadBy(ctx, filters)
+	if err != nil {
+		s.log.Error("stock:ReadBy - failed to read sizes", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Size]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		s.log.Error("stock:ReadBy - failed to count sizes", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Size]{}, ErrDefault
+	}
+
+	s.log.Info("stock:ReadBy - sizes read", logging.String("stage", "repository"), logging.Int("count", len(sizes)))
+	return entities.NumberedPage(sizes, pageNumber, pageSize, total), nil
+}
+
+func (s service) Update(ctx context.Context, id int64, changeset UpdateInput) (entities.Size, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Update")).End()
+	defer s.log.Sync()
+
+	// validate changeset
+	countChanges := 0
+	cost, ok := changeset.Cost.Get()
+	if ok {
+		countChanges++
+		if cost < 0 {
+			s.log.Debug("stock:Update - cost must not be negative", logging.String("stage", "validation"), logging.Float64("cost", cost))
+			return entities.Size{}, apperr.NewField(apperr.KindValidation, "cost", "stock.costNegative", "себестоимость не может быть отрицательной")
+		}
+	}
+
+	if countChanges == 0 {
+		s.log.Debug("stock:Update - no changes", logging.String("stage", "validation"))
+		return entities.Size{}, apperr.New(apperr.KindValidation, "stock.noChanges", "не переданы изменения")
+	}
+
+	var size entities.Size
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err != nil {
+			return err
+		}
+		size, err = s.repo.Update(ctx, id, changeset)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntitySize,
+			EntityID:   strconv.FormatInt(id, 10),
+			Before:     auditFields(before),
+			After:      auditFields(size),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("stock:Update - size not found", logging.String("stage", "repository"), logging.Int64("sizeID", id))
+			return entities.Size{}, err
+		}
+		s.log.Error("stock:Update - failed to update size", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Size{}, ErrDefault
+	}
+
+	s.log.Info("stock:Update - size updated", logging.String("stage", "repository"), logging.Int64("sizeID", size.ID))
+	return size, nil
+}
+
+func (s service) Delete(ctx context.Context, id int64) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err == ErrNotFound {
+			// nothing to delete
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntitySize,
+			EntityID:   strconv.FormatInt(id, 10),
+			Before:     auditFields(before),
+		})
+	})
+	if err != nil {
+		if err == ErrHasMovements {
+			s.log.Debug("stock:Delete - size has movements", logging.String("stage", "repository"), logging.Int64("sizeID", id))
+			return err
+		}
+		s.log.Error("stock:Delete - failed to delete size", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("stock:Delete - size deleted", logging.String("stage", "repository"), logging.Int64("sizeID", id))
+	return nil
+}
+
+func (s service) Move(ctx context.Context, input MoveInput) (entities.Movement, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Move")).End()
+	defer s.log.Sync()
+
+	if !manualMovements[input.Type] {
+		s.log.Debug("stock:Move - movement type is not manual", logging.String("stage", "validation"), logging.String("type", input.Type))
+		return entities.Movement{}, ErrNotManual
+	}
+
+	movement, err := entities.NewMovement(&entities.Size{ID: input.SizeID}, input.Type, input.Quantity, input.Comment)
+	if err != nil {
+		s.log.Debug("stock:Move - invalid movement", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Movement{}, err
+	}
+
+	movement, err = s.repo.Move(ctx, movement)
+	if err != nil {
+		if err == ErrNotFound || err == ErrInsufficientStock {
+			s.log.Debug("stock:Move - movement rejected", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Movement{}, err
+		}
+		s.log.Error("stock:Move - failed to record movement", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Movement{}, ErrDefault
+	}
+
+	s.log.Info("stock:Move - movement recorded", logging.String("stage", "repository"), logging.Int64("movementID", movement.ID), logging.Int64("sizeID", input.SizeID), logging.String("type", movement.Type))
+	return movement, nil
+}
+
+func (s service) ReadMovements(ctx context.Context, filters MovementsReadByInput) (entities.Page[entities.Movement], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadMovements")).End()
+	defer s.log.Sync()
+
+	_, okItem := filters.ItemID.Get()
+	_, okSize := filters.SizeID.Get()
+	if !okItem && !okSize {
+		s.log.Debug("stock:ReadMovements - itemID or sizeID is required", logging.String("stage", "validation"))
+		return entities.Page[entities.Movement]{}, apperr.New(apperr.KindInvalid, "stock.itemOrSizeRequired", "нужно указать товар или размер")
+	}
+
+	pageNumber, ok := filters.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filters.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("stock:ReadMovements - pageNumber must be greater than 0", logging.String("stage", "validation"))
+		return entities.Page[entities.Movement]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "stock.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filters.PageSize.Get()
+	if !ok {
+		pageSize = 50
+		filters.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("stock:ReadMovements - pageSize must be between 1 and 100", logging.String("stage", "validation"))
+		return entities.Page[entities.Movement]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "stock.pageSizeOutOfRange", "размер страницы должен быть между 1 и 100")
+	}
+
+	movements, err := s.repo.ReadMovements(ctx, filters)
+	if err != nil {
+		s.log.Error("stock:ReadMovements - failed to read movements", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Movement]{}, ErrDefault
+	}
+
+	total, err := s.repo.CountMovements(ctx, filters)
+	if err != nil {
+		s.log.Error("stock:ReadMovements - failed to count movements", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Movement]{}, ErrDefault
+	}
+
+	s.log.Info("stock:ReadMovements - movements read", logging.String("stage", "repository"), logging.Int("count", len(movements)))
+	return entities.NumberedPage(movements, pageNumber, pageSize, total), nil
+}
@@ -1,12 +1,18 @@
 package stores
 
-import "errors"
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 
 const (
 	PackageName = "internal/domains/stores/"
 
 	SortByCreatedAt = "createdAt"
 	SortByName      = "name"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
 )
 
-var ErrDefault = errors.New("что-то пошло не так")
+var (
+	ErrDefault  = apperr.New(apperr.KindInternal, "stores.default", "что-то пошло не так")
+	ErrNotFound = apperr.New(apperr.KindNotFound, "stores.notFound", "магазин не найден")
+)
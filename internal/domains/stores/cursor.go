@@ -0,0 +1,11 @@
+package stores
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+// SortKey is the cursor key of store in a list sorted by sortBy.
+func SortKey(store entities.Store, sortBy string) *string {
+	if sortBy == SortByName {
+		return &store.Name
+	}
+	return entities.TimeKey(store.CreatedAt)
+}
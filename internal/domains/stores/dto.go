@@ -10,13 +10,22 @@ type (
 	}
 
 	ReadByInput struct {
-		ID      entities.OptField[string] `json:"id"`
-		Text    entities.OptField[string] `json:"text"`
-		OwnerID entities.OptField[string] `json:"ownerID"`
+		ID      entities.OptField[string]   `json:"id"`
+		Text    entities.OptField[string]   `json:"text"`
+		OwnerID entities.OptField[string]   `json:"ownerID"`
+		IDs     entities.OptField[[]string] `json:"ids"` // used to show sellers only their stores
+		// Deleted lists stores in trash instead of live ones
+		Deleted entities.OptField[bool] `json:"deleted"`
 
 		// Pagination
 		PageNumber entities.OptField[uint64] `json:"pageNumber"`
 		PageSize   entities.OptField[uint]   `json:"pageSize"`
+		// Cursor continues a list from a page read before, PageNumber is
+		// ignored and sorting is taken from the cursor
+		Cursor entities.OptField[entities.Cursor] `json:"-"`
+		// WithTotal counts all stores matching filters when reading with
+		// Cursor, numbered pages are always counted
+		WithTotal entities.OptField[bool] `json:"withTotal"`
 
 		// Sorting
 		SortBy    entities.OptField[string] `json:"sortBy"`    // name, createdAt
@@ -2,10 +2,13 @@ package stores
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
 	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
 )
@@ -14,28 +17,65 @@ type (
 	// TODO: it is actually a bad practice to use types that are not buisness entities in repos, but for now fuck it
 	StoresRepository interface {
 		Create(ctx context.Context, store entities.Store) (entities.Store, error)
+		// ReadBy returns stores sorted by SortBy and then by id in the same
+		// order. With Cursor set it reads PageSize stores after the cursor,
+		// or before it for backward cursors, in the order of sorting.
 		ReadBy(ctx context.Context, filter ReadByInput) ([]entities.Store, error)
+		// Count counts stores matching filters, pagination is ignored.
+		Count(ctx context.Context, filter ReadByInput) (uint64, error)
 		Update(ctx context.Context, id string, changeset UpdateInput) (entities.Store, error)
+		// Delete moves store to trash, ReadBy does not show it unless
+		// asked for deleted stores.
 		Delete(ctx context.Context, id string) error
+		// Restore returns ErrNotFound if store is not in trash.
+		Restore(ctx context.Context, id string) error
+		// Purge removes stores deleted before deletedBefore for good.
+		Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 	}
 
 	Service interface {
 		Create(ctx context.Context, input CreateInput) (entities.Store, error)
-		ReadBy(ctx context.Context, filter ReadByInput) ([]entities.Store, error)
+		ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.Store], error)
 		Update(ctx context.Context, id string, input UpdateInput) (entities.Store, error)
 		Delete(ctx context.Context, id string) error
+		Restore(ctx context.Context, id string) error
+		Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 	}
 
 	service struct {
-		repo StoresRepository
-		log  *logging.Logger
+		repo  StoresRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
 	}
 )
 
 var _ Service = (*service)(nil)
 
-func NewService(repo StoresRepository, log *logging.Logger) service {
-	return service{repo: repo, log: log}
+func NewService(repo StoresRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// auditFields are fields of a store kept in audit log.
+func auditFields(store entities.Store) map[string]any {
+	return map[string]any{
+		"name":        store.Name,
+		"description": store.Description,
+	}
+}
+
+// readOne returns store by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, id string) (entities.Store, error) {
+	filter := ReadByInput{}
+	filter.ID.Set(id)
+	list, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		return entities.Store{}, err
+	}
+	if len(list) == 0 {
+		return entities.Store{}, ErrNotFound
+	}
+	return list[0], nil
 }
 
 func (s service) Create(ctx context.Context, input CreateInput) (entities.Store, error) {
@@ -44,7 +84,7 @@ func (s service) Create(ctx context.Context, input CreateInput) (entities.Store,
 	ownerID, err := uuid.Parse(input.OwnerID)
 	if err != nil {
 		s.log.Debug("stores:Create - failed to parse owner id", logging.String("stage", "validation"), logging.Error("err", err))
-		return entities.Store{}, errors.New("id владельца не валиден")
+		return entities.Store{}, apperr.NewField(apperr.KindValidation, "ownerID", "stores.ownerIDInvalid", "id владельца не валиден")
 	}
 	store := entities.Store{
 		Name:        input.Name,
@@ -52,7 +92,20 @@ func (s service) Create(ctx context.Context, input CreateInput) (entities.Store,
 		Owner:       &entities.Owner{ID: ownerID},
 	}
 
-	store, err = s.repo.Create(ctx, store)
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		store, err = s.repo.Create(ctx, store)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID.String(),
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityStore,
+			EntityID:   store.ID.String(),
+			After:      auditFields(store),
+		})
+	})
 	if err != nil {
 		s.log.Debug("stores:Create - failed to create store", logging.String("stage", "repository"), logging.Error("err", err))
 		return entities.Store{}, ErrDefault
@@ -62,36 +115,102 @@ func (s service) Create(ctx context.Context, input CreateInput) (entities.Store,
 	return store, nil
 }
 
-func (s service) ReadBy(ctx context.Context, filter ReadByInput) ([]entities.Store, error) {
+func (s service) ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.Store], error) {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
 	defer s.log.Sync()
 
 	// validate filters
-	val, ok := filter.PageNumber.Get()
+	pageNumber, ok := filter.PageNumber.Get()
 	if !ok {
-		filter.PageNumber.Set(1)
-	} else if val < 1 {
-		s.log.Debug("stores:ReadBy - invalid page number", logging.String("stage", "validation"), logging.Uint64("pageNumber", val))
-		return nil, errors.New("номер страницы не может быть меньше 1")
+		pageNumber = 1
+		filter.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("stores:ReadBy - invalid page number", logging.String("stage", "validation"), logging.Uint64("pageNumber", pageNumber))
+		return entities.Page[entities.Store]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "stores.pageNumberTooSmall", "номер страницы не может быть меньше 1")
 	}
 
-	val1, ok := filter.PageSize.Get()
+	pageSize, ok := filter.PageSize.Get()
 	if !ok {
-		filter.PageSize.Set(10)
-	} else if val < 1 || val > 100 {
-		s.log.Debug("stores:ReadBy - invalid page size", logging.String("stage", "validation"), logging.Uint("pageSize", val1))
-		return nil, errors.New("размер страницы должен быть в диапазоне от 1 до 100")
+		pageSize = 10
+		filter.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("stores:ReadBy - invalid page size", logging.String("stage", "validation"), logging.Uint("pageSize", pageSize))
+		return entities.Page[entities.Store]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "stores.pageSizeOutOfRange", "размер страницы должен быть в диапазоне от 1 до 100")
+	}
+
+	cursor, byCursor := filter.Cursor.Get()
+	if byCursor {
+		filter.SortBy.Set(cursor.SortBy)
+		filter.SortOrder.Set(cursor.SortOrder)
+	}
+
+	// cursors need to know how stores are sorted, so defaults of
+	// repositories are made explicit
+	sortBy, sortOrder := SortByCreatedAt, SortOrderDesc
+	if val, ok := filter.SortBy.Get(); ok {
+		if val == SortByName {
+			sortBy = val
+		}
+		sortOrder = SortOrderAsc
+	}
+	if val, ok := filter.SortOrder.Get(); ok {
+		if val != SortOrderAsc && val != SortOrderDesc {
+			s.log.Debug("stores:ReadBy - invalid sort order", logging.String("stage", "validation"), logging.String("sortOrder", val))
+			return entities.Page[entities.Store]{}, apperr.NewField(apperr.KindInvalid, "sortOrder", "stores.sortOrderUnknown", "порядок сортировки должен быть asc или desc")
+		}
+		sortOrder = val
+	}
+	filter.SortBy.Set(sortBy)
+	filter.SortOrder.Set(sortOrder)
+
+	if byCursor {
+		// one more store tells if there is a page after this one
+		filter.PageNumber.Unset()
+		filter.PageSize.Set(pageSize + 1)
 	}
 
 	// filter
 	stores, err := s.repo.ReadBy(ctx, filter)
 	if err != nil {
 		s.log.Debug("stores:ReadBy - failed to read stores", logging.String("stage", "repository"), logging.Error("err", err))
-		return nil, ErrDefault
+		return entities.Page[entities.Store]{}, ErrDefault
+	}
+
+	hasPrev, hasNext := pageNumber > 1, false
+	if byCursor {
+		var more bool
+		stores, more = entities.TrimPage(stores, int(pageSize), cursor.Backward)
+		hasPrev, hasNext = true, more
+		if cursor.Backward {
+			hasPrev, hasNext = more, true
+		}
+	}
+
+	var total *uint64
+	// numbered pages are always counted, clients show how many there are
+	if withTotal, _ := filter.WithTotal.Get(); withTotal || !byCursor {
+		count, err := s.repo.Count(ctx, filter)
+		if err != nil {
+			s.log.Debug("stores:ReadBy - failed to count stores", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Page[entities.Store]{}, ErrDefault
+		}
+		total = &count
+		if !byCursor {
+			hasNext = pageNumber*uint64(pageSize) < count
+		}
+	}
+
+	page := entities.NewPage(stores, hasPrev, hasNext, func(store entities.Store) entities.Cursor {
+		return entities.Cursor{SortBy: sortBy, SortOrder: sortOrder, Key: SortKey(store, sortBy), ID: store.ID}
+	})
+	page.Total = total
+	page.PageSize = pageSize
+	if !byCursor {
+		page.PageNumber = pageNumber
 	}
 
 	s.log.Info("stores:ReadBy - stores read", logging.String("stage", "repository"), logging.Int("count", len(stores)))
-	return stores, nil
+	return page, nil
 }
 
 func (s service) Update(ctx context.Context, id string, input UpdateInput) (entities.Store, error) {
@@ -105,7 +224,7 @@ func (s service) Update(ctx context.Context, id string, input UpdateInput) (enti
 		countChanges++
 		if len(val) < 3 {
 			s.log.Debug("stores:Update - invalid name", logging.String("stage", "validation"), logging.String("name", val))
-			return entities.Store{}, errors.New("название магазина должно содержать минимум 3 символа")
+			return entities.Store{}, apperr.NewField(apperr.KindValidation, "name", "stores.nameTooShort", "название магазина должно содержать минимум 3 символа")
 		}
 	}
 
@@ -114,18 +233,40 @@ func (s service) Update(ctx context.Context, id string, input UpdateInput) (enti
 		countChanges++
 		if len(val) < 3 {
 			s.log.Debug("stores:Update - invalid description", logging.String("stage", "validation"), logging.String("description", val))
-			return entities.Store{}, errors.New("описание магазина должно содержать минимум 3 символа")
+			return entities.Store{}, apperr.NewField(apperr.KindValidation, "description", "stores.descriptionTooShort", "описание магазина должно содержать минимум 3 символа")
 		}
 	}
 
 	if countChanges == 0 {
 		s.log.Debug("stores:Update - no changes", logging.String("stage", "validation"))
-		return entities.Store{}, errors.New("не переданы изменения")
+		return entities.Store{}, apperr.New(apperr.KindValidation, "stores.noChanges", "не переданы изменения")
 	}
 
 	// update
-	store, err := s.repo.Update(ctx, id, input)
+	var store entities.Store
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err != nil {
+			return err
+		}
+		store, err = s.repo.Update(ctx, id, input)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    before.Owner.ID.String(),
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityStore,
+			EntityID:   id,
+			Before:     auditFields(before),
+			After:      auditFields(store),
+		})
+	})
 	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("stores:Update - store not found", logging.String("stage", "repository"), logging.String("storeID", id))
+			return entities.Store{}, err
+		}
 		s.log.Debug("stores:Update - failed to update store", logging.String("stage", "repository"), logging.Error("err", err))
 		return entities.Store{}, ErrDefault
 	}
@@ -138,7 +279,26 @@ func (s service) Delete(ctx context.Context, id string) error {
 	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
 	defer s.log.Sync()
 
-	err := s.repo.Delete(ctx, id)
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, id)
+		if err == ErrNotFound {
+			// nothing to delete
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    before.Owner.ID.String(),
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityStore,
+			EntityID:   id,
+			Before:     auditFields(before),
+		})
+	})
 	if err != nil {
 		s.log.Debug("stores:Delete - failed to delete store", logging.String("stage", "repository"), logging.Error("err", err))
 		return ErrDefault
@@ -147,3 +307,50 @@ func (s service) Delete(ctx context.Context, id string) error {
 	s.log.Info("stores:Delete - store deleted", logging.String("stage", "repository"), logging.String("storeID", id))
 	return nil
 }
+
+func (s service) Restore(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Restore")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		store, err := s.readOne(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    store.Owner.ID.String(),
+			Action:     audit.ActionRestore,
+			EntityType: audit.EntityStore,
+			EntityID:   id,
+			After:      auditFields(store),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("stores:Restore - store is not in trash", logging.String("stage", "repository"), logging.String("storeID", id))
+			return err
+		}
+		s.log.Error("stores:Restore - failed to restore store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("stores:Restore - store restored", logging.String("stage", "repository"), logging.String("storeID", id))
+	return nil
+}
+
+func (s service) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Purge")).End()
+	defer s.log.Sync()
+
+	count, err := s.repo.Purge(ctx, deletedBefore)
+	if err != nil {
+		s.log.Error("stores:Purge - failed to purge stores", logging.String("stage", "repository"), logging.Error("err", err))
+		return 0, ErrDefault
+	}
+
+	s.log.Info("stores:Purge - stores purged", logging.String("stage", "repository"), logging.Int("count", int(count)))
+	return count, nil
+}
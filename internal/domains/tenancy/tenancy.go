@@ -0,0 +1,37 @@
+// Package tenancy tells repositories whose data a request works with, so
+// that storage can keep tenants apart on its own instead of relying on
+// every query to filter by owner.
+package tenancy
+
+import "context"
+
+type (
+	ownerKey  struct{}
+	systemKey struct{}
+)
+
+// WithOwner scopes ctx to data of ownerID. Sellers and api keys work with
+// data of the owner they belong to. Owner takes precedence over WithSystem.
+func WithOwner(ctx context.Context, ownerID string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, ownerID)
+}
+
+// OwnerFrom returns owner ctx is scoped to. Contexts without one see no
+// data of owners at all, unless they are given WithSystem.
+func OwnerFrom(ctx context.Context) (string, bool) {
+	ownerID, ok := ctx.Value(ownerKey{}).(string)
+	return ownerID, ok && ownerID != ""
+}
+
+// WithSystem lets ctx see data of every owner. Only what runs before an
+// owner is known needs it: sign in of sellers, api keys and background
+// jobs. Storage logs reason every time it is used.
+func WithSystem(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, systemKey{}, reason)
+}
+
+// SystemFrom returns reason ctx was given WithSystem for.
+func SystemFrom(ctx context.Context) (string, bool) {
+	reason, ok := ctx.Value(systemKey{}).(string)
+	return reason, ok && reason != ""
+}
@@ -0,0 +1,18 @@
+package transfers
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/transfers/"
+)
+
+var (
+	ErrNotFound          = apperr.New(apperr.KindNotFound, "transfers.notFound", "перемещение не найдено")
+	ErrLineNotFound      = apperr.New(apperr.KindNotFound, "transfers.lineNotFound", "позиция перемещения не найдена")
+	ErrSameWarehouse     = apperr.New(apperr.KindValidation, "transfers.sameWarehouse", "склад отправления и склад назначения должны отличаться")
+	ErrWrongWarehouse    = apperr.New(apperr.KindValidation, "transfers.wrongWarehouse", "склады и размеры должны принадлежать владельцу и складу отправления")
+	ErrInvalidStatus     = apperr.New(apperr.KindConflict, "transfers.invalidStatus", "действие недоступно в текущем статусе перемещения")
+	ErrReceivedTooMuch   = apperr.New(apperr.KindConflict, "transfers.receivedTooMuch", "нельзя принять больше, чем было отправлено")
+	ErrInsufficientStock = apperr.New(apperr.KindConflict, "transfers.insufficientStock", "недостаточно товара на складе отправления")
+	ErrDefault           = apperr.New(apperr.KindInternal, "transfers.default", "что-то пошло не так")
+)
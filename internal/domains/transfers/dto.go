@@ -0,0 +1,43 @@
+package transfers
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+type (
+	CreateInput struct {
+		OwnerID                string            `json:"ownerID" validate:"required"`
+		SourceWarehouseID      string            `json:"sourceWarehouseID" validate:"required,uuid4"`
+		DestinationWarehouseID string            `json:"destinationWarehouseID" validate:"required,uuid4"`
+		Comment                string            `json:"comment" validate:"max=500"`
+		Lines                  []CreateLineInput `json:"lines" validate:"required,min=1,dive"`
+	}
+
+	CreateLineInput struct {
+		SizeID   int64 `json:"sizeID" validate:"required"`
+		Quantity int64 `json:"quantity" validate:"required,gt=0"`
+	}
+
+	ReadByInput struct {
+		// if ID is set, other filters except OwnerID will be ignored
+		ID          entities.OptField[string] `json:"id"`
+		OwnerID     entities.OptField[string] `json:"ownerID"`
+		WarehouseID entities.OptField[string] `json:"warehouseID"` // either source or destination
+		Status      entities.OptField[string] `json:"status"`
+
+		// Pagination
+		PageNumber entities.OptField[uint64] `json:"pageNumber"`
+		PageSize   entities.OptField[uint]   `json:"pageSize"`
+	}
+
+	ReceiveInput struct {
+		Lines []ReceiveLineInput `json:"lines" validate:"dive"`
+		// Final closes the transfer, everything that was
+		// not received is recorded as a discrepancy.
+		Final bool `json:"final"`
+	}
+
+	ReceiveLineInput struct {
+		LineID          int64  `json:"lineID" validate:"required"`
+		Quantity        int64  `json:"quantity" validate:"min=0"`
+		DiscrepancyNote string `json:"discrepancyNote" validate:"max=500"`
+	}
+)
@@ -0,0 +1,327 @@
+package transfers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	TransfersRepository interface {
+		Create(ctx context.Context, transfer entities.Transfer) (entities.Transfer, error)
+		ReadBy(ctx context.Context, filters ReadByInput) ([]entities.Transfer, error)
+		// Count counts transfers matching filters, pagination is ignored.
+		Count(ctx context.Context, filters ReadByInput) (uint64, error)
+		// Delete, Ship and Receive work only with transfers of ownerID,
+		// others are ErrNotFound.
+		Delete(ctx context.Context, ownerID, id string) error
+
+		// Ship and Receive lock the transfer, check its status
+		// and record stock movements in a single transaction.
+		Ship(ctx context.Context, ownerID, id string) (entities.Transfer, error)
+		Receive(ctx context.Context, ownerID, id string, input ReceiveInput) (entities.Transfer, error)
+	}
+
+	Service interface {
+		Create(ctx context.Context, input CreateInput) (entities.Transfer, error)
+		ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Transfer], error)
+		Delete(ctx context.Context, ownerID, id string) error
+
+		// Ship takes goods from the source warehouse.
+		Ship(ctx context.Context, ownerID, id string) (entities.Transfer, error)
+		// Receive puts goods to the destination warehouse.
+		// It can be called several times until the transfer is fully received.
+		Receive(ctx context.Context, ownerID, id string, input ReceiveInput) (entities.Transfer, error)
+	}
+
+	service struct {
+		repo  TransfersRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo TransfersRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// auditFields are fields of a transfer kept in audit log. Lines are not
+// among them, moved quantities are in the stock journal.
+func auditFields(transfer entities.Transfer) map[string]any {
+	fields := map[string]any{"status": transfer.Status}
+	if transfer.Source != nil {
+		fields["sourceWarehouseID"] = transfer.Source.ID.String()
+	}
+	if transfer.Destination != nil {
+		fields["destinationWarehouseID"] = transfer.Destination.ID.String()
+	}
+	return fields
+}
+
+// readOne returns transfer of ownerID by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, ownerID, id string) (entities.Transfer, error) {
+	filter := ReadByInput{}
+	filter.ID.Set(id)
+	filter.OwnerID.Set(ownerID)
+	list, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		return entities.Transfer{}, err
+	}
+	if len(list) == 0 {
+		return entities.Transfer{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (s service) Create(ctx context.Context, input CreateInput) (entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Create")).End()
+	defer s.log.Sync()
+
+	ownerID, err := uuid.Parse(input.OwnerID)
+	if err != nil {
+		s.log.Debug("transfers:Create - failed to parse owner id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "ownerID", "transfers.ownerIDInvalid", "id владельца не валиден")
+	}
+	sourceID, err := uuid.Parse(input.SourceWarehouseID)
+	if err != nil {
+		s.log.Debug("transfers:Create - failed to parse source warehouse id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "sourceWarehouseID", "transfers.sourceWarehouseIDInvalid", "id склада отправления не валиден")
+	}
+	destinationID, err := uuid.Parse(input.DestinationWarehouseID)
+	if err != nil {
+		s.log.Debug("transfers:Create - failed to parse destination warehouse id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "destinationWarehouseID", "transfers.destinationWarehouseIDInvalid", "id склада назначения не валиден")
+	}
+	if sourceID == destinationID {
+		s.log.Debug("transfers:Create - same warehouse", logging.String("stage", "validation"))
+		return entities.Transfer{}, ErrSameWarehouse
+	}
+
+	if len(input.Lines) == 0 {
+		s.log.Debug("transfers:Create - no lines", logging.String("stage", "validation"))
+		return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "lines", "transfers.linesEmpty", "перемещение должно содержать хотя бы одну позицию")
+	}
+	lines := make([]entities.TransferLine, 0, len(input.Lines))
+	seen := make(map[int64]struct{}, len(input.Lines))
+	for _, l := range input.Lines {
+		if l.Quantity <= 0 {
+			s.log.Debug("transfers:Create - invalid quantity", logging.String("stage", "validation"), logging.Int64("sizeID", l.SizeID))
+			return entities.Transfer{}, entities.ErrMovementQuantity
+		}
+		if _, ok := seen[l.SizeID]; ok {
+			s.log.Debug("transfers:Create - duplicate size", logging.String("stage", "validation"), logging.Int64("sizeID", l.SizeID))
+			return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "lines", "transfers.sizeRepeated", "размер не может повторяться в одном перемещении")
+		}
+		seen[l.SizeID] = struct{}{}
+		lines = append(lines, entities.TransferLine{
+			Size:     &entities.Size{ID: l.SizeID},
+			Quantity: l.Quantity,
+		})
+	}
+
+	transfer := entities.NewTransfer(
+		&entities.Owner{ID: ownerID},
+		&entities.Warehouse{ID: sourceID},
+		&entities.Warehouse{ID: destinationID},
+		input.Comment,
+		lines,
+	)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		transfer, err = s.repo.Create(ctx, transfer)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID.String(),
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityTransfer,
+			EntityID:   transfer.ID.String(),
+			After:      auditFields(transfer),
+		})
+	})
+	if err != nil {
+		if err == ErrWrongWarehouse {
+			s.log.Debug("transfers:Create - wrong warehouse", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Transfer{}, err
+		}
+		s.log.Error("transfers:Create - failed to create transfer", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Transfer{}, ErrDefault
+	}
+
+	s.log.Info("transfers:Create - transfer created", logging.String("stage", "repository"), logging.String("transferID", transfer.ID.String()))
+	return transfer, nil
+}
+
+func (s service) ReadBy(ctx context.Context, filters ReadByInput) (entities.Page[entities.Transfer], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	pageNumber, ok := filters.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filters.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("transfers:ReadBy - pageNumber must be greater than 0", logging.String("stage", "validation"))
+		return entities.Page[entities.Transfer]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "transfers.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filters.PageSize.Get()
+	if !ok {
+		pageSize = 10
+		filters.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("transfers:ReadBy - pageSize must be between 1 and 100", logging.String("stage", "validation"))
+		return entities.Page[entities.Transfer]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "transfers.pageSizeOutOfRange", "размер страницы должен быть между 1 и 100")
+	}
+
+	status, ok := filters.Status.Get()
+	if ok {
+		switch status {
+		case entities.TransferDraft, entities.TransferShipped, entities.TransferPartiallyReceived, entities.TransferReceived:
+		default:
+			s.log.Debug("transfers:ReadBy - invalid status", logging.String("stage", "validation"), logging.String("status", status))
+			return entities.Page[entities.Transfer]{}, apperr.NewField(apperr.KindInvalid, "status", "transfers.statusUnknown", "неизвестный статус перемещения")
+		}
+	}
+
+	transfers, err := s.repo.ReadBy(ctx, filters)
+	if err != nil {
+		s.log.Error("transfers:ReadBy - failed to read transfers", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Transfer]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		s.log.Error("transfers:ReadBy - failed to count transfers", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Transfer]{}, ErrDefault
+	}
+
+	s.log.Info("transfers:ReadBy - transfers read", logging.String("stage", "repository"), logging.Int("count", len(transfers)))
+	return entities.NumberedPage(transfers, pageNumber, pageSize, total), nil
+}
+
+func (s service) Delete(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, ownerID, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityTransfer,
+			EntityID:   id,
+			Before:     auditFields(before),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound || err == ErrInvalidStatus {
+			s.log.Debug("transfers:Delete - transfer can not be deleted", logging.String("stage", "repository"), logging.Error("err", err))
+			return err
+		}
+		s.log.Error("transfers:Delete - failed to delete transfer", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("transfers:Delete - transfer deleted", logging.String("stage", "repository"), logging.String("transferID", id))
+	return nil
+}
+
+func (s service) Ship(ctx context.Context, ownerID, id string) (entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Ship")).End()
+	defer s.log.Sync()
+
+	transfer, err := s.change(ctx, ownerID, id, func(ctx context.Context) (entities.Transfer, error) {
+		return s.repo.Ship(ctx, ownerID, id)
+	})
+	if err != nil {
+		if err == ErrNotFound || err == ErrInvalidStatus || err == ErrInsufficientStock {
+			s.log.Debug("transfers:Ship - transfer can not be shipped", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Transfer{}, err
+		}
+		s.log.Error("transfers:Ship - failed to ship transfer", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Transfer{}, ErrDefault
+	}
+
+	s.log.Info("transfers:Ship - transfer shipped", logging.String("stage", "repository"), logging.String("transferID", id))
+	return transfer, nil
+}
+
+func (s service) Receive(ctx context.Context, ownerID, id string, input ReceiveInput) (entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Receive")).End()
+	defer s.log.Sync()
+
+	if len(input.Lines) == 0 && !input.Final {
+		s.log.Debug("transfers:Receive - nothing to receive", logging.String("stage", "validation"))
+		return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "lines", "transfers.linesEmpty", "не переданы принятые позиции")
+	}
+	seen := make(map[int64]struct{}, len(input.Lines))
+	for _, l := range input.Lines {
+		if l.Quantity < 0 {
+			s.log.Debug("transfers:Receive - negative quantity", logging.String("stage", "validation"), logging.Int64("lineID", l.LineID))
+			return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "lines", "transfers.quantityNegative", "принятое количество не может быть отрицательным")
+		}
+		if _, ok := seen[l.LineID]; ok {
+			s.log.Debug("transfers:Receive - duplicate line", logging.String("stage", "validation"), logging.Int64("lineID", l.LineID))
+			return entities.Transfer{}, apperr.NewField(apperr.KindValidation, "lines", "transfers.lineRepeated", "позиция не может повторяться")
+		}
+		seen[l.LineID] = struct{}{}
+	}
+
+	transfer, err := s.change(ctx, ownerID, id, func(ctx context.Context) (entities.Transfer, error) {
+		return s.repo.Receive(ctx, ownerID, id, input)
+	})
+	if err != nil {
+		if err == ErrNotFound || err == ErrLineNotFound || err == ErrInvalidStatus || err == ErrReceivedTooMuch {
+			s.log.Debug("transfers:Receive - transfer can not be received", logging.String("stage", "repository"), logging.Error("err", err))
+			return entities.Transfer{}, err
+		}
+		s.log.Error("transfers:Receive - failed to receive transfer", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Transfer{}, ErrDefault
+	}
+
+	s.log.Info("transfers:Receive - transfer received", logging.String("stage", "repository"), logging.String("transferID", id), logging.String("status", transfer.Status))
+	return transfer, nil
+}
+
+// change runs fn, which moves transfer to another status, and records
+// the status change in audit log.
+func (s service) change(ctx context.Context, ownerID, id string, fn func(ctx context.Context) (entities.Transfer, error)) (entities.Transfer, error) {
+	var transfer entities.Transfer
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		transfer, err = fn(ctx)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityTransfer,
+			EntityID:   id,
+			Before:     auditFields(before),
+			After:      auditFields(transfer),
+		})
+	})
+	return transfer, err
+}
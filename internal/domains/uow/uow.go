@@ -0,0 +1,13 @@
+// Package uow lets a service group several repository calls so that they
+// are applied together or not at all.
+package uow
+
+import "context"
+
+// UnitOfWork runs fn in one transaction. Repositories called with the
+// context passed to fn take part in that transaction. If fn returns an
+// error or panics, nothing it did is kept. Nested calls join the outer
+// transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
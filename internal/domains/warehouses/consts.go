@@ -0,0 +1,19 @@
+package warehouses
+
+import "github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+
+const (
+	PackageName = "internal/domains/warehouses/"
+
+	SortByCreatedAt = "createdAt"
+	SortByName      = "name"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+var (
+	ErrNotFound      = apperr.New(apperr.KindNotFound, "warehouses.notFound", "склад не найден")
+	ErrStoreMismatch = apperr.New(apperr.KindValidation, "warehouses.storeMismatch", "склад и магазин должны принадлежать одному владельцу")
+	ErrDefault       = apperr.New(apperr.KindInternal, "warehouses.default", "что-то пошло не так")
+)
@@ -0,0 +1,322 @@
+package warehouses
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/uow"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type (
+	WarehousesRepository interface {
+		Create(ctx context.Context, warehouse entities.Warehouse) (entities.Warehouse, error)
+		ReadBy(ctx context.Context, filter ReadByInput) ([]entities.Warehouse, error)
+		// Count counts warehouses matching filters, pagination is ignored.
+		Count(ctx context.Context, filter ReadByInput) (uint64, error)
+		// Update, Delete, LinkStore and UnlinkStore change only warehouses
+		// of ownerID, others are ErrNotFound.
+		Update(ctx context.Context, ownerID, id string, changeset UpdateInput) (entities.Warehouse, error)
+		Delete(ctx context.Context, ownerID, id string) error
+
+		LinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error
+		UnlinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error
+	}
+
+	Service interface {
+		Create(ctx context.Context, input CreateInput) (entities.Warehouse, error)
+		ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.Warehouse], error)
+		Update(ctx context.Context, ownerID, id string, input UpdateInput) (entities.Warehouse, error)
+		Delete(ctx context.Context, ownerID, id string) error
+
+		// LinkStore marks warehouse as a supplier of the store, both have
+		// to belong to ownerID.
+		LinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error
+		UnlinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error
+	}
+
+	service struct {
+		repo  WarehousesRepository
+		uow   uow.UnitOfWork
+		audit audit.Recorder
+		log   *logging.Logger
+	}
+)
+
+var _ Service = (*service)(nil)
+
+func NewService(repo WarehousesRepository, uow uow.UnitOfWork, audit audit.Recorder, log *logging.Logger) service {
+	return service{repo: repo, uow: uow, audit: audit, log: log}
+}
+
+// auditFields are fields of a warehouse kept in audit log.
+func auditFields(warehouse entities.Warehouse) map[string]any {
+	return map[string]any{
+		"name":        warehouse.Name,
+		"description": warehouse.Description,
+	}
+}
+
+// readOne returns warehouse of ownerID by id or ErrNotFound.
+func (s service) readOne(ctx context.Context, ownerID, id string) (entities.Warehouse, error) {
+	filter := ReadByInput{}
+	filter.ID.Set(id)
+	filter.OwnerID.Set(ownerID)
+	list, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		return entities.Warehouse{}, err
+	}
+	if len(list) == 0 {
+		return entities.Warehouse{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (s service) Create(ctx context.Context, input CreateInput) (entities.Warehouse, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Create")).End()
+	defer s.log.Sync()
+
+	ownerID, err := uuid.Parse(input.OwnerID)
+	if err != nil {
+		s.log.Debug("warehouses:Create - failed to parse owner id", logging.String("stage", "validation"), logging.Error("err", err))
+		return entities.Warehouse{}, apperr.NewField(apperr.KindValidation, "ownerID", "warehouses.ownerIDInvalid", "id владельца не валиден")
+	}
+
+	warehouse := entities.NewWarehouse(&entities.Owner{ID: ownerID}, input.Name, input.Description)
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		warehouse, err = s.repo.Create(ctx, warehouse)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID.String(),
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityWarehouse,
+			EntityID:   warehouse.ID.String(),
+			After:      auditFields(warehouse),
+		})
+	})
+	if err != nil {
+		s.log.Error("warehouses:Create - failed to create warehouse", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Warehouse{}, ErrDefault
+	}
+
+	s.log.Info("warehouses:Create - warehouse created", logging.String("stage", "repository"), logging.String("warehouseID", warehouse.ID.String()), logging.String("ownerID", ownerID.String()))
+	return warehouse, nil
+}
+
+func (s service) ReadBy(ctx context.Context, filter ReadByInput) (entities.Page[entities.Warehouse], error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.ReadBy")).End()
+	defer s.log.Sync()
+
+	// validate filters
+	pageNumber, ok := filter.PageNumber.Get()
+	if !ok {
+		pageNumber = 1
+		filter.PageNumber.Set(pageNumber)
+	} else if pageNumber < 1 {
+		s.log.Debug("warehouses:ReadBy - invalid page number", logging.String("stage", "validation"), logging.Uint64("pageNumber", pageNumber))
+		return entities.Page[entities.Warehouse]{}, apperr.NewField(apperr.KindInvalid, "pageNumber", "warehouses.pageNumberTooSmall", "номер страницы не может быть меньше 1")
+	}
+
+	pageSize, ok := filter.PageSize.Get()
+	if !ok {
+		pageSize = 10
+		filter.PageSize.Set(pageSize)
+	} else if pageSize < 1 || pageSize > 100 {
+		s.log.Debug("warehouses:ReadBy - invalid page size", logging.String("stage", "validation"), logging.Uint("pageSize", pageSize))
+		return entities.Page[entities.Warehouse]{}, apperr.NewField(apperr.KindInvalid, "pageSize", "warehouses.pageSizeOutOfRange", "размер страницы должен быть в диапазоне от 1 до 100")
+	}
+
+	sortBy, ok := filter.SortBy.Get()
+	if ok {
+		switch sortBy {
+		case SortByName, SortByCreatedAt:
+		default:
+			s.log.Debug("warehouses:ReadBy - invalid sortBy", logging.String("stage", "validation"), logging.String("sortBy", sortBy))
+			return entities.Page[entities.Warehouse]{}, apperr.NewField(apperr.KindInvalid, "sortBy", "warehouses.sortByUnknown", "сортировка должна быть одной из name, createdAt")
+		}
+	}
+
+	sortOrder, ok := filter.SortOrder.Get()
+	if ok {
+		switch sortOrder {
+		case SortOrderAsc, SortOrderDesc:
+		default:
+			s.log.Debug("warehouses:ReadBy - invalid sortOrder", logging.String("stage", "validation"), logging.String("sortOrder", sortOrder))
+			return entities.Page[entities.Warehouse]{}, apperr.NewField(apperr.KindInvalid, "sortOrder", "warehouses.sortOrderUnknown", "сортировка должна быть одной из asc, desc")
+		}
+	}
+
+	warehouses, err := s.repo.ReadBy(ctx, filter)
+	if err != nil {
+		s.log.Error("warehouses:ReadBy - failed to read warehouses", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Warehouse]{}, ErrDefault
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		s.log.Error("warehouses:ReadBy - failed to count warehouses", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Page[entities.Warehouse]{}, ErrDefault
+	}
+
+	s.log.Info("warehouses:ReadBy - warehouses read", logging.String("stage", "repository"), logging.Int("count", len(warehouses)))
+	return entities.NumberedPage(warehouses, pageNumber, pageSize, total), nil
+}
+
+func (s service) Update(ctx context.Context, ownerID, id string, input UpdateInput) (entities.Warehouse, error) {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Update")).End()
+	defer s.log.Sync()
+
+	// validate
+	countChanges := 0
+	val, ok := input.Name.Get()
+	if ok {
+		countChanges++
+		if len(val) < 3 {
+			s.log.Debug("warehouses:Update - invalid name", logging.String("stage", "validation"), logging.String("name", val))
+			return entities.Warehouse{}, apperr.NewField(apperr.KindValidation, "name", "warehouses.nameTooShort", "название склада должно содержать минимум 3 символа")
+		}
+	}
+
+	if _, ok := input.Description.Get(); ok {
+		countChanges++
+	}
+
+	if countChanges == 0 {
+		s.log.Debug("warehouses:Update - no changes", logging.String("stage", "validation"))
+		return entities.Warehouse{}, apperr.New(apperr.KindValidation, "warehouses.noChanges", "не переданы изменения")
+	}
+
+	var warehouse entities.Warehouse
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		warehouse, err = s.repo.Update(ctx, ownerID, id, input)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityWarehouse,
+			EntityID:   id,
+			Before:     auditFields(before),
+			After:      auditFields(warehouse),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("warehouses:Update - warehouse not found", logging.String("stage", "repository"), logging.String("warehouseID", id))
+			return entities.Warehouse{}, err
+		}
+		s.log.Error("warehouses:Update - failed to update warehouse", logging.String("stage", "repository"), logging.Error("err", err))
+		return entities.Warehouse{}, ErrDefault
+	}
+
+	s.log.Info("warehouses:Update - warehouse updated", logging.String("stage", "repository"), logging.String("warehouseID", warehouse.ID.String()))
+	return warehouse, nil
+}
+
+func (s service) Delete(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.Delete")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		before, err := s.readOne(ctx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, ownerID, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityWarehouse,
+			EntityID:   id,
+			Before:     auditFields(before),
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("warehouses:Delete - warehouse not found", logging.String("stage", "repository"), logging.String("warehouseID", id))
+			return err
+		}
+		s.log.Error("warehouses:Delete - failed to delete warehouse", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("warehouses:Delete - warehouse deleted", logging.String("stage", "repository"), logging.String("warehouseID", id))
+	return nil
+}
+
+func (s service) LinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.LinkStore")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.LinkStore(ctx, ownerID, warehouseID, storeID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionLink,
+			EntityType: audit.EntityWarehouse,
+			EntityID:   warehouseID,
+			After:      map[string]any{"storeID": storeID},
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("warehouses:LinkStore - warehouse not found", logging.String("stage", "repository"), logging.String("warehouseID", warehouseID))
+			return err
+		}
+		if err == ErrStoreMismatch {
+			s.log.Debug("warehouses:LinkStore - store and warehouse owners differ", logging.String("stage", "repository"), logging.String("warehouseID", warehouseID), logging.String("storeID", storeID))
+			return err
+		}
+		s.log.Error("warehouses:LinkStore - failed to link store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("warehouses:LinkStore - store linked", logging.String("stage", "repository"), logging.String("warehouseID", warehouseID), logging.String("storeID", storeID))
+	return nil
+}
+
+func (s service) UnlinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error {
+	defer telemetry.NewSpan(ctx, telemetry.Name(PackageName+"service.UnlinkStore")).End()
+	defer s.log.Sync()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.UnlinkStore(ctx, ownerID, warehouseID, storeID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Change{
+			OwnerID:    ownerID,
+			Action:     audit.ActionUnlink,
+			EntityType: audit.EntityWarehouse,
+			EntityID:   warehouseID,
+			After:      map[string]any{"storeID": storeID},
+		})
+	})
+	if err != nil {
+		if err == ErrNotFound {
+			s.log.Debug("warehouses:UnlinkStore - warehouse not found", logging.String("stage", "repository"), logging.String("warehouseID", warehouseID))
+			return err
+		}
+		s.log.Error("warehouses:UnlinkStore - failed to unlink store", logging.String("stage", "repository"), logging.Error("err", err))
+		return ErrDefault
+	}
+
+	s.log.Info("warehouses:UnlinkStore - store unlinked", logging.String("stage", "repository"), logging.String("warehouseID", warehouseID), logging.String("storeID", storeID))
+	return nil
+}
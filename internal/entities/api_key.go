@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets scripts act on behalf of an owner within its scopes. Only
+// hash of the key is stored, Prefix helps owners to tell keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Owner      *Owner     `json:"owner,omitempty"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func NewAPIKey(owner *Owner, name, prefix, hash string, scopes []string) APIKey {
+	return APIKey{
+		ID:        uuid.New(),
+		Owner:     owner,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+}
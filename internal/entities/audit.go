@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry records one change made to an owner's data. Entries are
+// never changed or removed.
+type AuditEntry struct {
+	ID         uuid.UUID `json:"id"`
+	OwnerID    uuid.UUID `json:"ownerID"`
+	ActorID    string    `json:"actorID"`
+	ActorRole  string    `json:"actorRole"`          // owner/seller/system
+	APIKeyID   string    `json:"apiKeyID,omitempty"` // set when actor used an api key
+	Action     string    `json:"action"`             // create/update/delete/restore/link/unlink
+	EntityType string    `json:"entityType"`
+	EntityID   string    `json:"entityID"`
+	// Changes maps every changed field to its values before and after:
+	// {"name": {"before": "old", "after": "new"}}.
+	Changes   json.RawMessage `json:"changes"`
+	RequestID string          `json:"requestID,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
@@ -15,12 +15,13 @@ type (
 	}
 
 	Category struct {
-		ID             uuid.UUID `json:"id"`
-		Store          *Store    `json:"store,omitempty"`
-		ParentCategory *Category `json:"parentCategory,omitempty"`
-		Name           string    `json:"name" validate:"required,max=255"`
-		Article        *string   `json:"article" validate:"required,max=100"`
-		IconURL        string    `json:"iconURL"`
-		CreatedAt      time.Time `json:"createdAt"`
+		ID             uuid.UUID  `json:"id"`
+		Store          *Store     `json:"store,omitempty"`
+		ParentCategory *Category  `json:"parentCategory,omitempty"`
+		Name           string     `json:"name" validate:"required,max=255"`
+		Article        *string    `json:"article" validate:"required,max=100"`
+		IconURL        string     `json:"iconURL"`
+		CreatedAt      time.Time  `json:"createdAt"`
+		DeletedAt      *time.Time `json:"deletedAt,omitempty"` // set while category is in trash
 	}
 )
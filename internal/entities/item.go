@@ -1,14 +1,14 @@
 package entities
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 )
 
 var (
-	ErrSizeExclusive = errors.New("размер должен быть либо числовым диапазоном, либо символом")
+	ErrSizeExclusive = apperr.New(apperr.KindValidation, "size.exclusive", "размер должен быть либо числовым диапазоном, либо символом")
 )
 
 type (
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+)
+
+// Types of stock movements. Every change of Size.Quantity
+// must be recorded as one of these.
+const (
+	MovementReceipt     = "receipt"     // goods received from a supplier
+	MovementSale        = "sale"        // goods sold to a customer
+	MovementReturn      = "return"      // goods returned by a customer
+	MovementWriteOff    = "writeOff"    // goods damaged, lost, etc.
+	MovementTransferOut = "transferOut" // goods sent to another warehouse
+	MovementTransferIn  = "transferIn"  // goods received from another warehouse
+	MovementCorrection  = "correction"  // inventory count correction, can be negative
+)
+
+var (
+	ErrMovementType     = apperr.NewField(apperr.KindValidation, "type", "movement.unknownType", "неизвестный тип движения товара")
+	ErrMovementQuantity = apperr.NewField(apperr.KindValidation, "quantity", "movement.quantityNotPositive", "количество в движении товара должно быть больше 0")
+)
+
+// Movement is an append-only record in the stock journal.
+// Quantity is signed: positive for incoming goods and negative for outgoing.
+type Movement struct {
+	ID          int64      `json:"id"`
+	Size        *Size      `json:"size,omitempty"`
+	Type        string     `json:"type"`
+	Quantity    int64      `json:"quantity"`
+	Balance     int64      `json:"balance"`               // quantity of the size right after this movement
+	ReferenceID *uuid.UUID `json:"referenceID,omitempty"` // receipt, transfer or any other document
+	Comment     string     `json:"comment,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// NewMovement turns the absolute quantity into a signed one according to the type.
+// Only corrections accept negative quantity.
+func NewMovement(size *Size, movementType string, quantity int64, comment string) (Movement, error) {
+	switch movementType {
+	case MovementReceipt, MovementReturn, MovementTransferIn:
+		if quantity <= 0 {
+			return Movement{}, ErrMovementQuantity
+		}
+	case MovementSale, MovementWriteOff, MovementTransferOut:
+		if quantity <= 0 {
+			return Movement{}, ErrMovementQuantity
+		}
+		quantity = -quantity
+	case MovementCorrection:
+		if quantity == 0 {
+			return Movement{}, apperr.NewField(apperr.KindValidation, "quantity", "movement.zeroCorrection", "корректировка не может быть нулевой")
+		}
+	default:
+		return Movement{}, ErrMovementType
+	}
+
+	return Movement{
+		Size:      size,
+		Type:      movementType,
+		Quantity:  quantity,
+		Comment:   comment,
+		CreatedAt: time.Now(),
+	}, nil
+}
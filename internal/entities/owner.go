@@ -1,17 +1,21 @@
 package entities
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 	"golang.org/x/crypto/bcrypt"
 )
 
-const hashCost = bcrypt.MinCost
+const (
+	MinHashCost     = bcrypt.MinCost
+	MaxHashCost     = bcrypt.MaxCost
+	DefaultHashCost = bcrypt.DefaultCost
+)
 
 var (
-	ErrPasswordTooShort = errors.New("пароль не может содержать менее 5 символов")
+	ErrPasswordTooShort = apperr.NewField(apperr.KindValidation, "password", "owner.passwordTooShort", "пароль не может содержать менее 5 символов")
 )
 
 type Owner struct {
@@ -24,25 +28,41 @@ type Owner struct {
 	CreatedAt   time.Time `json:"createdAt"`
 }
 
-func NewOwner(phoneNumber, fullName, username, password string) (Owner, error) {
+func NewOwner(phoneNumber, fullName, username, password string, hashCost int) (Owner, error) {
+	o := Owner{
+		ID:          uuid.New(),
+		PhoneNumber: phoneNumber,
+		FullName:    fullName,
+		Username:    username,
+	}
+	if err := o.SetPassword(password, hashCost); err != nil {
+		return Owner{}, err
+	}
+
+	return o, nil
+}
+
+// SetPassword replaces password hash of the owner.
+func (o *Owner) SetPassword(password string, hashCost int) error {
 	if len(password) < 5 {
-		return Owner{}, ErrPasswordTooShort
+		return ErrPasswordTooShort
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), hashCost)
 	if err != nil {
-		return Owner{}, err
+		return err
 	}
 
-	return Owner{
-		ID:          uuid.New(),
-		PhoneNumber: phoneNumber,
-		FullName:    fullName,
-		Username:    username,
-		Password:    string(hashedPassword),
-	}, nil
+	o.Password = string(hashedPassword)
+	return nil
 }
 
 func (o Owner) ComparePassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(o.Password), []byte(password))
 }
+
+// NeedsRehash reports whether password hash is weaker than hashCost.
+func (o Owner) NeedsRehash(hashCost int) bool {
+	cost, err := bcrypt.Cost([]byte(o.Password))
+	return err == nil && cost < hashCost
+}
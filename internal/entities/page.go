@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// Cursor points at a row of a sorted list. A page read with it starts
+	// right after that row, or ends right before it when Backward is set.
+	Cursor struct {
+		SortBy    string `json:"s"`
+		SortOrder string `json:"o"`
+		// Key is SortBy field of the row, nil for NULL. Keys compare as
+		// strings in the order rows are sorted, see CompareKeys.
+		Key      *string   `json:"k"`
+		ID       uuid.UUID `json:"i"`
+		Backward bool      `json:"b,omitempty"`
+	}
+
+	// Page is a part of a list with cursors to pages around it.
+	Page[T any] struct {
+		Items []T
+		// PageNumber is zero for pages read with a cursor.
+		PageNumber uint64
+		PageSize   uint
+		HasNext    bool
+		// Total is the number of items in the whole list. Pages read with
+		// a cursor have it only when asked for, it costs one more query.
+		Total *uint64
+		// Next and Prev are nil at the ends of the list and for lists
+		// that are not read with cursors.
+		Next *Cursor
+		Prev *Cursor
+	}
+)
+
+// TimeKey makes a cursor key of t. Keys of the same length compare like
+// times they were made from.
+func TimeKey(t time.Time) *string {
+	key := t.UTC().Format("2006-01-02T15:04:05.000000Z")
+	return &key
+}
+
+// CompareKeys orders cursor keys like postgres orders their values in
+// ascending order: NULL goes last.
+func CompareKeys(a, b *string) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	}
+	return 0
+}
+
+// TrimPage drops the item a list was read past size for, it tells that
+// there is more in the direction of reading. Reading backward it is the
+// first item, otherwise the last.
+func TrimPage[T any](items []T, size int, backward bool) ([]T, bool) {
+	if len(items) <= size {
+		return items, false
+	}
+	if backward {
+		return items[len(items)-size:], true
+	}
+	return items[:size], true
+}
+
+// NumberedPage is page pageNumber of a list of total items.
+func NumberedPage[T any](items []T, pageNumber uint64, pageSize uint, total uint64) Page[T] {
+	return Page[T]{
+		Items:      items,
+		PageNumber: pageNumber,
+		PageSize:   pageSize,
+		HasNext:    pageNumber*uint64(pageSize) < total,
+		Total:      &total,
+	}
+}
+
+// WholePage is a list that is never split into pages.
+func WholePage[T any](items []T) Page[T] {
+	return NumberedPage(items, 1, uint(len(items)), uint64(len(items)))
+}
+
+// NewPage points cursors at the first and the last items of a page,
+// hasPrev and hasNext tell if there is anything before and after them.
+func NewPage[T any](items []T, hasPrev, hasNext bool, cursorOf func(T) Cursor) Page[T] {
+	page := Page[T]{Items: items, HasNext: hasNext}
+	if len(items) == 0 {
+		return page
+	}
+	if hasNext {
+		next := cursorOf(items[len(items)-1])
+		page.Next = &next
+	}
+	if hasPrev {
+		prev := cursorOf(items[0])
+		prev.Backward = true
+		page.Prev = &prev
+	}
+	return page
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// Receipt is a record of a single sale in a store.
+	Receipt struct {
+		ID        uuid.UUID     `json:"id"`
+		Store     *Store        `json:"store,omitempty"`
+		Seller    *Seller       `json:"seller,omitempty"` // nil when the owner made the sale
+		Lines     []ReceiptLine `json:"lines"`
+		Total     float64       `json:"total"`
+		CreatedAt time.Time     `json:"createdAt"`
+	}
+
+	ReceiptLine struct {
+		ID       int64   `json:"id"`
+		Item     *Item   `json:"item,omitempty"`
+		Size     *Size   `json:"size,omitempty"`
+		Quantity int64   `json:"quantity"`
+		Price    float64 `json:"price"`    // retail price of one item at the moment of sale
+		Discount float64 `json:"discount"` // discount for the whole line
+		Total    float64 `json:"total"`
+	}
+)
+
+func NewReceipt(store *Store, seller *Seller, lines []ReceiptLine) Receipt {
+	return Receipt{
+		ID:        uuid.New(),
+		Store:     store,
+		Seller:    seller,
+		Lines:     lines,
+		CreatedAt: time.Now(),
+	}
+}
+
+// CalculateTotal fills totals of every line and of the whole receipt.
+func (r *Receipt) CalculateTotal() {
+	r.Total = 0
+	for i := range r.Lines {
+		l := &r.Lines[i]
+		l.Total = roundMoney(l.Price*float64(l.Quantity) - l.Discount)
+		r.Total += l.Total
+	}
+	r.Total = roundMoney(r.Total)
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ReceiptReturn is a return of goods from a single receipt line.
+type ReceiptReturn struct {
+	ID        uuid.UUID    `json:"id"`
+	Line      *ReceiptLine `json:"line,omitempty"`
+	Warehouse *Warehouse   `json:"warehouse,omitempty"` // where returned goods are put back
+	Seller    *Seller      `json:"seller,omitempty"`
+	Quantity  int64        `json:"quantity"`
+	Refund    float64      `json:"refund"`
+	Reason    string       `json:"reason"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+func NewReceiptReturn(line *ReceiptLine, warehouse *Warehouse, seller *Seller, quantity int64, refund float64, reason string) ReceiptReturn {
+	return ReceiptReturn{
+		ID:        uuid.New(),
+		Line:      line,
+		Warehouse: warehouse,
+		Seller:    seller,
+		Quantity:  quantity,
+		Refund:    roundMoney(refund),
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+}
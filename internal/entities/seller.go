@@ -7,19 +7,56 @@ import (
 )
 
 type Seller struct {
+	ID          uuid.UUID `json:"id"`
+	Owner       *Owner    `json:"owner,omitempty"`
+	Stores      []Store   `json:"stores,omitempty"` // stores where seller is allowed to work
+	Username    string    `json:"username" validate:"required,max=500"`
+	FullName    string    `json:"fullName" validate:"required"`
+	PhoneNumber string    `json:"phoneNumber,omitempty" validate:"max=500"`
+	IsActive    bool      `json:"isActive"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func NewSeller(owner *Owner, username, fullName, phoneNumber string) Seller {
+	return Seller{
+		ID:          uuid.New(),
+		Owner:       owner,
+		Username:    username,
+		FullName:    fullName,
+		PhoneNumber: phoneNumber,
+		IsActive:    true,
+		CreatedAt:   time.Now(),
+	}
+}
+
+const (
+	SellerLoginPending  = "pending"
+	SellerLoginApproved = "approved"
+	SellerLoginRejected = "rejected"
+	SellerLoginUsed     = "used"
+)
+
+// SellerLoginRequest is created when a seller wants to sign in. It is
+// completed either with a one-time code sent to the seller or after
+// the owner approves it.
+type SellerLoginRequest struct {
 	ID        uuid.UUID `json:"id"`
-	Owner     *Owner    `json:"owner,omitempty"`
-	Username  string    `json:"username" validate:"required,max=500"`
-	FullName  string    `json:"fullName" validate:"required"`
+	Seller    *Seller   `json:"seller,omitempty"`
+	CodeHash  string    `json:"-"`
+	Attempts  int       `json:"-"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-func NewSeller(owner *Owner, username, fullName string) Seller {
-	return Seller{
+func NewSellerLoginRequest(seller *Seller, codeHash string, ttl time.Duration) SellerLoginRequest {
+	now := time.Now()
+	return SellerLoginRequest{
 		ID:        uuid.New(),
-		Owner:     owner,
-		Username:  username,
-		FullName:  fullName,
-		CreatedAt: time.Now(),
+		Seller:    seller,
+		CodeHash:  codeHash,
+		Status:    SellerLoginPending,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
 	}
 }
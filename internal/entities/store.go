@@ -14,6 +14,7 @@ type Store struct {
 	Name        string      `json:"name" validate:"required"`
 	Description string      `json:"description" validate:"required"`
 	CreatedAt   time.Time   `json:"createdAt"`
+	DeletedAt   *time.Time  `json:"deletedAt,omitempty"` // set while store is in trash
 }
 
 func NewStore(owner *Owner, name, description string) Store {
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a transfer document.
+// draft -> shipped -> (partiallyReceived ->) received
+const (
+	TransferDraft             = "draft"
+	TransferShipped           = "shipped"
+	TransferPartiallyReceived = "partiallyReceived"
+	TransferReceived          = "received"
+)
+
+type (
+	// Transfer moves goods from one warehouse of an owner to another.
+	Transfer struct {
+		ID          uuid.UUID      `json:"id"`
+		Owner       *Owner         `json:"owner,omitempty"`
+		Source      *Warehouse     `json:"source,omitempty"`
+		Destination *Warehouse     `json:"destination,omitempty"`
+		Status      string         `json:"status"`
+		Comment     string         `json:"comment"`
+		Lines       []TransferLine `json:"lines,omitempty"`
+		CreatedAt   time.Time      `json:"createdAt"`
+		ShippedAt   *time.Time     `json:"shippedAt,omitempty"`
+		ReceivedAt  *time.Time     `json:"receivedAt,omitempty"`
+	}
+
+	TransferLine struct {
+		ID               int64  `json:"id"`
+		Size             *Size  `json:"size,omitempty"` // size at the source warehouse
+		Quantity         int64  `json:"quantity"`
+		ReceivedQuantity int64  `json:"receivedQuantity"`
+		Discrepancy      int64  `json:"discrepancy"` // shipped but never received
+		DiscrepancyNote  string `json:"discrepancyNote,omitempty"`
+	}
+)
+
+func NewTransfer(owner *Owner, source, destination *Warehouse, comment string, lines []TransferLine) Transfer {
+	return Transfer{
+		ID:          uuid.New(),
+		Owner:       owner,
+		Source:      source,
+		Destination: destination,
+		Status:      TransferDraft,
+		Comment:     comment,
+		Lines:       lines,
+		CreatedAt:   time.Now(),
+	}
+}
@@ -0,0 +1,49 @@
+// Package notifications delivers short messages (one-time codes,
+// reset links) to users. Real providers (SMS, email) are expected to
+// live next to the Stdout implementation.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+)
+
+// Stdout prints messages instead of delivering them. It is meant
+// for dev mode only.
+type Stdout struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewStdout() *Stdout {
+	return &Stdout{out: os.Stdout}
+}
+
+func (n *Stdout) Send(ctx context.Context, to, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.out, "[notification] to=%q: %s\n", to, message)
+	return err
+}
+
+// Log writes messages to the application log instead of delivering
+// them. Messages contain secrets, so it must not be used where logs are
+// readable by anyone but the developer.
+type Log struct {
+	log *logging.Logger
+}
+
+func NewLog(log *logging.Logger) Log {
+	return Log{log: log}
+}
+
+func (n Log) Send(ctx context.Context, to, message string) error {
+	n.log.Info("notifications:Log - message", logging.String("to", to), logging.String("message", message))
+	return nil
+}
@@ -0,0 +1,274 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type categoriesRepository struct {
+	db *database
+}
+
+var _ categories.CategoriesRepository = categoriesRepository{}
+
+func (r categoriesRepository) Create(ctx context.Context, input entities.Category) (entities.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if input.Store == nil {
+		return entities.Category{}, errForeignKey
+	}
+	if _, ok := r.db.stores[input.Store.ID]; !ok {
+		return entities.Category{}, errForeignKey
+	}
+	if input.ParentCategory != nil {
+		if _, ok := r.db.categories[input.ParentCategory.ID]; !ok {
+			return entities.Category{}, errForeignKey
+		}
+	}
+
+	input.ID = uuid.New()
+	input.CreatedAt = timeNow()
+	r.db.categories[input.ID] = copyCategory(input)
+	return copyCategory(input), nil
+}
+
+func (r categoriesRepository) ReadBy(ctx context.Context, filters categories.ReadByInput) ([]entities.Category, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	deleted, _ := filters.Deleted.Get()
+
+	if id, ok := filters.ID.Get(); ok {
+		categoryID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]entities.Category, 0, 1)
+		if category, ok := r.db.categories[categoryID]; ok && deleted == (category.DeletedAt != nil) {
+			result = append(result, copyCategory(category))
+		}
+		return result, nil
+	}
+
+	var storeID, parentCategoryID *uuid.UUID
+	if val, ok := filters.StoreID.Get(); ok {
+		id, err := uuid.Parse(val)
+		if err != nil {
+			return nil, err
+		}
+		storeID = &id
+	}
+	if val, ok := filters.ParentCategoryID.Get(); ok {
+		id, err := uuid.Parse(val)
+		if err != nil {
+			return nil, err
+		}
+		parentCategoryID = &id
+	}
+	text, hasText := filters.Text.Get()
+	text = strings.ToLower(text)
+
+	result := make([]entities.Category, 0)
+	for _, category := range r.db.categories {
+		if deleted != (category.DeletedAt != nil) {
+			continue
+		}
+		if storeID != nil && category.Store.ID != *storeID {
+			continue
+		}
+		if parentCategoryID != nil && (category.ParentCategory == nil || category.ParentCategory.ID != *parentCategoryID) {
+			continue
+		}
+		if hasText && !strings.Contains(strings.ToLower(category.Name), text) {
+			continue
+		}
+		result = append(result, copyCategory(category))
+	}
+
+	sortBy, sortOrder := categories.SortByCreatedAt, categories.SortOrderDesc
+	if val, ok := filters.SortBy.Get(); ok {
+		if val == categories.SortByName || val == categories.SortByArticle {
+			sortBy = val
+		}
+		sortOrder, _ = filters.SortOrder.Get()
+	}
+	key := func(c entities.Category) *string { return categories.SortKey(c, sortBy) }
+	id := func(c entities.Category) uuid.UUID { return c.ID }
+
+	var cursor *entities.Cursor
+	if c, ok := filters.Cursor.Get(); ok {
+		cursor = &c
+	}
+	pageNumber, _ := filters.PageNumber.Get()
+	pageSize, _ := filters.PageSize.Get()
+	result = sortPage(result, key, id, sortOrder, cursor, pageNumber, uint64(pageSize))
+
+	return result, nil
+}
+
+func (r categoriesRepository) Count(ctx context.Context, filters categories.ReadByInput) (uint64, error) {
+	filters.PageNumber = entities.OptField[uint64]{}
+	filters.PageSize = entities.OptField[uint]{}
+	filters.Cursor = entities.OptField[entities.Cursor]{}
+	list, err := r.ReadBy(ctx, filters)
+	return uint64(len(list)), err
+}
+
+func (r categoriesRepository) Update(ctx context.Context, changeset categories.UpdateInput) (entities.Category, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	categoryID, err := uuid.Parse(changeset.ID)
+	if err != nil {
+		return entities.Category{}, err
+	}
+	category, ok := r.db.categories[categoryID]
+	if !ok || category.DeletedAt != nil {
+		return entities.Category{}, categories.ErrNotFound
+	}
+
+	if name, ok := changeset.Name.Get(); ok {
+		category.Name = name
+	}
+	if article, ok := changeset.Article.Get(); ok {
+		category.Article = article
+	}
+	if parent, ok := changeset.ParentCategoryID.Get(); ok {
+		category.ParentCategory = nil
+		if parent != nil {
+			parentID, err := uuid.Parse(*parent)
+			if err != nil {
+				return entities.Category{}, err
+			}
+			if _, ok := r.db.categories[parentID]; !ok || parentID == categoryID {
+				return entities.Category{}, errForeignKey
+			}
+			category.ParentCategory = &entities.Category{ID: parentID}
+		}
+	}
+
+	r.db.categories[categoryID] = copyCategory(category)
+	return copyCategory(category), nil
+}
+
+// subtree returns id with ids of all its descendants. Callers must hold
+// the lock.
+func (r categoriesRepository) subtree(id uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	for i := 0; i < len(ids); i++ {
+		for childID, child := range r.db.categories {
+			if child.ParentCategory != nil && child.ParentCategory.ID == ids[i] {
+				ids = append(ids, childID)
+			}
+		}
+	}
+	return ids
+}
+
+// Delete moves category to trash together with its live descendants.
+func (r categoriesRepository) Delete(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	if _, ok := r.db.categories[categoryID]; !ok {
+		return nil
+	}
+
+	deletedAt := timeNow()
+	for _, id := range r.subtree(categoryID) {
+		category := r.db.categories[id]
+		if category.DeletedAt == nil {
+			category.DeletedAt = &deletedAt
+			r.db.categories[id] = category
+		}
+	}
+	return nil
+}
+
+// Restore takes category out of trash with descendants deleted together
+// with it.
+func (r categoriesRepository) Restore(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		return categories.ErrNotFound
+	}
+	category, ok := r.db.categories[categoryID]
+	if !ok || category.DeletedAt == nil {
+		return categories.ErrNotFound
+	}
+	if r.db.stores[category.Store.ID].DeletedAt != nil {
+		return categories.ErrParentDeleted
+	}
+	if category.ParentCategory != nil && r.db.categories[category.ParentCategory.ID].DeletedAt != nil {
+		return categories.ErrParentDeleted
+	}
+
+	deletedAt := *category.DeletedAt
+	for _, id := range r.subtree(categoryID) {
+		category := r.db.categories[id]
+		if category.DeletedAt != nil && category.DeletedAt.Equal(deletedAt) {
+			category.DeletedAt = nil
+			r.db.categories[id] = category
+		}
+	}
+	return nil
+}
+
+// Purge removes categories deleted before deletedBefore, a category stays
+// while it has children that are not purged with it.
+func (r categoriesRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	purged := func(c entities.Category) bool {
+		return c.DeletedAt != nil && c.DeletedAt.Before(deletedBefore)
+	}
+	keep := make(map[uuid.UUID]bool)
+	for _, category := range r.db.categories {
+		if category.ParentCategory != nil && !purged(category) {
+			keep[category.ParentCategory.ID] = true
+		}
+	}
+
+	var count int64
+	for categoryID, category := range r.db.categories {
+		if purged(category) && !keep[categoryID] {
+			delete(r.db.categories, categoryID)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// copyCategory keeps only ids of related rows, like postgres repository
+// does, and does not share pointers with the caller.
+func copyCategory(c entities.Category) entities.Category {
+	if c.Store != nil {
+		c.Store = &entities.Store{ID: c.Store.ID}
+	}
+	if c.ParentCategory != nil {
+		c.ParentCategory = &entities.Category{ID: c.ParentCategory.ID}
+	}
+	if c.Article != nil {
+		article := *c.Article
+		c.Article = &article
+	}
+	if c.DeletedAt != nil {
+		deletedAt := *c.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	return c
+}
@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/storage/memory"
+	"github.com/rasulov-emirlan/accounter-backend/internal/storage/storagetest"
+)
+
+func TestContract(t *testing.T) {
+	repo := memory.NewRepositories()
+
+	storagetest.Run(t, storagetest.Repositories{
+		Owners:     repo.Owners(),
+		Stores:     repo.Stores(),
+		Categories: repo.Categories(),
+	})
+}
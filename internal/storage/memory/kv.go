@@ -0,0 +1,120 @@
+// Package memory holds in-memory storage implementations. Data does not
+// survive restarts and is not shared between instances.
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+)
+
+// sweepEvery is how many writes pass between removals of expired keys.
+const sweepEvery = 1000
+
+type kvEntry struct {
+	value     string
+	expiresAt time.Time // zero means never
+}
+
+func (e kvEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+type KeyValue struct {
+	mu     sync.Mutex
+	data   map[string]kvEntry
+	writes int
+}
+
+var _ auth.KeyValueRepository = (*KeyValue)(nil)
+
+func NewKeyValue() *KeyValue {
+	return &KeyValue{data: make(map[string]kvEntry)}
+}
+
+func (kv *KeyValue) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	now := time.Now()
+	e := kvEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	kv.data[key] = e
+
+	kv.writes++
+	if kv.writes >= sweepEvery {
+		kv.writes = 0
+		for k, e := range kv.data {
+			if e.expired(now) {
+				delete(kv.data, k)
+			}
+		}
+	}
+	return nil
+}
+
+func (kv *KeyValue) Get(ctx context.Context, key string) (string, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	e, ok := kv.data[key]
+	if !ok {
+		return "", auth.ErrKeyNotFound
+	}
+	if e.expired(time.Now()) {
+		delete(kv.data, key)
+		return "", auth.ErrKeyNotFound
+	}
+	return e.value, nil
+}
+
+func (kv *KeyValue) Swap(ctx context.Context, key, old, new string, ttl time.Duration) (bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	now := time.Now()
+	e, ok := kv.data[key]
+	if !ok || e.expired(now) || e.value != old {
+		return false, nil
+	}
+
+	e = kvEntry{value: new}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	kv.data[key] = e
+	return true, nil
+}
+
+func (kv *KeyValue) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	now := time.Now()
+	var n int64
+	if e, ok := kv.data[key]; ok && !e.expired(now) {
+		// not numeric values count as zero, like in postgres
+		n, _ = strconv.ParseInt(e.value, 10, 64)
+	}
+	n++
+
+	e := kvEntry{value: strconv.FormatInt(n, 10)}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	kv.data[key] = e
+	return n, nil
+}
+
+func (kv *KeyValue) Delete(ctx context.Context, key string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	delete(kv.data, key)
+	return nil
+}
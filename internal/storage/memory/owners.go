@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type ownersRepository struct {
+	db *database
+}
+
+var _ auth.OwnersRepository = ownersRepository{}
+
+func (r ownersRepository) Create(ctx context.Context, owner entities.Owner) (entities.Owner, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.usernameTaken(owner.Username, uuid.Nil) {
+		return entities.Owner{}, auth.ErrUsernameTaken
+	}
+
+	// id is generated by storage, same as in postgres
+	owner.ID = uuid.New()
+	owner.Sellers = nil
+	r.db.owners[owner.ID] = owner
+	return owner, nil
+}
+
+func (r ownersRepository) Read(ctx context.Context, id string) (entities.Owner, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	ownerID, err := uuid.Parse(id)
+	if err != nil {
+		return entities.Owner{}, auth.ErrIdNotFound
+	}
+	owner, ok := r.db.owners[ownerID]
+	if !ok {
+		return entities.Owner{}, auth.ErrIdNotFound
+	}
+	return owner, nil
+}
+
+func (r ownersRepository) ReadByUsername(ctx context.Context, username string) (entities.Owner, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, owner := range r.db.owners {
+		if owner.Username == username {
+			return owner, nil
+		}
+	}
+	return entities.Owner{}, auth.ErrUsernameNotFound
+}
+
+func (r ownersRepository) ReadAll(ctx context.Context) ([]entities.Owner, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	owners := make([]entities.Owner, 0, len(r.db.owners))
+	for _, owner := range r.db.owners {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].ID.String() < owners[j].ID.String()
+	})
+	return owners, nil
+}
+
+func (r ownersRepository) Update(ctx context.Context, owner entities.Owner) (entities.Owner, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	old, ok := r.db.owners[owner.ID]
+	if !ok {
+		return entities.Owner{}, auth.ErrIdNotFound
+	}
+	if r.usernameTaken(owner.Username, owner.ID) {
+		return entities.Owner{}, auth.ErrUsernameTaken
+	}
+
+	old.FullName = owner.FullName
+	old.Username = owner.Username
+	old.Password = owner.Password
+	old.PhoneNumber = owner.PhoneNumber
+	r.db.owners[owner.ID] = old
+	return owner, nil
+}
+
+// Delete removes owner together with stores and categories of the owner.
+func (r ownersRepository) Delete(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	ownerID, err := uuid.Parse(id)
+	if err != nil {
+		return auth.ErrIdNotFound
+	}
+	if _, ok := r.db.owners[ownerID]; !ok {
+		return auth.ErrIdNotFound
+	}
+
+	for storeID, store := range r.db.stores {
+		if store.Owner.ID != ownerID {
+			continue
+		}
+		for categoryID, category := range r.db.categories {
+			if category.Store.ID == storeID {
+				delete(r.db.categories, categoryID)
+			}
+		}
+		delete(r.db.stores, storeID)
+	}
+	delete(r.db.owners, ownerID)
+	return nil
+}
+
+// Anonymize clears personal data of owner, stores and categories stay.
+func (r ownersRepository) Anonymize(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	ownerID, err := uuid.Parse(id)
+	if err != nil {
+		return auth.ErrIdNotFound
+	}
+	owner, ok := r.db.owners[ownerID]
+	if !ok {
+		return auth.ErrIdNotFound
+	}
+
+	owner.Username = "deleted-" + id
+	owner.FullName = ""
+	owner.PhoneNumber = ""
+	owner.Password = ""
+	r.db.owners[ownerID] = owner
+	return nil
+}
+
+// usernameTaken reports whether username belongs to an owner other than
+// except. Callers must hold the lock.
+func (r ownersRepository) usernameTaken(username string, except uuid.UUID) bool {
+	for id, owner := range r.db.owners {
+		if id != except && owner.Username == username {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+// errForeignKey mirrors a foreign key violation of the database: a row
+// refers to a row that does not exist, or a row still referred to is deleted.
+var errForeignKey = errors.New("memory: foreign key violation")
+
+// database is shared by all repositories of one RepositoryCombiner, so
+// that references between rows are checked the same way postgres does.
+type database struct {
+	mu         sync.RWMutex
+	owners     map[uuid.UUID]entities.Owner
+	stores     map[uuid.UUID]entities.Store
+	categories map[uuid.UUID]entities.Category
+}
+
+// RepositoryCombiner is an in-memory counterpart of postgresql.RepositoryCombiner
+// for the repositories that have one. It is meant for tests and demos.
+type RepositoryCombiner struct {
+	ownersRepo     ownersRepository
+	storesRepo     storesRepository
+	categoriesRepo categoriesRepository
+}
+
+func NewRepositories() RepositoryCombiner {
+	db := &database{
+		owners:     make(map[uuid.UUID]entities.Owner),
+		stores:     make(map[uuid.UUID]entities.Store),
+		categories: make(map[uuid.UUID]entities.Category),
+	}
+
+	return RepositoryCombiner{
+		ownersRepo:     ownersRepository{db},
+		storesRepo:     storesRepository{db},
+		categoriesRepo: categoriesRepository{db},
+	}
+}
+
+func (r RepositoryCombiner) Owners() ownersRepository {
+	return r.ownersRepo
+}
+
+func (r RepositoryCombiner) Stores() storesRepository {
+	return r.storesRepo
+}
+
+func (r RepositoryCombiner) Categories() categoriesRepository {
+	return r.categoriesRepo
+}
+
+// paginate returns page of items like LIMIT and OFFSET would.
+func paginate[T any](items []T, pageNumber, pageSize uint64) []T {
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+	offset := (pageNumber - 1) * pageSize
+	if offset >= uint64(len(items)) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if pageSize < uint64(len(items)) {
+		items = items[:pageSize]
+	}
+	return items
+}
+
+// sortPage sorts items by key and then by id like postgres repositories
+// do and cuts a page out of them: by pageNumber, or after cursor. Backward
+// cursors give pageSize items right before the cursor. Zero pageSize means
+// no limit.
+func sortPage[T any](items []T, key func(T) *string, id func(T) uuid.UUID, sortOrder string, cursor *entities.Cursor, pageNumber, pageSize uint64) []T {
+	desc := sortOrder == "desc"
+	compare := func(item T, k *string, i uuid.UUID) int {
+		c := entities.CompareKeys(key(item), k)
+		if c == 0 {
+			c = strings.Compare(id(item).String(), i.String())
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return compare(items[i], key(items[j]), id(items[j])) < 0
+	})
+
+	if pageSize == 0 {
+		pageSize = uint64(len(items))
+	}
+	if cursor == nil {
+		return paginate(items, pageNumber, pageSize)
+	}
+
+	if cursor.Backward {
+		n := sort.Search(len(items), func(i int) bool { return compare(items[i], cursor.Key, cursor.ID) >= 0 })
+		items = items[:n]
+		if uint64(len(items)) > pageSize {
+			items = items[uint64(len(items))-pageSize:]
+		}
+		return items
+	}
+	n := sort.Search(len(items), func(i int) bool { return compare(items[i], cursor.Key, cursor.ID) > 0 })
+	items = items[n:]
+	if uint64(len(items)) > pageSize {
+		items = items[:pageSize]
+	}
+	return items
+}
+
+// timeNow is what postgres would store for time.Now(), it keeps
+// microseconds only.
+func timeNow() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
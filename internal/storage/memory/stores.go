@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type storesRepository struct {
+	db *database
+}
+
+var _ stores.StoresRepository = storesRepository{}
+
+func (r storesRepository) Create(ctx context.Context, store entities.Store) (entities.Store, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if store.Owner == nil {
+		return entities.Store{}, errForeignKey
+	}
+	if _, ok := r.db.owners[store.Owner.ID]; !ok {
+		return entities.Store{}, errForeignKey
+	}
+
+	store.ID = uuid.New()
+	store.CreatedAt = timeNow()
+	store.Owner = &entities.Owner{ID: store.Owner.ID}
+	store.Sellers = nil
+	store.Warehouses = nil
+	r.db.stores[store.ID] = store
+
+	store.Owner = &entities.Owner{ID: store.Owner.ID}
+	return store, nil
+}
+
+func (r storesRepository) ReadBy(ctx context.Context, filter stores.ReadByInput) ([]entities.Store, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	result := make([]entities.Store, 0)
+	for _, store := range r.db.stores {
+		if !storeMatches(store, filter) {
+			continue
+		}
+		owner := r.db.owners[store.Owner.ID]
+		store.Owner = &entities.Owner{ID: owner.ID, FullName: owner.FullName, Username: owner.Username, CreatedAt: owner.CreatedAt}
+		if store.DeletedAt != nil {
+			deletedAt := *store.DeletedAt
+			store.DeletedAt = &deletedAt
+		}
+		result = append(result, store)
+	}
+
+	sortBy, sortOrder := stores.SortByCreatedAt, stores.SortOrderDesc
+	if val, ok := filter.SortBy.Get(); ok {
+		if val == stores.SortByName {
+			sortBy = val
+		}
+		sortOrder, _ = filter.SortOrder.Get()
+	}
+	key := func(s entities.Store) *string { return stores.SortKey(s, sortBy) }
+	id := func(s entities.Store) uuid.UUID { return s.ID }
+
+	pageSize, ok := filter.PageSize.Get()
+	if !ok {
+		pageSize = 10
+	}
+	page, ok := filter.PageNumber.Get()
+	if !ok {
+		page = 1
+	}
+	var cursor *entities.Cursor
+	if c, ok := filter.Cursor.Get(); ok {
+		cursor = &c
+	}
+	result = sortPage(result, key, id, sortOrder, cursor, page, uint64(pageSize))
+
+	// a single store is requested, postgres shows its warehouses and sellers
+	// too, they are not kept in memory
+	if _, ok := filter.ID.Get(); ok && len(result) == 1 {
+		result[0].Warehouses = make([]entities.Warehouse, 0)
+		result[0].Sellers = make([]entities.Seller, 0)
+	}
+
+	return result, nil
+}
+
+func (r storesRepository) Count(ctx context.Context, filter stores.ReadByInput) (uint64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var count uint64
+	for _, store := range r.db.stores {
+		if storeMatches(store, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func storeMatches(store entities.Store, filter stores.ReadByInput) bool {
+	if deleted, _ := filter.Deleted.Get(); deleted != (store.DeletedAt != nil) {
+		return false
+	}
+	if id, ok := filter.ID.Get(); ok {
+		return store.ID.String() == strings.ToLower(id)
+	}
+	if ownerID, ok := filter.OwnerID.Get(); ok && store.Owner.ID.String() != strings.ToLower(ownerID) {
+		return false
+	}
+	if ids, ok := filter.IDs.Get(); ok {
+		found := false
+		for _, id := range ids {
+			if store.ID.String() == strings.ToLower(id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if text, ok := filter.Text.Get(); ok {
+		// roughly what plainto_tsquery does without stemming: every word
+		// has to be in name or description
+		words := strings.Fields(strings.ToLower(store.Name + " " + store.Description))
+		for _, w := range strings.Fields(strings.ToLower(text)) {
+			if !containsWord(words, w) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+func (r storesRepository) Update(ctx context.Context, id string, changeset stores.UpdateInput) (entities.Store, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	storeID, err := uuid.Parse(id)
+	if err != nil {
+		return entities.Store{}, stores.ErrNotFound
+	}
+	store, ok := r.db.stores[storeID]
+	if !ok || store.DeletedAt != nil {
+		return entities.Store{}, stores.ErrNotFound
+	}
+
+	if val, ok := changeset.Name.Get(); ok {
+		store.Name = val
+	}
+	if val, ok := changeset.Description.Get(); ok {
+		store.Description = val
+	}
+	r.db.stores[storeID] = store
+
+	store.Owner = &entities.Owner{ID: store.Owner.ID}
+	return store, nil
+}
+
+// Delete moves store to trash together with its live categories.
+func (r storesRepository) Delete(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	storeID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	store, ok := r.db.stores[storeID]
+	if !ok || store.DeletedAt != nil {
+		return nil
+	}
+
+	deletedAt := timeNow()
+	store.DeletedAt = &deletedAt
+	r.db.stores[storeID] = store
+	for categoryID, category := range r.db.categories {
+		if category.Store.ID == storeID && category.DeletedAt == nil {
+			category.DeletedAt = &deletedAt
+			r.db.categories[categoryID] = category
+		}
+	}
+	return nil
+}
+
+// Restore takes store out of trash with categories deleted together with it.
+func (r storesRepository) Restore(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	storeID, err := uuid.Parse(id)
+	if err != nil {
+		return stores.ErrNotFound
+	}
+	store, ok := r.db.stores[storeID]
+	if !ok || store.DeletedAt == nil {
+		return stores.ErrNotFound
+	}
+
+	deletedAt := *store.DeletedAt
+	store.DeletedAt = nil
+	r.db.stores[storeID] = store
+	for categoryID, category := range r.db.categories {
+		if category.Store.ID == storeID && category.DeletedAt != nil && category.DeletedAt.Equal(deletedAt) {
+			category.DeletedAt = nil
+			r.db.categories[categoryID] = category
+		}
+	}
+	return nil
+}
+
+// Purge removes stores deleted before deletedBefore that have no categories.
+func (r storesRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	hasCategories := make(map[uuid.UUID]bool)
+	for _, category := range r.db.categories {
+		hasCategories[category.Store.ID] = true
+	}
+
+	var count int64
+	for storeID, store := range r.db.stores {
+		if store.DeletedAt != nil && store.DeletedAt.Before(deletedBefore) && !hasCategories[storeID] {
+			delete(r.db.stores, storeID)
+			count++
+		}
+	}
+	return count, nil
+}
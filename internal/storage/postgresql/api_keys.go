@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type apiKeysRepository struct {
+	conn *pgxpool.Pool
+}
+
+var _ auth.APIKeysRepository = (*apiKeysRepository)(nil)
+
+const apiKeyColumns = "id, owner_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, created_at"
+
+func (r apiKeysRepository) Create(ctx context.Context, key entities.APIKey) error {
+	defer telemetry.NewSpan(ctx, PackageName+"apiKeysRepository.Create").End()
+
+	if key.Owner == nil {
+		return errors.New("owner is required")
+	}
+
+	const sql = `INSERT INTO api_keys (id, owner_id, name, prefix, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db(ctx, r.conn).Exec(ctx, sql, key.ID, key.Owner.ID, key.Name, key.Prefix, key.Hash, key.Scopes, key.CreatedAt)
+	return err
+}
+
+func (r apiKeysRepository) ReadByOwner(ctx context.Context, ownerID string) ([]entities.APIKey, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"apiKeysRepository.ReadByOwner").End()
+
+	rows, err := db(ctx, r.conn).Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE owner_id = $1 ORDER BY created_at DESC", ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]entities.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r apiKeysRepository) ReadByHash(ctx context.Context, hash string) (entities.APIKey, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"apiKeysRepository.ReadByHash").End()
+
+	key, err := scanAPIKey(db(ctx, r.conn).QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.APIKey{}, auth.ErrInvalidAPIKey
+		}
+		return entities.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r apiKeysRepository) Revoke(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"apiKeysRepository.Revoke").End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return auth.ErrAPIKeyNotFound
+	}
+
+	const sql = `UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND owner_id = $2 AND revoked_at IS NULL`
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, id, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r apiKeysRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	defer telemetry.NewSpan(ctx, PackageName+"apiKeysRepository.Touch").End()
+
+	_, err := db(ctx, r.conn).Exec(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, at)
+	return err
+}
+
+func scanAPIKey(row pgx.Row) (entities.APIKey, error) {
+	var (
+		key   entities.APIKey
+		owner entities.Owner
+	)
+	err := row.Scan(&key.ID, &owner.ID, &key.Name, &key.Prefix, &key.Hash, &key.Scopes, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return entities.APIKey{}, err
+	}
+	key.Owner = &owner
+	return key, nil
+}
@@ -0,0 +1,112 @@
+package postgresql
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type auditRepository struct {
+	conn *pgxpool.Pool
+}
+
+var _ audit.AuditRepository = (*auditRepository)(nil)
+
+func (r auditRepository) Append(ctx context.Context, entry entities.AuditEntry) error {
+	defer telemetry.NewSpan(ctx, PackageName+"auditRepository.Append").End()
+
+	const sql = `INSERT INTO audit_log (id, owner_id, actor_id, actor_role, api_key_id, action, entity_type, entity_id, changes, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := db(ctx, r.conn).Exec(ctx, sql,
+		entry.ID, entry.OwnerID, entry.ActorID, entry.ActorRole, entry.APIKeyID, entry.Action,
+		entry.EntityType, entry.EntityID, string(entry.Changes), entry.RequestID, entry.CreatedAt,
+	)
+	return err
+}
+
+func whereAudit(query sq.SelectBuilder, filter audit.ReadByInput) sq.SelectBuilder {
+	query = query.Where(sq.Eq{"owner_id": filter.OwnerID})
+
+	if val, ok := filter.ActorID.Get(); ok {
+		query = query.Where(sq.Eq{"actor_id": val})
+	}
+	if val, ok := filter.Action.Get(); ok {
+		query = query.Where(sq.Eq{"action": val})
+	}
+	if val, ok := filter.EntityType.Get(); ok {
+		query = query.Where(sq.Eq{"entity_type": val})
+	}
+	if val, ok := filter.EntityID.Get(); ok {
+		query = query.Where(sq.Eq{"entity_id": val})
+	}
+	// created_at holds utc time without zone
+	if val, ok := filter.From.Get(); ok {
+		query = query.Where(sq.GtOrEq{"created_at": val.UTC()})
+	}
+	if val, ok := filter.To.Get(); ok {
+		query = query.Where(sq.Lt{"created_at": val.UTC()})
+	}
+	return query
+}
+
+func (r auditRepository) ReadBy(ctx context.Context, filter audit.ReadByInput) ([]entities.AuditEntry, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"auditRepository.ReadBy").End()
+
+	query := whereAudit(sq.Select("id", "owner_id", "actor_id", "actor_role", "api_key_id", "action", "entity_type", "entity_id", "changes", "request_id", "created_at").
+		From("audit_log").
+		OrderBy("created_at DESC", "id").
+		PlaceholderFormat(sq.Dollar), filter)
+
+	pageSize, ok := filter.PageSize.Get()
+	if !ok {
+		pageSize = 50
+	}
+	page, ok := filter.PageNumber.Get()
+	if !ok {
+		page = 1
+	}
+	query = query.Limit(uint64(pageSize)).Offset((page - 1) * uint64(pageSize))
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]entities.AuditEntry, 0)
+	for rows.Next() {
+		var (
+			entry   entities.AuditEntry
+			changes []byte
+		)
+		err := rows.Scan(&entry.ID, &entry.OwnerID, &entry.ActorID, &entry.ActorRole, &entry.APIKeyID, &entry.Action,
+			&entry.EntityType, &entry.EntityID, &changes, &entry.RequestID, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (r auditRepository) Count(ctx context.Context, filter audit.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"auditRepository.Count").End()
+
+	sql, args, err := whereAudit(sq.Select("COUNT(*)").From("audit_log").PlaceholderFormat(sq.Dollar), filter).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
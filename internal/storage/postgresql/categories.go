@@ -2,9 +2,12 @@ package postgresql
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
 	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
@@ -31,52 +34,80 @@ func (c categoriesRepository) Create(ctx context.Context, input entities.Categor
 		parentCategoryID = &tmp
 	}
 	const sql = "INSERT INTO categories (store_id, parent_category_id, name, article, icon_url)" +
-		"VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		"VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at"
 
-	res := c.conn.QueryRow(ctx, sql,
+	res := db(ctx, c.conn).QueryRow(ctx, sql,
 		storeID, parentCategoryID, input.Name, input.Article, input.IconURL,
 	)
-	return input, res.Scan(&input.ID)
+	return input, res.Scan(&input.ID, &input.CreatedAt)
 }
 
-func (c categoriesRepository) ReadBy(ctx context.Context, filters categories.ReadByInput) ([]entities.Category, error) {
-	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.ReadBy").End()
+const categoryColumns = "id, store_id, parent_category_id, name, article, icon_url, created_at, deleted_at"
 
-	query := sq.Select("id", "store_id", "parent_category_id", "name", "article", "icon_url", "created_at").
-		From("categories").
-		PlaceholderFormat(sq.Dollar)
+var categorySortingFields = map[string]sortField{
+	categories.SortByCreatedAt: {[]string{"created_at"}, timeKey},
+	categories.SortByArticle:   {[]string{"article IS NULL", "COALESCE(article, '')"}, nullableTextKey},
+	categories.SortByName:      {[]string{"name"}, textKey},
+}
+
+// whereCategories filters query by everything in filters but pagination.
+func whereCategories(query sq.SelectBuilder, filters categories.ReadByInput) sq.SelectBuilder {
+	if deleted, _ := filters.Deleted.Get(); deleted {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
 
 	id, ok := filters.ID.Get()
 	if ok {
-		query = query.Where(sq.Eq{"id": id})
-	} else {
-		text, ok := filters.Text.Get()
-		if ok {
-			query = query.Where(sq.Like{"name": text})
+		return query.Where(sq.Eq{"id": id})
+	}
+
+	text, ok := filters.Text.Get()
+	if ok {
+		// served by trigram index on name
+		query = query.Where(sq.ILike{"name": "%" + text + "%"})
+	}
+	storeID, ok := filters.StoreID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"store_id": storeID})
+	}
+	parentCategoryID, ok := filters.ParentCategoryID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"parent_category_id": parentCategoryID})
+	}
+	return query
+}
+
+func (c categoriesRepository) ReadBy(ctx context.Context, filters categories.ReadByInput) ([]entities.Category, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.ReadBy").End()
+
+	query := whereCategories(sq.Select(categoryColumns).From("categories").PlaceholderFormat(sq.Dollar), filters)
+
+	var cursor *entities.Cursor
+	if _, ok := filters.ID.Get(); !ok {
+		field, sortOrder := categorySortingFields[categories.SortByCreatedAt], "desc"
+		if sortBy, ok := filters.SortBy.Get(); ok {
+			if f, ok := categorySortingFields[sortBy]; ok {
+				field = f
+			}
+			sortOrder, _ = filters.SortOrder.Get()
 		}
-		storeID, ok := filters.StoreID.Get()
-		if ok {
-			query = query.Where(sq.Eq{"store_id": storeID})
+
+		if c, ok := filters.Cursor.Get(); ok {
+			cursor = &c
 		}
-		parentCategoryID, ok := filters.ParentCategoryID.Get()
-		if ok {
-			query = query.Where(sq.Eq{"parent_category_id": parentCategoryID})
+		var err error
+		query, err = keyset(query, field, "id", sortOrder, cursor)
+		if err != nil {
+			return nil, err
 		}
 
-		pageNumber, ok := filters.PageNumber.Get()
-		if ok {
-			query = query.Offset(pageNumber)
-		}
 		pageSize, ok := filters.PageSize.Get()
 		if ok {
 			query = query.Limit(uint64(pageSize))
-		}
-
-		sortBy, ok := filters.SortBy.Get()
-		if ok {
-			sortOrder, ok := filters.SortOrder.Get()
-			if ok {
-				query = query.OrderBy(sortBy + " " + sortOrder)
+			if pageNumber, ok := filters.PageNumber.Get(); ok && pageNumber > 1 && cursor == nil {
+				query = query.Offset((pageNumber - 1) * uint64(pageSize))
 			}
 		}
 	}
@@ -86,67 +117,94 @@ func (c categoriesRepository) ReadBy(ctx context.Context, filters categories.Rea
 		return nil, err
 	}
 
-	rows, err := c.conn.Query(ctx, sql, args...)
+	rows, err := db(ctx, c.conn).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var result []entities.Category
+	result := make([]entities.Category, 0)
 	for rows.Next() {
-		var (
-			category         entities.Category
-			storeID          *string
-			parentCategoryID *string
-		)
-		err := rows.Scan(
-			&category.ID,
-			&storeID,
-			&parentCategoryID,
-			&category.Name,
-			&category.Article,
-			&category.IconURL,
-			&category.CreatedAt,
-		)
+		category, err := scanCategory(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		// TODO: read more than just ids
-		if storeID != nil {
-			category.Store = &entities.Store{ID: uuid.MustParse(*storeID)}
-		}
-		if parentCategoryID != nil {
-			category.ParentCategory = &entities.Category{ID: uuid.MustParse(*parentCategoryID)}
-		}
-
 		result = append(result, category)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if cursor != nil && cursor.Backward {
+		reverse(result)
+	}
 	return result, nil
 }
 
+func (c categoriesRepository) Count(ctx context.Context, filters categories.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.Count").End()
+
+	sql, args, err := whereCategories(sq.Select("COUNT(*)").From("categories").PlaceholderFormat(sq.Dollar), filters).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, c.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func scanCategory(row pgx.Row) (entities.Category, error) {
+	var (
+		category         entities.Category
+		storeID          *string
+		parentCategoryID *string
+		iconURL          *string
+	)
+	err := row.Scan(
+		&category.ID,
+		&storeID,
+		&parentCategoryID,
+		&category.Name,
+		&category.Article,
+		&iconURL,
+		&category.CreatedAt,
+		&category.DeletedAt,
+	)
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	// TODO: read more than just ids
+	if storeID != nil {
+		category.Store = &entities.Store{ID: uuid.MustParse(*storeID)}
+	}
+	if parentCategoryID != nil {
+		category.ParentCategory = &entities.Category{ID: uuid.MustParse(*parentCategoryID)}
+	}
+	if iconURL != nil {
+		category.IconURL = *iconURL
+	}
+	return category, nil
+}
+
 func (c categoriesRepository) Update(ctx context.Context, changeset categories.UpdateInput) (entities.Category, error) {
 	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.Update").End()
 
 	query := sq.Update("categories").
-		Where(sq.Eq{"id": changeset.ID}).
+		Where(sq.Eq{"id": changeset.ID, "deleted_at": nil}).
+		Suffix("RETURNING " + categoryColumns).
 		PlaceholderFormat(sq.Dollar)
-	var category entities.Category
 
 	name, ok := changeset.Name.Get()
 	if ok {
 		query = query.Set("name", name)
-		category.Name = name
 	}
 	article, ok := changeset.Article.Get()
 	if ok {
 		query = query.Set("article", article)
-		category.Article = article
 	}
 	parent, ok := changeset.ParentCategoryID.Get()
 	if ok {
 		query = query.Set("parent_category_id", parent)
-		category.ParentCategory = &entities.Category{ID: uuid.MustParse(*parent)}
 	}
 
 	sql, args, err := query.ToSql()
@@ -154,14 +212,86 @@ func (c categoriesRepository) Update(ctx context.Context, changeset categories.U
 		return entities.Category{}, err
 	}
 
-	res := c.conn.QueryRow(ctx, sql, args...)
-	return category, res.Scan(&category.ID)
+	category, err := scanCategory(db(ctx, c.conn).QueryRow(ctx, sql, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entities.Category{}, categories.ErrNotFound
+	}
+	return category, err
 }
 
+// categorySubtree selects category $1 with all its descendants.
+const categorySubtree = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = $1
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_category_id = s.id
+	)`
+
+// Delete moves category to trash together with its live descendants.
+// They share deleted_at, so that Restore brings back exactly them.
 func (c categoriesRepository) Delete(ctx context.Context, id string) error {
 	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.Delete").End()
 
-	const sql = "DELETE FROM categories WHERE id = $1"
-	_, err := c.conn.Exec(ctx, sql, id)
+	const sql = categorySubtree + `
+		UPDATE categories SET deleted_at = $2
+		WHERE id IN (SELECT id FROM subtree) AND deleted_at IS NULL`
+
+	_, err := db(ctx, c.conn).Exec(ctx, sql, id, time.Now().UTC().Truncate(time.Microsecond))
 	return err
 }
+
+// Restore takes category out of trash with descendants deleted together
+// with it. Category can not be restored into a store or parent that is
+// still in trash.
+func (c categoriesRepository) Restore(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.Restore").End()
+
+	const check = `SELECT c.deleted_at, s.deleted_at IS NOT NULL OR COALESCE(p.deleted_at IS NOT NULL, false)
+		FROM categories c
+		JOIN stores s ON s.id = c.store_id
+		LEFT JOIN categories p ON p.id = c.parent_category_id
+		WHERE c.id = $1
+		FOR UPDATE OF c`
+	const restore = categorySubtree + `
+		UPDATE categories SET deleted_at = NULL
+		WHERE id IN (SELECT id FROM subtree) AND deleted_at = $2`
+
+	return pgx.BeginFunc(ctx, db(ctx, c.conn), func(tx pgx.Tx) error {
+		var (
+			deletedAt     *time.Time
+			parentDeleted bool
+		)
+		if err := tx.QueryRow(ctx, check, id).Scan(&deletedAt, &parentDeleted); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return categories.ErrNotFound
+			}
+			return err
+		}
+		if deletedAt == nil {
+			return categories.ErrNotFound
+		}
+		if parentDeleted {
+			return categories.ErrParentDeleted
+		}
+
+		_, err := tx.Exec(ctx, restore, id, *deletedAt)
+		return err
+	})
+}
+
+// Purge removes categories deleted before deletedBefore. Category stays
+// while it has children that are not purged with it.
+func (c categoriesRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"categoriesRepository.Purge").End()
+
+	const sql = `DELETE FROM categories c WHERE c.deleted_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM categories ch WHERE ch.parent_category_id = c.id
+			AND (ch.deleted_at IS NULL OR ch.deleted_at >= $1)
+		)`
+
+	tag, err := db(ctx, c.conn).Exec(ctx, sql, deletedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
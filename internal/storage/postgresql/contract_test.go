@@ -0,0 +1,42 @@
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rasulov-emirlan/accounter-backend/config"
+	"github.com/rasulov-emirlan/accounter-backend/internal/storage/postgresql"
+	"github.com/rasulov-emirlan/accounter-backend/internal/storage/storagetest"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+)
+
+// TestContract needs a database, set TEST_DATABASE_URL to run it.
+// Migrations are applied before the run.
+func TestContract(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	log, err := logging.NewLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{DatabaseURL: url}
+	cfg.Flags.WithMigrations = true
+
+	ctx := context.Background()
+	repo, err := postgresql.NewRepositories(ctx, cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close(ctx) })
+
+	storagetest.Run(t, storagetest.Repositories{
+		Owners:     repo.Owners(),
+		Stores:     repo.Stores(),
+		Categories: repo.Categories(),
+	})
+}
@@ -0,0 +1,320 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/items"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type itemsRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r itemsRepository) Create(ctx context.Context, item entities.Item) (entities.Item, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"itemsRepository.Create").End()
+
+	if item.Store == nil {
+		return entities.Item{}, errors.New("store is required")
+	}
+	var categoryID *uuid.UUID
+	if item.Category != nil {
+		categoryID = &item.Category.ID
+		if err := r.checkCategory(ctx, categoryID.String(), sq.Expr("?", item.Store.ID)); err != nil {
+			return entities.Item{}, err
+		}
+	}
+
+	sql, args, err := sq.Insert("items").
+		Columns("id", "store_id", "category_id", "name", "article", "description", "icon_url", "color", "price", "created_at", "tsv").
+		Values(
+			item.ID, item.Store.ID, categoryID, item.Name, item.Article, item.Description, item.IconURL, item.Color, item.Price, item.CreatedAt,
+			sq.Expr(
+				`setweight(to_tsvector(?), 'A') || setweight(to_tsvector(?), 'A') || setweight(to_tsvector(?), 'B')`,
+				item.Name, item.Article, item.Description,
+			)).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return entities.Item{}, err
+	}
+
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
+	if err := row.Scan(&item.ID); err != nil {
+		return entities.Item{}, err
+	}
+
+	return item, nil
+}
+
+var itemSortingFields = map[string]string{
+	items.SortByCreatedAt: "created_at",
+	items.SortByName:      "name",
+	items.SortByPrice:     "price",
+}
+
+func whereItems(query sq.SelectBuilder, filters items.ReadByInput) sq.SelectBuilder {
+	id, ok := filters.ID.Get()
+	if ok {
+		return query.Where(sq.Eq{"id": id})
+	}
+
+	storeID, ok := filters.StoreID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"store_id": storeID})
+	}
+	categoryID, ok := filters.CategoryID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"category_id": categoryID})
+	}
+	color, ok := filters.Color.Get()
+	if ok {
+		query = query.Where(sq.Eq{"color": color})
+	}
+	priceFrom, ok := filters.PriceFrom.Get()
+	if ok {
+		query = query.Where(sq.GtOrEq{"price": priceFrom})
+	}
+	priceTo, ok := filters.PriceTo.Get()
+	if ok {
+		query = query.Where(sq.LtOrEq{"price": priceTo})
+	}
+	text, ok := filters.Text.Get()
+	if ok {
+		// full text search on 'tsv' column
+		query = query.Where(sq.Expr("tsv @@ plainto_tsquery(?)", text))
+	}
+	return query
+}
+
+func (r itemsRepository) ReadBy(ctx context.Context, filters items.ReadByInput) ([]entities.Item, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"itemsRepository.ReadBy").End()
+
+	query := whereItems(sq.Select("id", "store_id", "category_id", "name", "article", "description", "icon_url", "color", "price", "created_at").
+		From("items").
+		PlaceholderFormat(sq.Dollar), filters)
+
+	if _, ok := filters.ID.Get(); !ok {
+		sortBy, ok := filters.SortBy.Get()
+		if ok {
+			sortBy, ok := itemSortingFields[sortBy]
+			if !ok {
+				sortBy = "created_at"
+			}
+			sortOrder, ok := filters.SortOrder.Get()
+			if !ok {
+				sortOrder = items.SortOrderDesc
+			}
+			query = query.OrderBy(sortBy + " " + sortOrder)
+		} else {
+			query = query.OrderBy("created_at desc")
+		}
+
+		pageSize, ok := filters.PageSize.Get()
+		if !ok {
+			pageSize = 10
+		}
+		page, ok := filters.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.Limit(uint64(pageSize)).Offset((page - 1) * uint64(pageSize))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Item, 0)
+	for rows.Next() {
+		var (
+			item       entities.Item
+			storeID    uuid.UUID
+			categoryID *uuid.UUID
+		)
+		err := rows.Scan(
+			&item.ID,
+			&storeID,
+			&categoryID,
+			&item.Name,
+			&item.Article,
+			&item.Description,
+			&item.IconURL,
+			&item.Color,
+			&item.Price,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Store = &entities.Store{ID: storeID}
+		if categoryID != nil {
+			item.Category = &entities.Category{ID: *categoryID}
+		}
+
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
+func (r itemsRepository) Count(ctx context.Context, filters items.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"itemsRepository.Count").End()
+
+	sql, args, err := whereItems(sq.Select("COUNT(*)").From("items").PlaceholderFormat(sq.Dollar), filters).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r itemsRepository) Update(ctx context.Context, id string, changeset items.UpdateInput) (entities.Item, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"itemsRepository.Update").End()
+
+	query := sq.Update("items").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	// tsv has to be built from the new values, because in UPDATE
+	// column references on the right side point to the old row
+	var (
+		tsvChanged  bool
+		name        = sq.Expr("name")
+		article     = sq.Expr("article")
+		description = sq.Expr("description")
+	)
+
+	categoryID, ok := changeset.CategoryID.Get()
+	if ok {
+		if categoryID != nil {
+			storeID := sq.Expr("(SELECT store_id FROM items WHERE id = ?)", id)
+			if err := r.checkCategory(ctx, *categoryID, storeID); err != nil {
+				return entities.Item{}, err
+			}
+		}
+		query = query.Set("category_id", categoryID)
+	}
+	if val, ok := changeset.Name.Get(); ok {
+		query = query.Set("name", val)
+		name, tsvChanged = sq.Expr("?", val), true
+	}
+	if val, ok := changeset.Article.Get(); ok {
+		query = query.Set("article", val)
+		article, tsvChanged = sq.Expr("?", val), true
+	}
+	if val, ok := changeset.Description.Get(); ok {
+		query = query.Set("description", val)
+		description, tsvChanged = sq.Expr("?", val), true
+	}
+	if val, ok := changeset.IconURL.Get(); ok {
+		query = query.Set("icon_url", val)
+	}
+	if val, ok := changeset.Color.Get(); ok {
+		query = query.Set("color", val)
+	}
+	if val, ok := changeset.Price.Get(); ok {
+		query = query.Set("price", val)
+	}
+
+	if tsvChanged {
+		query = query.Set("tsv", sq.Expr(
+			`setweight(to_tsvector(?), 'A') || setweight(to_tsvector(?), 'A') || setweight(to_tsvector(?), 'B')`,
+			name, article, description,
+		))
+	}
+
+	sql, args, err := query.
+		Suffix(`RETURNING "id", "store_id", "category_id", "name", "article", "description", "icon_url", "color", "price", "created_at"`).
+		ToSql()
+	if err != nil {
+		return entities.Item{}, err
+	}
+
+	var (
+		item          entities.Item
+		storeID       uuid.UUID
+		newCategoryID *uuid.UUID
+	)
+	err = db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(
+		&item.ID,
+		&storeID,
+		&newCategoryID,
+		&item.Name,
+		&item.Article,
+		&item.Description,
+		&item.IconURL,
+		&item.Color,
+		&item.Price,
+		&item.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Item{}, items.ErrNotFound
+		}
+		return entities.Item{}, err
+	}
+
+	item.Store = &entities.Store{ID: storeID}
+	if newCategoryID != nil {
+		item.Category = &entities.Category{ID: *newCategoryID}
+	}
+
+	return item, nil
+}
+
+// checkCategory returns ErrCategoryNotInStore unless category belongs
+// to the store, categories of other tenants are not visible, so they
+// never do.
+func (r itemsRepository) checkCategory(ctx context.Context, categoryID string, storeID sq.Sqlizer) error {
+	sql, args, err := sq.Select("1").From("categories").
+		Where(sq.Eq{"id": categoryID}).
+		Where(sq.Expr("store_id = ?", storeID)).
+		Prefix("SELECT EXISTS (").Suffix(")").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	if err := db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return items.ErrCategoryNotInStore
+	}
+	return nil
+}
+
+func (r itemsRepository) Delete(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"itemsRepository.Delete").End()
+
+	sql, args, err := sq.Delete("items").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	// sizes are deleted with the item, but movements and receipt
+	// lines keep them
+	_, err = db(ctx, r.conn).Exec(ctx, sql, args...)
+	if isPgError(err, pgForeignKeyViolation) {
+		return items.ErrHasMovements
+	}
+	return err
+}
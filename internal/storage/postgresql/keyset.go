@@ -0,0 +1,83 @@
+package postgresql
+
+import (
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+// sortField is what a list is ordered by. Rows compare by columns and
+// then by id, values turns a cursor key into values of columns.
+type sortField struct {
+	columns []string
+	values  func(key *string) ([]any, error)
+}
+
+func textKey(key *string) ([]any, error) {
+	if key == nil {
+		return []any{""}, nil
+	}
+	return []any{*key}, nil
+}
+
+func timeKey(key *string) ([]any, error) {
+	if key == nil {
+		return []any{time.Time{}}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, *key)
+	if err != nil {
+		return nil, err
+	}
+	return []any{t}, nil
+}
+
+// nullableTextKey goes with columns "x IS NULL" and x with NULL replaced
+// by an empty string, so that NULLs are ordered like postgres does and
+// still compare as a row.
+func nullableTextKey(key *string) ([]any, error) {
+	if key == nil {
+		return []any{true, ""}, nil
+	}
+	return []any{false, *key}, nil
+}
+
+// keyset orders query by field and idColumn in sortOrder. With cursor it
+// keeps only rows after the cursor, backward cursors read rows before it
+// in reverse order, so they have to be reversed after scanning.
+func keyset(query sq.SelectBuilder, field sortField, idColumn, sortOrder string, cursor *entities.Cursor) (sq.SelectBuilder, error) {
+	desc := sortOrder == "desc"
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+
+	direction, op := " ASC", ">"
+	if desc {
+		direction, op = " DESC", "<"
+	}
+
+	columns := append(append([]string{}, field.columns...), idColumn)
+	orderBy := make([]string, len(columns))
+	for i, column := range columns {
+		orderBy[i] = column + direction
+	}
+	query = query.OrderBy(orderBy...)
+
+	if cursor == nil {
+		return query, nil
+	}
+	values, err := field.values(cursor.Key)
+	if err != nil {
+		return query, err
+	}
+	values = append(values, cursor.ID)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	return query.Where("("+strings.Join(columns, ", ")+") "+op+" ("+placeholders+")", values...), nil
+}
+
+func reverse[T any](items []T) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
@@ -0,0 +1,110 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type kvRepository struct {
+	conn *pgxpool.Pool
+}
+
+var _ auth.KeyValueRepository = (*kvRepository)(nil)
+
+func (r kvRepository) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	defer telemetry.NewSpan(ctx, PackageName+"kvRepository.Set").End()
+
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		// there is no background job for kv, so writers clean up after themselves
+		if _, err := tx.Exec(ctx, "DELETE FROM kv WHERE expires_at < NOW()"); err != nil {
+			return err
+		}
+
+		const sql = `INSERT INTO kv (key, value, expires_at) VALUES ($1, $2, $3)
+			ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at`
+		_, err := tx.Exec(ctx, sql, key, value, expiresAt)
+		return err
+	})
+}
+
+func (r kvRepository) Get(ctx context.Context, key string) (string, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"kvRepository.Get").End()
+
+	const sql = `SELECT value FROM kv
+		WHERE key = $1 AND (expires_at IS NULL OR expires_at > NOW())`
+
+	var value string
+	if err := db(ctx, r.conn).QueryRow(ctx, sql, key).Scan(&value); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", auth.ErrKeyNotFound
+		}
+		return "", err
+	}
+	return value, nil
+}
+
+func (r kvRepository) Swap(ctx context.Context, key, old, new string, ttl time.Duration) (bool, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"kvRepository.Swap").End()
+
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+
+	// the row is locked by UPDATE, a concurrent swap waits for it and
+	// then sees the new value, so it changes nothing
+	const sql = `UPDATE kv SET value = $3, expires_at = $4
+		WHERE key = $1 AND value = $2 AND (expires_at IS NULL OR expires_at > NOW())`
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, key, old, new, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r kvRepository) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"kvRepository.Increment").End()
+
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+
+	// the addition happens in the upsert, so concurrent increments are
+	// serialized by the row lock instead of overwriting each other
+	const sql = `INSERT INTO kv (key, value, expires_at) VALUES ($1, '1', $2)
+		ON CONFLICT (key) DO UPDATE SET
+			value = CASE
+				WHEN kv.expires_at <= NOW() OR kv.value !~ '^[0-9]{1,18}$' THEN '1'
+				ELSE (kv.value::bigint + 1)::text
+			END,
+			expires_at = EXCLUDED.expires_at
+		RETURNING value::bigint`
+
+	var n int64
+	if err := db(ctx, r.conn).QueryRow(ctx, sql, key, expiresAt).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r kvRepository) Delete(ctx context.Context, key string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"kvRepository.Delete").End()
+
+	_, err := db(ctx, r.conn).Exec(ctx, "DELETE FROM kv WHERE key = $1", key)
+	return err
+}
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS items (
+  id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  store_id    uuid NOT NULL,
+  category_id uuid,
+  name        VARCHAR(255) NOT NULL,
+  article     VARCHAR(100) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  icon_url    TEXT NOT NULL DEFAULT '',
+  color       VARCHAR(6) NOT NULL,
+  price       NUMERIC(12, 2) NOT NULL,
+  tsv         TSVECTOR,
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_items_store_id FOREIGN KEY (store_id)
+    REFERENCES stores(id),
+  CONSTRAINT fk_items_category_id FOREIGN KEY (category_id)
+    REFERENCES categories(id) ON DELETE SET NULL,
+  CONSTRAINT check_items_price CHECK (price > 0)
+);
+
+CREATE INDEX IF NOT EXISTS ix_items_store_id ON items(store_id);
+CREATE INDEX IF NOT EXISTS ix_items_category_id ON items(category_id);
+CREATE INDEX IF NOT EXISTS ix_items_tsv ON items USING GIN(tsv);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_items_tsv;
+DROP INDEX IF EXISTS ix_items_category_id;
+DROP INDEX IF EXISTS ix_items_store_id;
+DROP TABLE IF EXISTS items;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sizes (
+  id           BIGSERIAL PRIMARY KEY,
+  item_id      uuid NOT NULL,
+  -- there is no warehouses table yet, foreign key
+  -- will be added together with it
+  warehouse_id uuid NOT NULL,
+  size_number  VARCHAR(50),
+  size_symbol  VARCHAR(10),
+  quantity     BIGINT NOT NULL DEFAULT 0,
+  cost         NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_sizes_item_id FOREIGN KEY (item_id)
+    REFERENCES items(id) ON DELETE CASCADE,
+  CONSTRAINT check_sizes_exclusive CHECK (
+    size_number IS NULL OR
+    size_symbol IS NULL
+  ),
+  CONSTRAINT check_sizes_quantity CHECK (quantity >= 0),
+  CONSTRAINT check_sizes_cost CHECK (cost >= 0)
+);
+
+-- one row per item, warehouse and size. NULLs are coalesced,
+-- otherwise postgres would treat every NULL as a distinct value
+CREATE UNIQUE INDEX IF NOT EXISTS ux_sizes_item_warehouse_size ON sizes (
+  item_id,
+  warehouse_id,
+  COALESCE(size_number, ''),
+  COALESCE(size_symbol, '')
+);
+
+CREATE INDEX IF NOT EXISTS ix_sizes_warehouse_id ON sizes(warehouse_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_sizes_warehouse_id;
+DROP INDEX IF EXISTS ux_sizes_item_warehouse_size;
+DROP TABLE IF EXISTS sizes;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS warehouses (
+  id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner_id    uuid NOT NULL,
+  name        TEXT NOT NULL,
+  description TEXT NOT NULL,
+  tsv         TSVECTOR,
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT  fk_warehouses_owner_id FOREIGN KEY (owner_id)
+    REFERENCES owners(id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_warehouses_tsv ON warehouses USING GIN(tsv);
+
+-- which warehouses supply which stores
+CREATE TABLE IF NOT EXISTS store_warehouses (
+  store_id     uuid NOT NULL,
+  warehouse_id uuid NOT NULL,
+  created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (store_id, warehouse_id),
+  CONSTRAINT fk_store_warehouses_store_id FOREIGN KEY (store_id)
+    REFERENCES stores(id) ON DELETE CASCADE,
+  CONSTRAINT fk_store_warehouses_warehouse_id FOREIGN KEY (warehouse_id)
+    REFERENCES warehouses(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ix_store_warehouses_warehouse_id ON store_warehouses(warehouse_id);
+
+ALTER TABLE sizes ADD CONSTRAINT fk_sizes_warehouse_id FOREIGN KEY (warehouse_id)
+  REFERENCES warehouses(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sizes DROP CONSTRAINT IF EXISTS fk_sizes_warehouse_id;
+DROP INDEX IF EXISTS ix_store_warehouses_warehouse_id;
+DROP TABLE IF EXISTS store_warehouses;
+DROP INDEX IF EXISTS ix_warehouses_tsv;
+DROP TABLE IF EXISTS warehouses;
+-- +goose StatementEnd
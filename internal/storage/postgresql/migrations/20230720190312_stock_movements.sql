@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stock_movements (
+  id           BIGSERIAL PRIMARY KEY,
+  size_id      BIGINT NOT NULL,
+  type         VARCHAR(20) NOT NULL,
+  quantity     BIGINT NOT NULL,
+  reference_id uuid,
+  comment      TEXT NOT NULL DEFAULT '',
+  created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_stock_movements_size_id FOREIGN KEY (size_id)
+    REFERENCES sizes(id),
+  CONSTRAINT check_stock_movements_type CHECK (type IN (
+    'receipt', 'sale', 'return', 'writeOff', 'transferOut', 'transferIn', 'correction'
+  )),
+  CONSTRAINT check_stock_movements_quantity CHECK (quantity <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS ix_stock_movements_size_id ON stock_movements(size_id, id);
+CREATE INDEX IF NOT EXISTS ix_stock_movements_reference_id ON stock_movements(reference_id);
+
+-- the journal is append-only, history can not be rewritten
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_stock_movements_append_only
+  BEFORE UPDATE OR DELETE ON stock_movements
+  FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- quantities that existed before the journal become its opening balance
+INSERT INTO stock_movements (size_id, type, quantity, comment)
+  SELECT id, 'correction', quantity, 'opening balance' FROM sizes WHERE quantity <> 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
+DROP INDEX IF EXISTS ix_stock_movements_reference_id;
+DROP INDEX IF EXISTS ix_stock_movements_size_id;
+DROP TABLE IF EXISTS stock_movements;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS transfers (
+  id                       uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner_id                 uuid NOT NULL,
+  source_warehouse_id      uuid NOT NULL,
+  destination_warehouse_id uuid NOT NULL,
+  status                   VARCHAR(20) NOT NULL DEFAULT 'draft',
+  comment                  TEXT NOT NULL DEFAULT '',
+  created_at               TIMESTAMP NOT NULL DEFAULT NOW(),
+  shipped_at               TIMESTAMP,
+  received_at              TIMESTAMP,
+  CONSTRAINT fk_transfers_owner_id FOREIGN KEY (owner_id)
+    REFERENCES owners(id),
+  CONSTRAINT fk_transfers_source_warehouse_id FOREIGN KEY (source_warehouse_id)
+    REFERENCES warehouses(id),
+  CONSTRAINT fk_transfers_destination_warehouse_id FOREIGN KEY (destination_warehouse_id)
+    REFERENCES warehouses(id),
+  CONSTRAINT check_transfers_warehouses CHECK (source_warehouse_id <> destination_warehouse_id),
+  CONSTRAINT check_transfers_status CHECK (status IN (
+    'draft', 'shipped', 'partiallyReceived', 'received'
+  ))
+);
+
+CREATE INDEX IF NOT EXISTS ix_transfers_owner_id ON transfers(owner_id);
+
+CREATE TABLE IF NOT EXISTS transfer_lines (
+  id                BIGSERIAL PRIMARY KEY,
+  transfer_id       uuid NOT NULL,
+  size_id           BIGINT NOT NULL,
+  quantity          BIGINT NOT NULL,
+  received_quantity BIGINT NOT NULL DEFAULT 0,
+  discrepancy       BIGINT NOT NULL DEFAULT 0,
+  discrepancy_note  TEXT NOT NULL DEFAULT '',
+  CONSTRAINT fk_transfer_lines_transfer_id FOREIGN KEY (transfer_id)
+    REFERENCES transfers(id) ON DELETE CASCADE,
+  CONSTRAINT fk_transfer_lines_size_id FOREIGN KEY (size_id)
+    REFERENCES sizes(id),
+  CONSTRAINT ux_transfer_lines_size UNIQUE (transfer_id, size_id),
+  CONSTRAINT check_transfer_lines_quantity CHECK (
+    quantity > 0 AND
+    received_quantity >= 0 AND
+    discrepancy >= 0 AND
+    received_quantity + discrepancy <= quantity
+  )
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transfer_lines;
+DROP INDEX IF EXISTS ix_transfers_owner_id;
+DROP TABLE IF EXISTS transfers;
+-- +goose StatementEnd
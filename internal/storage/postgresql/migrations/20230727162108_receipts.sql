@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS receipts (
+  id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  store_id   uuid NOT NULL,
+  seller_id  uuid, -- NULL when the owner made the sale
+  total      NUMERIC(12, 2) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_receipts_store_id FOREIGN KEY (store_id)
+    REFERENCES stores(id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_receipts_store_id_created_at ON receipts(store_id, created_at);
+CREATE INDEX IF NOT EXISTS ix_receipts_seller_id ON receipts(seller_id);
+
+CREATE TABLE IF NOT EXISTS receipt_lines (
+  id         BIGSERIAL PRIMARY KEY,
+  receipt_id uuid NOT NULL,
+  item_id    uuid NOT NULL,
+  size_id    BIGINT NOT NULL,
+  quantity   BIGINT NOT NULL,
+  price      NUMERIC(12, 2) NOT NULL,
+  discount   NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  total      NUMERIC(12, 2) NOT NULL,
+  CONSTRAINT fk_receipt_lines_receipt_id FOREIGN KEY (receipt_id)
+    REFERENCES receipts(id),
+  CONSTRAINT fk_receipt_lines_item_id FOREIGN KEY (item_id)
+    REFERENCES items(id),
+  CONSTRAINT fk_receipt_lines_size_id FOREIGN KEY (size_id)
+    REFERENCES sizes(id),
+  CONSTRAINT check_receipt_lines_amounts CHECK (
+    quantity > 0 AND
+    discount >= 0 AND
+    discount <= price * quantity
+  )
+);
+
+CREATE INDEX IF NOT EXISTS ix_receipt_lines_receipt_id ON receipt_lines(receipt_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_receipt_lines_receipt_id;
+DROP TABLE IF EXISTS receipt_lines;
+DROP INDEX IF EXISTS ix_receipts_seller_id;
+DROP INDEX IF EXISTS ix_receipts_store_id_created_at;
+DROP TABLE IF EXISTS receipts;
+-- +goose StatementEnd
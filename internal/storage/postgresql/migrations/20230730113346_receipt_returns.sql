@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS receipt_returns (
+  id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  line_id      BIGINT NOT NULL,
+  warehouse_id uuid NOT NULL,
+  size_id      BIGINT NOT NULL, -- size the goods were put back to
+  seller_id    uuid, -- NULL when the owner made the return
+  quantity     BIGINT NOT NULL,
+  refund       NUMERIC(12, 2) NOT NULL,
+  reason       TEXT NOT NULL,
+  created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_receipt_returns_line_id FOREIGN KEY (line_id)
+    REFERENCES receipt_lines(id),
+  CONSTRAINT fk_receipt_returns_warehouse_id FOREIGN KEY (warehouse_id)
+    REFERENCES warehouses(id),
+  CONSTRAINT fk_receipt_returns_size_id FOREIGN KEY (size_id)
+    REFERENCES sizes(id),
+  CONSTRAINT check_receipt_returns_amounts CHECK (quantity > 0 AND refund >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS ix_receipt_returns_line_id ON receipt_returns(line_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_receipt_returns_line_id;
+DROP TABLE IF EXISTS receipt_returns;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sellers (
+  id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner_id     uuid NOT NULL,
+  username     VARCHAR(500) NOT NULL,
+  full_name    TEXT NOT NULL,
+  phone_number VARCHAR(500) NOT NULL DEFAULT '',
+  is_active    BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_sellers_owner_id FOREIGN KEY (owner_id)
+    REFERENCES owners(id),
+  CONSTRAINT ux_sellers_username UNIQUE (username)
+);
+
+CREATE INDEX IF NOT EXISTS ix_sellers_owner_id ON sellers(owner_id);
+
+CREATE TABLE IF NOT EXISTS seller_stores (
+  seller_id  uuid NOT NULL,
+  store_id   uuid NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (seller_id, store_id),
+  CONSTRAINT fk_seller_stores_seller_id FOREIGN KEY (seller_id)
+    REFERENCES sellers(id) ON DELETE CASCADE,
+  CONSTRAINT fk_seller_stores_store_id FOREIGN KEY (store_id)
+    REFERENCES stores(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ix_seller_stores_store_id ON seller_stores(store_id);
+
+ALTER TABLE receipts ADD CONSTRAINT fk_receipts_seller_id FOREIGN KEY (seller_id)
+  REFERENCES sellers(id);
+ALTER TABLE receipt_returns ADD CONSTRAINT fk_receipt_returns_seller_id FOREIGN KEY (seller_id)
+  REFERENCES sellers(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE receipt_returns DROP CONSTRAINT IF EXISTS fk_receipt_returns_seller_id;
+ALTER TABLE receipts DROP CONSTRAINT IF EXISTS fk_receipts_seller_id;
+DROP INDEX IF EXISTS ix_seller_stores_store_id;
+DROP TABLE IF EXISTS seller_stores;
+DROP INDEX IF EXISTS ix_sellers_owner_id;
+DROP TABLE IF EXISTS sellers;
+-- +goose StatementEnd
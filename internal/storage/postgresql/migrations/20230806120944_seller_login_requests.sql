@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS seller_login_requests (
+  id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  seller_id  uuid NOT NULL,
+  code_hash  VARCHAR(64) NOT NULL DEFAULT '', -- empty when no code was sent
+  attempts   INT NOT NULL DEFAULT 0,
+  status     VARCHAR(20) NOT NULL DEFAULT 'pending',
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_seller_login_requests_seller_id FOREIGN KEY (seller_id)
+    REFERENCES sellers(id) ON DELETE CASCADE,
+  CONSTRAINT check_seller_login_requests_status CHECK (status IN (
+    'pending', 'approved', 'rejected', 'used'
+  ))
+);
+
+CREATE INDEX IF NOT EXISTS ix_seller_login_requests_seller_id ON seller_login_requests(seller_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_seller_login_requests_seller_id;
+DROP TABLE IF EXISTS seller_login_requests;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS kv (
+  key        TEXT PRIMARY KEY,
+  value      TEXT NOT NULL,
+  expires_at TIMESTAMP NULL -- NULL means the key never expires
+);
+
+CREATE INDEX IF NOT EXISTS ix_kv_expires_at ON kv(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_kv_expires_at;
+DROP TABLE IF EXISTS kv;
+-- +goose StatementEnd
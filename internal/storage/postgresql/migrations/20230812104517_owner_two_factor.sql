@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NULL; -- set on enrollment, before it is confirmed
+ALTER TABLE owners ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS owner_recovery_codes (
+  owner_id  uuid NOT NULL,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at   TIMESTAMP NULL,
+  PRIMARY KEY (owner_id, code_hash),
+  CONSTRAINT fk_owner_recovery_codes_owner_id FOREIGN KEY (owner_id)
+    REFERENCES owners(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS owner_recovery_codes;
+ALTER TABLE owners DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE owners DROP COLUMN IF EXISTS totp_secret;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys (
+  id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  owner_id     uuid NOT NULL,
+  name         VARCHAR(100) NOT NULL,
+  prefix       VARCHAR(16) NOT NULL,
+  key_hash     VARCHAR(64) NOT NULL,
+  scopes       TEXT[] NOT NULL DEFAULT '{}',
+  last_used_at TIMESTAMP NULL,
+  revoked_at   TIMESTAMP NULL,
+  created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_api_keys_owner_id FOREIGN KEY (owner_id)
+    REFERENCES owners(id) ON DELETE CASCADE,
+  CONSTRAINT unique_api_keys_key_hash UNIQUE (key_hash)
+);
+
+CREATE INDEX IF NOT EXISTS ix_api_keys_owner_id ON api_keys(owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_api_keys_owner_id;
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
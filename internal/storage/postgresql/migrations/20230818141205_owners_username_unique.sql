@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS unique_owners_username ON owners(username);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS unique_owners_username;
+-- +goose StatementEnd
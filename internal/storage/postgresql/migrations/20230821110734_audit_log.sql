@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- audit_log has no foreign keys: entries stay after the entity, or even the
+-- owner, is gone.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id          uuid PRIMARY KEY,
+  owner_id    uuid NOT NULL,
+  actor_id    TEXT NOT NULL,
+  actor_role  VARCHAR(20) NOT NULL,
+  api_key_id  TEXT NOT NULL DEFAULT '',
+  action      VARCHAR(20) NOT NULL,
+  entity_type VARCHAR(50) NOT NULL,
+  entity_id   TEXT NOT NULL,
+  changes     JSONB NOT NULL,
+  request_id  TEXT NOT NULL DEFAULT '',
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ix_audit_log_owner_id_created_at ON audit_log(owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS ix_audit_log_entity ON audit_log(entity_type, entity_id);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+  FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+-- +goose StatementEnd
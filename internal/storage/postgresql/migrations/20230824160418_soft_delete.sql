@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+-- trash is small, purge job looks only at it
+CREATE INDEX IF NOT EXISTS ix_stores_deleted_at ON stores(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS ix_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_categories_deleted_at;
+DROP INDEX IF EXISTS ix_stores_deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE stores DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
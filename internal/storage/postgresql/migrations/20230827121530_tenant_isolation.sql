@@ -0,0 +1,85 @@
+-- +goose Up
+-- +goose StatementBegin
+-- app_tenant is the owner the connection works for, the application sets
+-- it on every acquire. NULL matches no rows, so a request that forgot to
+-- set it sees nothing.
+CREATE OR REPLACE FUNCTION app_tenant() RETURNS uuid AS $$
+  SELECT NULLIF(current_setting('app.owner_id', true), '')::uuid;
+$$ LANGUAGE sql STABLE;
+
+-- app_system is true when the application explicitly works for the system
+-- (sign in, background jobs) and has to see data of every owner. The
+-- application logs every connection it is set on.
+CREATE OR REPLACE FUNCTION app_system() RETURNS boolean AS $$
+  SELECT current_setting('app.system', true) = 'on';
+$$ LANGUAGE sql STABLE;
+
+-- Every table with data of an owner gets a policy like these. FORCE makes
+-- policies apply to the owner of tables too, only superusers and roles
+-- with BYPASSRLS skip them.
+ALTER TABLE stores ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stores FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stores
+  USING (owner_id = app_tenant() OR app_system());
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE categories FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON categories
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM stores s WHERE s.id = categories.store_id AND s.owner_id = app_tenant()
+  ));
+
+ALTER TABLE items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON items
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM stores s WHERE s.id = items.store_id AND s.owner_id = app_tenant()
+  ));
+
+ALTER TABLE warehouses ENABLE ROW LEVEL SECURITY;
+ALTER TABLE warehouses FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON warehouses
+  USING (owner_id = app_tenant() OR app_system());
+
+ALTER TABLE transfers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transfers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON transfers
+  USING (owner_id = app_tenant() OR app_system());
+
+ALTER TABLE receipts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE receipts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON receipts
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM stores s WHERE s.id = receipts.store_id AND s.owner_id = app_tenant()
+  ));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY IF EXISTS tenant_isolation ON receipts;
+ALTER TABLE receipts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE receipts DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON transfers;
+ALTER TABLE transfers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transfers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON warehouses;
+ALTER TABLE warehouses NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE warehouses DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON items;
+ALTER TABLE items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE items DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON categories;
+ALTER TABLE categories NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE categories DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stores;
+ALTER TABLE stores NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stores DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS app_system();
+DROP FUNCTION IF EXISTS app_tenant();
+-- +goose StatementEnd
@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Account deletion is the only way to remove journal rows, it calls
+-- purge_owner_journals. The function runs as the owner of the tables,
+-- the application's role never does, so setting app.purge_owner from
+-- the application is not enough to delete anything. The owner of the
+-- tables could drop the triggers anyway.
+CREATE OR REPLACE FUNCTION purging_owner(row_owner uuid, tbl name) RETURNS boolean AS $$
+  SELECT current_user = (SELECT tableowner FROM pg_tables WHERE schemaname = 'public' AND tablename = tbl)
+    AND NULLIF(current_setting('app.purge_owner', true), '') = row_owner::text;
+$$ LANGUAGE sql STABLE;
+
+-- Only movements of sizes of the purged owner may be deleted, updates are
+-- never allowed.
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' AND EXISTS (
+    SELECT 1 FROM sizes z
+    LEFT JOIN warehouses w ON w.id = z.warehouse_id
+    LEFT JOIN items i ON i.id = z.item_id
+    LEFT JOIN stores s ON s.id = i.store_id
+    WHERE z.id = OLD.size_id
+      AND (purging_owner(w.owner_id, TG_TABLE_NAME) OR purging_owner(s.owner_id, TG_TABLE_NAME))
+  ) THEN
+    RETURN OLD;
+  END IF;
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' AND purging_owner(OLD.owner_id, TG_TABLE_NAME) THEN
+    RETURN OLD;
+  END IF;
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- purge_owner_journals deletes stock movements and audit entries of
+-- purged, it is called in the transaction deleting the owner.
+CREATE OR REPLACE FUNCTION purge_owner_journals(purged uuid) RETURNS void
+SECURITY DEFINER SET search_path = public, pg_temp AS $$
+BEGIN
+  PERFORM set_config('app.purge_owner', purged::text, true);
+  DELETE FROM stock_movements WHERE size_id IN (
+    SELECT z.id FROM sizes z JOIN items i ON i.id = z.item_id JOIN stores s ON s.id = i.store_id WHERE s.owner_id = purged
+    UNION
+    SELECT z.id FROM sizes z JOIN warehouses w ON w.id = z.warehouse_id WHERE w.owner_id = purged);
+  DELETE FROM audit_log WHERE audit_log.owner_id = purged;
+  PERFORM set_config('app.purge_owner', '', true);
+END;
+$$ LANGUAGE plpgsql;
+
+-- accounter_app is granted it in "tenant_isolation_children"
+REVOKE ALL ON FUNCTION purge_owner_journals(uuid) FROM PUBLIC;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS purge_owner_journals(uuid);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS purging_owner(uuid, name);
+-- +goose StatementEnd
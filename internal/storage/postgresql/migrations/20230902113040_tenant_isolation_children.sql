@@ -0,0 +1,128 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tables without owner_id of their own see the tenant through the row
+-- they belong to, same as categories and items in "tenant_isolation".
+ALTER TABLE sizes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sizes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON sizes
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM warehouses w WHERE w.id = sizes.warehouse_id AND w.owner_id = app_tenant()
+  ));
+
+ALTER TABLE stock_movements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_movements FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON stock_movements
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM sizes z JOIN warehouses w ON w.id = z.warehouse_id
+    WHERE z.id = stock_movements.size_id AND w.owner_id = app_tenant()
+  ));
+
+ALTER TABLE sellers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sellers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON sellers
+  USING (owner_id = app_tenant() OR app_system());
+
+ALTER TABLE seller_stores ENABLE ROW LEVEL SECURITY;
+ALTER TABLE seller_stores FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON seller_stores
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM sellers s WHERE s.id = seller_stores.seller_id AND s.owner_id = app_tenant()
+  ));
+
+ALTER TABLE receipt_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE receipt_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON receipt_lines
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM receipts r JOIN stores s ON s.id = r.store_id
+    WHERE r.id = receipt_lines.receipt_id AND s.owner_id = app_tenant()
+  ));
+
+ALTER TABLE receipt_returns ENABLE ROW LEVEL SECURITY;
+ALTER TABLE receipt_returns FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON receipt_returns
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM receipt_lines l
+    JOIN receipts r ON r.id = l.receipt_id
+    JOIN stores s ON s.id = r.store_id
+    WHERE l.id = receipt_returns.line_id AND s.owner_id = app_tenant()
+  ));
+
+ALTER TABLE transfer_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transfer_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON transfer_lines
+  USING (app_system() OR EXISTS (
+    SELECT 1 FROM transfers t WHERE t.id = transfer_lines.transfer_id AND t.owner_id = app_tenant()
+  ));
+
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON api_keys
+  USING (owner_id = app_tenant() OR app_system());
+
+-- accounter_app is the role the application works as, policies apply to
+-- it. Login roles are created by operators with IN ROLE accounter_app,
+-- see README. Migrating roles without CREATEROLE skip it.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'accounter_app') THEN
+    CREATE ROLE accounter_app NOLOGIN NOSUPERUSER NOBYPASSRLS;
+  END IF;
+  GRANT USAGE ON SCHEMA public TO accounter_app;
+  GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO accounter_app;
+  GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO accounter_app;
+  GRANT EXECUTE ON FUNCTION purge_owner_journals(uuid) TO accounter_app;
+  ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO accounter_app;
+  ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO accounter_app;
+EXCEPTION WHEN insufficient_privilege THEN
+  RAISE NOTICE 'accounter_app role is not created: %', SQLERRM;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'accounter_app') THEN
+    ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE ALL ON TABLES FROM accounter_app;
+    ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE ALL ON SEQUENCES FROM accounter_app;
+    REVOKE ALL ON ALL TABLES IN SCHEMA public FROM accounter_app;
+    REVOKE ALL ON ALL SEQUENCES IN SCHEMA public FROM accounter_app;
+    REVOKE EXECUTE ON FUNCTION purge_owner_journals(uuid) FROM accounter_app;
+    REVOKE USAGE ON SCHEMA public FROM accounter_app;
+  END IF;
+EXCEPTION WHEN insufficient_privilege THEN
+  RAISE NOTICE 'accounter_app role is kept: %', SQLERRM;
+END $$;
+
+DROP POLICY IF EXISTS tenant_isolation ON api_keys;
+ALTER TABLE api_keys NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE api_keys DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON transfer_lines;
+ALTER TABLE transfer_lines NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transfer_lines DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON receipt_returns;
+ALTER TABLE receipt_returns NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE receipt_returns DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON receipt_lines;
+ALTER TABLE receipt_lines NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE receipt_lines DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON seller_stores;
+ALTER TABLE seller_stores NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE seller_stores DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON sellers;
+ALTER TABLE sellers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE sellers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON stock_movements;
+ALTER TABLE stock_movements NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE stock_movements DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON sizes;
+ALTER TABLE sizes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE sizes DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
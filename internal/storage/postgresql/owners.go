@@ -2,10 +2,13 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
 	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
 )
@@ -26,8 +29,11 @@ func (r ownersRepository) Create(ctx context.Context, owner entities.Owner) (ent
 		return entities.Owner{}, fmt.Errorf("could not construct sql: %w", err)
 	}
 
-	row := r.conn.QueryRow(ctx, sql, args...)
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
 	if err := row.Scan(&owner.ID); err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return entities.Owner{}, auth.ErrUsernameTaken
+		}
 		return entities.Owner{}, fmt.Errorf("could not scan row: %w", err)
 	}
 
@@ -47,8 +53,11 @@ func (r ownersRepository) Read(ctx context.Context, id string) (entities.Owner,
 		return entities.Owner{}, fmt.Errorf("could not construct sql: %w", err)
 	}
 
-	row := r.conn.QueryRow(ctx, sql, args...)
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
 	if err := row.Scan(&owner.ID, &owner.FullName, &owner.Username, &owner.Password, &owner.PhoneNumber, &owner.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Owner{}, auth.ErrIdNotFound
+		}
 		return entities.Owner{}, fmt.Errorf("could not scan row: %w", err)
 	}
 
@@ -68,8 +77,11 @@ func (r ownersRepository) ReadByUsername(ctx context.Context, username string) (
 		return entities.Owner{}, fmt.Errorf("could not construct sql: %w", err)
 	}
 
-	row := r.conn.QueryRow(ctx, sql, args...)
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
 	if err := row.Scan(&owner.ID, &owner.FullName, &owner.Username, &owner.Password, &owner.PhoneNumber, &owner.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Owner{}, auth.ErrUsernameNotFound
+		}
 		return entities.Owner{}, fmt.Errorf("could not scan row: %w", err)
 	}
 
@@ -88,7 +100,7 @@ func (r ownersRepository) ReadAll(ctx context.Context) ([]entities.Owner, error)
 		return nil, fmt.Errorf("could not construct sql: %w", err)
 	}
 
-	rows, err := r.conn.Query(ctx, sql, args...)
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not scan row: %w", err)
 	}
@@ -119,14 +131,42 @@ func (r ownersRepository) Update(ctx context.Context, owner entities.Owner) (ent
 		return entities.Owner{}, fmt.Errorf("could not construct sql: %w", err)
 	}
 
-	row := r.conn.QueryRow(ctx, sql, args...)
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
 	if err := row.Scan(&owner.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Owner{}, auth.ErrIdNotFound
+		}
+		if isPgError(err, pgUniqueViolation) {
+			return entities.Owner{}, auth.ErrUsernameTaken
+		}
 		return entities.Owner{}, fmt.Errorf("could not scan row: %w", err)
 	}
 
 	return owner, nil
 }
 
+// ownerDataQueries delete everything an owner has, children first.
+// Each query takes owner id as $1.
+var ownerDataQueries = []string{
+	`DELETE FROM receipt_returns WHERE line_id IN (
+		SELECT l.id FROM receipt_lines l
+		JOIN receipts r ON r.id = l.receipt_id
+		JOIN stores s ON s.id = r.store_id
+		WHERE s.owner_id = $1)`,
+	`DELETE FROM receipt_lines WHERE receipt_id IN (
+		SELECT r.id FROM receipts r JOIN stores s ON s.id = r.store_id WHERE s.owner_id = $1)`,
+	`DELETE FROM receipts WHERE store_id IN (SELECT id FROM stores WHERE owner_id = $1)`,
+	`DELETE FROM transfers WHERE owner_id = $1`,
+	`DELETE FROM sizes WHERE warehouse_id IN (SELECT id FROM warehouses WHERE owner_id = $1)`,
+	`DELETE FROM items WHERE store_id IN (SELECT id FROM stores WHERE owner_id = $1)`,
+	`DELETE FROM categories WHERE store_id IN (SELECT id FROM stores WHERE owner_id = $1)`,
+	`DELETE FROM sellers WHERE owner_id = $1`,
+	`DELETE FROM stores WHERE owner_id = $1`,
+	`DELETE FROM warehouses WHERE owner_id = $1`,
+}
+
+// Delete removes owner together with stores, categories, items, stock,
+// sales, sellers and audit log in one transaction.
 func (r ownersRepository) Delete(ctx context.Context, id string) error {
 	defer telemetry.NewSpan(ctx, PackageName+"ownersRepository.Delete").End()
 
@@ -137,9 +177,63 @@ func (r ownersRepository) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("could not construct sql: %w", err)
 	}
 
-	if _, err := r.conn.Exec(ctx, sql, args...); err != nil {
-		return fmt.Errorf("could not scan row: %w", err)
-	}
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		// stock journal and audit log are append-only, only this function
+		// may delete from them, see migration "purge_owner_movements"
+		if _, err := tx.Exec(ctx, "SELECT purge_owner_journals($1)", id); err != nil {
+			return fmt.Errorf("could not purge journals: %w", err)
+		}
 
-	return nil
+		for _, query := range ownerDataQueries {
+			if _, err := tx.Exec(ctx, query, id); err != nil {
+				return fmt.Errorf("could not delete owner data: %w", err)
+			}
+		}
+
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("could not delete owner: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return auth.ErrIdNotFound
+		}
+		return nil
+	})
+}
+
+// ownerAnonymizeQueries remove personal data of an owner's sellers and
+// everything they could sign in with. Each query takes owner id as $1.
+var ownerAnonymizeQueries = []string{
+	`DELETE FROM seller_login_requests WHERE seller_id IN (SELECT id FROM sellers WHERE owner_id = $1)`,
+	`UPDATE sellers SET username = 'deleted-' || id, full_name = '', phone_number = '', is_active = FALSE
+		WHERE owner_id = $1`,
+	`DELETE FROM api_keys WHERE owner_id = $1`,
+	`DELETE FROM owner_recovery_codes WHERE owner_id = $1`,
+}
+
+// Anonymize clears personal data of owner and sellers of the owner in one
+// transaction, business records stay.
+func (r ownersRepository) Anonymize(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"ownersRepository.Anonymize").End()
+
+	const sql = `UPDATE owners SET username = 'deleted-' || id, full_name = '', phone_number = '',
+		password_hash = '', totp_secret = NULL, totp_enabled = FALSE
+		WHERE id = $1`
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		for _, query := range ownerAnonymizeQueries {
+			if _, err := tx.Exec(ctx, query, id); err != nil {
+				return fmt.Errorf("could not anonymize owner data: %w", err)
+			}
+		}
+
+		tag, err := tx.Exec(ctx, sql, id)
+		if err != nil {
+			return fmt.Errorf("could not anonymize owner: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return auth.ErrIdNotFound
+		}
+		return nil
+	})
 }
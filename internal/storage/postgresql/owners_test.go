@@ -0,0 +1,213 @@
+package postgresql
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rasulov-emirlan/accounter-backend/config"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+)
+
+// TestDeleteOwnerWithMovements needs a database, set TEST_DATABASE_URL to
+// run it. Stock journal and audit log are append-only, but account
+// deletion has to be able to remove them.
+func TestDeleteOwnerWithMovements(t *testing.T) {
+	system := ownersTestRepositories(t)
+	ctx := systemContext()
+
+	t.Run("Delete", func(t *testing.T) {
+		owner, size := createStockedOwner(t, system)
+		appendAuditEntry(t, system, owner.ID)
+
+		if err := system.Owners().Delete(ctx, owner.ID.String()); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if n := countMovements(t, system, size.ID); n != 0 {
+			t.Errorf("Delete: %d movements of deleted owner are left", n)
+		}
+		if n := countAuditEntries(t, system, owner.ID); n != 0 {
+			t.Errorf("Delete: %d audit entries of deleted owner are left", n)
+		}
+	})
+
+	t.Run("Anonymize", func(t *testing.T) {
+		owner, size := createStockedOwner(t, system)
+		appendAuditEntry(t, system, owner.ID)
+
+		if err := system.Owners().Anonymize(ctx, owner.ID.String()); err != nil {
+			t.Fatalf("Anonymize: %v", err)
+		}
+		if n := countMovements(t, system, size.ID); n != 1 {
+			t.Errorf("Anonymize: got %d movements, want 1", n)
+		}
+		if n := countAuditEntries(t, system, owner.ID); n != 1 {
+			t.Errorf("Anonymize: got %d audit entries, want 1", n)
+		}
+
+		got, err := system.Owners().Read(ctx, owner.ID.String())
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if got.Username == owner.Username || got.FullName != "" || got.PhoneNumber != "" {
+			t.Errorf("Read after Anonymize: personal data is kept %+v", got)
+		}
+	})
+
+	t.Run("JournalStaysAppendOnly", func(t *testing.T) {
+		_, size := createStockedOwner(t, system)
+
+		if _, err := system.storesRepo.conn.Exec(ctx, "DELETE FROM stock_movements WHERE size_id = $1", size.ID); err == nil {
+			t.Error("movements were deleted without account deletion")
+		}
+	})
+
+	t.Run("PurgeSettingIsNotEnough", func(t *testing.T) {
+		owner, size := createStockedOwner(t, system)
+		pool := tenantPool(t, system.storesRepo.conn, os.Getenv("TEST_DATABASE_URL"))
+
+		var tablesOwner bool
+		err := pool.QueryRow(ctx, "SELECT current_user = tableowner FROM pg_tables WHERE schemaname = 'public' AND tablename = 'stock_movements'").Scan(&tablesOwner)
+		if err != nil {
+			t.Fatalf("could not read owner of tables: %v", err)
+		}
+		if tablesOwner {
+			t.Skip("TEST_DATABASE_URL role owns the tables, it may delete anything")
+		}
+
+		asOwner := tenancy.WithOwner(ctx, owner.ID.String())
+		err = pgx.BeginFunc(asOwner, pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(asOwner, "SELECT set_config('app.purge_owner', $1, true)", owner.ID.String()); err != nil {
+				return err
+			}
+			_, err := tx.Exec(asOwner, "DELETE FROM stock_movements WHERE size_id = $1", size.ID)
+			return err
+		})
+		if err == nil {
+			t.Error("movements were deleted by the application's role")
+		}
+	})
+}
+
+// appendAuditEntry appends an entry about a store of owner.
+func appendAuditEntry(t *testing.T, system RepositoryCombiner, ownerID uuid.UUID) {
+	t.Helper()
+
+	err := system.Audit().Append(systemContext(), entities.AuditEntry{
+		ID:         uuid.New(),
+		OwnerID:    ownerID,
+		ActorID:    ownerID.String(),
+		ActorRole:  "owner",
+		Action:     "create",
+		EntityType: "store",
+		EntityID:   uuid.NewString(),
+		Changes:    []byte(`{}`),
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("could not append audit entry: %v", err)
+	}
+}
+
+func countAuditEntries(t *testing.T, system RepositoryCombiner, ownerID uuid.UUID) int {
+	t.Helper()
+
+	var n int
+	err := system.storesRepo.conn.QueryRow(systemContext(), "SELECT COUNT(*) FROM audit_log WHERE owner_id = $1", ownerID).Scan(&n)
+	if err != nil {
+		t.Fatalf("could not count audit entries: %v", err)
+	}
+	return n
+}
+
+func ownersTestRepositories(t *testing.T) RepositoryCombiner {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	log, err := logging.NewLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{DatabaseURL: url}
+	cfg.Flags.WithMigrations = true
+
+	ctx := context.Background()
+	system, err := NewRepositories(ctx, cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { system.Close(ctx) })
+	return system
+}
+
+// createStockedOwner creates an owner with a store, an item and a size of
+// it in a warehouse, opening quantity of the size is in the journal.
+func createStockedOwner(t *testing.T, system RepositoryCombiner) (entities.Owner, entities.Size) {
+	t.Helper()
+	ctx := systemContext()
+
+	owner, err := entities.NewOwner("+996700000000", "Stocked", "stocked_"+uuid.NewString(), "password", entities.MinHashCost)
+	if err != nil {
+		t.Fatalf("could not build owner: %v", err)
+	}
+	owner, err = system.Owners().Create(ctx, owner)
+	if err != nil {
+		t.Fatalf("could not create owner: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := system.Owners().Delete(ctx, owner.ID.String()); err != nil && err != auth.ErrIdNotFound {
+			t.Errorf("could not delete owner: %v", err)
+		}
+	})
+
+	store, err := system.Stores().Create(ctx, entities.Store{
+		Owner: &entities.Owner{ID: owner.ID},
+		Name:  "Stocked Store",
+	})
+	if err != nil {
+		t.Fatalf("could not create store: %v", err)
+	}
+
+	item, err := system.Items().Create(ctx, entities.NewItem(&store, nil, "Boots", "B-1", "", "", "000000", 100))
+	if err != nil {
+		t.Fatalf("could not create item: %v", err)
+	}
+
+	warehouse, err := system.Warehouses().Create(ctx, entities.NewWarehouse(&owner, "Stocked Warehouse", "of "+owner.Username))
+	if err != nil {
+		t.Fatalf("could not create warehouse: %v", err)
+	}
+
+	symbol := "M"
+	size, err := entities.NewSize(&item, &warehouse, nil, &symbol, 5, 50)
+	if err != nil {
+		t.Fatalf("could not build size: %v", err)
+	}
+	size, err = system.Stock().Create(ctx, size)
+	if err != nil {
+		t.Fatalf("could not create size: %v", err)
+	}
+	return owner, size
+}
+
+func countMovements(t *testing.T, system RepositoryCombiner, sizeID int64) int {
+	t.Helper()
+
+	var n int
+	err := system.storesRepo.conn.QueryRow(systemContext(), "SELECT COUNT(*) FROM stock_movements WHERE size_id = $1", sizeID).Scan(&n)
+	if err != nil {
+		t.Fatalf("could not count movements: %v", err)
+	}
+	return n
+}
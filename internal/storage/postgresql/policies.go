@@ -0,0 +1,117 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type policiesRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r policiesRepository) StoreOwner(ctx context.Context, storeID string) (string, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"policiesRepository.StoreOwner").End()
+
+	var ownerID uuid.UUID
+	err := db(ctx, r.conn).QueryRow(ctx, "SELECT owner_id FROM stores WHERE id = $1", storeID).Scan(&ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", policies.ErrNotFound
+		}
+		return "", err
+	}
+	return ownerID.String(), nil
+}
+
+func (r policiesRepository) CategoryStore(ctx context.Context, categoryID string) (policies.StoreRef, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"policiesRepository.CategoryStore").End()
+
+	const sql = `SELECT s.id, s.owner_id FROM categories c
+		JOIN stores s ON s.id = c.store_id
+		WHERE c.id = $1`
+
+	var storeID, ownerID uuid.UUID
+	err := db(ctx, r.conn).QueryRow(ctx, sql, categoryID).Scan(&storeID, &ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return policies.StoreRef{}, policies.ErrNotFound
+		}
+		return policies.StoreRef{}, err
+	}
+	return policies.StoreRef{ID: storeID.String(), OwnerID: ownerID.String()}, nil
+}
+
+func (r policiesRepository) ItemStore(ctx context.Context, itemID string) (policies.StoreRef, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"policiesRepository.ItemStore").End()
+
+	const sql = `SELECT s.id, s.owner_id FROM items i
+		JOIN stores s ON s.id = i.store_id
+		WHERE i.id = $1`
+
+	var storeID, ownerID uuid.UUID
+	err := db(ctx, r.conn).QueryRow(ctx, sql, itemID).Scan(&storeID, &ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return policies.StoreRef{}, policies.ErrNotFound
+		}
+		return policies.StoreRef{}, err
+	}
+	return policies.StoreRef{ID: storeID.String(), OwnerID: ownerID.String()}, nil
+}
+
+func (r policiesRepository) ReceiptStore(ctx context.Context, receiptID string) (policies.StoreRef, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"policiesRepository.ReceiptStore").End()
+
+	const sql = `SELECT s.id, s.owner_id FROM receipts r
+		JOIN stores s ON s.id = r.store_id
+		WHERE r.id = $1`
+
+	var storeID, ownerID uuid.UUID
+	err := db(ctx, r.conn).QueryRow(ctx, sql, receiptID).Scan(&storeID, &ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return policies.StoreRef{}, policies.ErrNotFound
+		}
+		return policies.StoreRef{}, err
+	}
+	return policies.StoreRef{ID: storeID.String(), OwnerID: ownerID.String()}, nil
+}
+
+func (r policiesRepository) WarehouseOwner(ctx context.Context, warehouseID string) (string, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"policiesRepository.WarehouseOwner").End()
+
+	var ownerID uuid.UUID
+	err := db(ctx, r.conn).QueryRow(ctx, "SELECT owner_id FROM warehouses WHERE id = $1", warehouseID).Scan(&ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", policies.ErrNotFound
+		}
+		return "", err
+	}
+	return ownerID.String(), nil
+}
+
+func (r policiesRepository) SizeStore(ctx context.Context, sizeID int64) (policies.StoreRef, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"policiesRepository.SizeStore").End()
+
+	const sql = `SELECT s.id, s.owner_id FROM sizes sz
+		JOIN items i ON i.id = sz.item_id
+		JOIN stores s ON s.id = i.store_id
+		WHERE sz.id = $1`
+
+	var storeID, ownerID uuid.UUID
+	err := db(ctx, r.conn).QueryRow(ctx, sql, sizeID).Scan(&storeID, &ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return policies.StoreRef{}, policies.ErrNotFound
+		}
+		return policies.StoreRef{}, err
+	}
+	return policies.StoreRef{ID: storeID.String(), OwnerID: ownerID.String()}, nil
+}
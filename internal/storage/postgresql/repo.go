@@ -16,6 +16,18 @@ type RepositoryCombiner struct {
 	ownersRepo     ownersRepository
 	storesRepo     storesRepository
 	categoriesRepo categoriesRepository
+	itemsRepo      itemsRepository
+	stockRepo      stockRepository
+	warehousesRepo warehousesRepository
+	transfersRepo  transfersRepository
+	salesRepo      salesRepository
+	sellersRepo    sellersRepository
+	policiesRepo   policiesRepository
+	kvRepo         kvRepository
+	twoFactorRepo  twoFactorRepository
+	apiKeysRepo    apiKeysRepository
+	auditRepo      auditRepository
+	unitOfWork     unitOfWork
 }
 
 func NewRepositories(ctx context.Context, cfg config.Config, log *logging.Logger) (RepositoryCombiner, error) {
@@ -25,6 +37,7 @@ func NewRepositories(ctx context.Context, cfg config.Config, log *logging.Logger
 	}
 
 	c.ConnConfig.Tracer = log
+	withTenancy(c, log)
 
 	conn, err := pgxpool.NewWithConfig(ctx, c)
 	if err != nil {
@@ -36,7 +49,11 @@ func NewRepositories(ctx context.Context, cfg config.Config, log *logging.Logger
 	}
 
 	if cfg.Flags.WithMigrations {
-		if err := migrations.Up(ctx, cfg.DatabaseURL, log.Goosed()); err != nil {
+		url := cfg.MigrationsDatabaseURL
+		if url == "" {
+			url = cfg.DatabaseURL
+		}
+		if err := migrations.Up(ctx, url, log.Goosed()); err != nil {
 			return RepositoryCombiner{}, fmt.Errorf("could not migrate database: %w", err)
 		}
 	}
@@ -45,9 +62,29 @@ func NewRepositories(ctx context.Context, cfg config.Config, log *logging.Logger
 		ownersRepo:     ownersRepository{conn},
 		storesRepo:     storesRepository{conn},
 		categoriesRepo: categoriesRepository{conn},
+		itemsRepo:      itemsRepository{conn},
+		stockRepo:      stockRepository{conn},
+		warehousesRepo: warehousesRepository{conn},
+		transfersRepo:  transfersRepository{conn},
+		salesRepo:      salesRepository{conn},
+		sellersRepo:    sellersRepository{conn},
+		policiesRepo:   policiesRepository{conn},
+		kvRepo:         kvRepository{conn},
+		twoFactorRepo:  twoFactorRepository{conn},
+		apiKeysRepo:    apiKeysRepository{conn},
+		auditRepo:      auditRepository{conn},
+		unitOfWork:     unitOfWork{conn},
 	}, nil
 }
 
+// BypassesTenancy reports whether the app connects as a superuser or a
+// role with BYPASSRLS, row level security does not apply to them.
+func (r RepositoryCombiner) BypassesTenancy(ctx context.Context) (bool, error) {
+	var bypass bool
+	err := r.ownersRepo.conn.QueryRow(ctx, "SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass)
+	return bypass, err
+}
+
 func (r RepositoryCombiner) Owners() ownersRepository {
 	return r.ownersRepo
 }
@@ -60,6 +97,54 @@ func (r RepositoryCombiner) Categories() categoriesRepository {
 	return r.categoriesRepo
 }
 
+func (r RepositoryCombiner) Items() itemsRepository {
+	return r.itemsRepo
+}
+
+func (r RepositoryCombiner) Stock() stockRepository {
+	return r.stockRepo
+}
+
+func (r RepositoryCombiner) Warehouses() warehousesRepository {
+	return r.warehousesRepo
+}
+
+func (r RepositoryCombiner) Transfers() transfersRepository {
+	return r.transfersRepo
+}
+
+func (r RepositoryCombiner) Sales() salesRepository {
+	return r.salesRepo
+}
+
+func (r RepositoryCombiner) Sellers() sellersRepository {
+	return r.sellersRepo
+}
+
+func (r RepositoryCombiner) Policies() policiesRepository {
+	return r.policiesRepo
+}
+
+func (r RepositoryCombiner) KeyValue() kvRepository {
+	return r.kvRepo
+}
+
+func (r RepositoryCombiner) TwoFactor() twoFactorRepository {
+	return r.twoFactorRepo
+}
+
+func (r RepositoryCombiner) APIKeys() apiKeysRepository {
+	return r.apiKeysRepo
+}
+
+func (r RepositoryCombiner) Audit() auditRepository {
+	return r.auditRepo
+}
+
+func (r RepositoryCombiner) UnitOfWork() unitOfWork {
+	return r.unitOfWork
+}
+
 func (r RepositoryCombiner) Close(ctx context.Context) error {
 	r.ownersRepo.conn.Close()
 	return nil
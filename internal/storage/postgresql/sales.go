@@ -0,0 +1,394 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sales"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type salesRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r salesRepository) Create(ctx context.Context, receipt entities.Receipt) (entities.Receipt, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"salesRepository.Create").End()
+
+	if receipt.Store == nil {
+		return entities.Receipt{}, errors.New("store is required")
+	}
+
+	var sellerID *uuid.UUID
+	if receipt.Seller != nil {
+		sellerID = &receipt.Seller.ID
+	}
+
+	err := pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		// size must belong to an item of the store and lie in a warehouse supplying it
+		const sizeSQL = `SELECT s.item_id, s.warehouse_id, s.size_number, s.size_symbol, i.name, i.article, i.price
+			FROM sizes s
+			JOIN items i ON i.id = s.item_id
+			WHERE s.id = $1 AND i.store_id = $2 AND EXISTS (
+				SELECT 1 FROM store_warehouses sw
+				WHERE sw.store_id = $2 AND sw.warehouse_id = s.warehouse_id
+			)`
+		for i := range receipt.Lines {
+			var (
+				l         = &receipt.Lines[i]
+				item      entities.Item
+				warehouse entities.Warehouse
+			)
+			err := tx.QueryRow(ctx, sizeSQL, l.Size.ID, receipt.Store.ID).Scan(
+				&item.ID, &warehouse.ID, &l.Size.SizeNumber, &l.Size.SizeSymbol, &item.Name, &item.Article, &item.Price,
+			)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return sales.ErrNotInStore
+			}
+			if err != nil {
+				return err
+			}
+			l.Item, l.Size.Warehouse = &item, &warehouse
+			l.Price = item.Price
+			if l.Discount > l.Price*float64(l.Quantity) {
+				return sales.ErrDiscountTooBig
+			}
+		}
+		receipt.CalculateTotal()
+
+		const insertReceipt = "INSERT INTO receipts (id, store_id, seller_id, total, created_at) VALUES ($1, $2, $3, $4, $5)"
+		_, err := tx.Exec(ctx, insertReceipt, receipt.ID, receipt.Store.ID, sellerID, receipt.Total, receipt.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		const insertLine = `INSERT INTO receipt_lines (receipt_id, item_id, size_id, quantity, price, discount, total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+		for i := range receipt.Lines {
+			l := &receipt.Lines[i]
+			err := tx.QueryRow(ctx, insertLine, receipt.ID, l.Item.ID, l.Size.ID, l.Quantity, l.Price, l.Discount, l.Total).Scan(&l.ID)
+			if err != nil {
+				return err
+			}
+
+			m, err := entities.NewMovement(l.Size, entities.MovementSale, l.Quantity, "sale")
+			if err != nil {
+				return err
+			}
+			m.ReferenceID = &receipt.ID
+			m, err = recordMovement(ctx, tx, m)
+			if err != nil {
+				if err == stock.ErrInsufficientStock {
+					return sales.ErrInsufficientStock
+				}
+				return err
+			}
+			l.Size.Quantity = m.Balance
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entities.Receipt{}, err
+	}
+
+	return receipt, nil
+}
+
+func whereReceipts(query sq.SelectBuilder, filters sales.ReadByInput) sq.SelectBuilder {
+	if val, ok := filters.OwnerID.Get(); ok {
+		query = query.Where(sq.Expr("store_id IN (SELECT id FROM stores WHERE owner_id = ?)", val))
+	}
+	if val, ok := filters.StoreIDs.Get(); ok {
+		query = query.Where(sq.Eq{"store_id": val})
+	}
+	if id, ok := filters.ID.Get(); ok {
+		return query.Where(sq.Eq{"id": id})
+	}
+
+	if val, ok := filters.StoreID.Get(); ok {
+		query = query.Where(sq.Eq{"store_id": val})
+	}
+	if val, ok := filters.SellerID.Get(); ok {
+		query = query.Where(sq.Eq{"seller_id": val})
+	}
+	if val, ok := filters.From.Get(); ok {
+		query = query.Where(sq.GtOrEq{"created_at": val})
+	}
+	if val, ok := filters.To.Get(); ok {
+		query = query.Where(sq.Lt{"created_at": val})
+	}
+	return query
+}
+
+func (r salesRepository) ReadBy(ctx context.Context, filters sales.ReadByInput) ([]entities.Receipt, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"salesRepository.ReadBy").End()
+
+	query := whereReceipts(sq.Select("id", "store_id", "seller_id", "total", "created_at").
+		From("receipts").
+		PlaceholderFormat(sq.Dollar), filters)
+
+	_, byID := filters.ID.Get()
+	if !byID {
+		pageSize, ok := filters.PageSize.Get()
+		if !ok {
+			pageSize = 10
+		}
+		page, ok := filters.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.
+			OrderBy("created_at desc").
+			Limit(uint64(pageSize)).
+			Offset((page - 1) * uint64(pageSize))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Receipt, 0)
+	for rows.Next() {
+		var (
+			receipt  entities.Receipt
+			store    entities.Store
+			sellerID *uuid.UUID
+		)
+		if err := rows.Scan(&receipt.ID, &store.ID, &sellerID, &receipt.Total, &receipt.CreatedAt); err != nil {
+			return nil, err
+		}
+		receipt.Store = &store
+		if sellerID != nil {
+			receipt.Seller = &entities.Seller{ID: *sellerID}
+		}
+		result = append(result, receipt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// lines are shown only for a single receipt
+	if byID && len(result) == 1 {
+		result[0].Lines, err = r.readLines(ctx, result[0].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r salesRepository) Count(ctx context.Context, filters sales.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"salesRepository.Count").End()
+
+	sql, args, err := whereReceipts(sq.Select("COUNT(*)").From("receipts").PlaceholderFormat(sq.Dollar), filters).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r salesRepository) readLines(ctx context.Context, receiptID uuid.UUID) ([]entities.ReceiptLine, error) {
+	const sql = `SELECT l.id, l.quantity, l.price, l.discount, l.total,
+			i.id, i.name, i.article, i.color,
+			s.id, s.warehouse_id, s.size_number, s.size_symbol
+		FROM receipt_lines l
+		JOIN items i ON i.id = l.item_id
+		JOIN sizes s ON s.id = l.size_id
+		WHERE l.receipt_id = $1
+		ORDER BY l.id`
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, receiptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]entities.ReceiptLine, 0)
+	for rows.Next() {
+		var (
+			l         entities.ReceiptLine
+			item      entities.Item
+			size      entities.Size
+			warehouse entities.Warehouse
+		)
+		err := rows.Scan(
+			&l.ID, &l.Quantity, &l.Price, &l.Discount, &l.Total,
+			&item.ID, &item.Name, &item.Article, &item.Color,
+			&size.ID, &warehouse.ID, &size.SizeNumber, &size.SizeSymbol,
+		)
+		if err != nil {
+			return nil, err
+		}
+		size.Warehouse = &warehouse
+		l.Item, l.Size = &item, &size
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r salesRepository) CreateReturn(ctx context.Context, ret entities.ReceiptReturn, receiptID uuid.UUID) (entities.ReceiptReturn, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"salesRepository.CreateReturn").End()
+
+	if ret.Line == nil || ret.Warehouse == nil {
+		return entities.ReceiptReturn{}, errors.New("line and warehouse are required")
+	}
+
+	var sellerID *uuid.UUID
+	if ret.Seller != nil {
+		sellerID = &ret.Seller.ID
+	}
+
+	err := pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		var (
+			line    = ret.Line
+			item    entities.Item
+			size    entities.Size
+			storeID uuid.UUID
+		)
+		// the line is locked so concurrent returns of it are serialized
+		const lineSQL = `SELECT l.quantity, l.price, l.discount, l.total, l.item_id, s.id, s.size_number, s.size_symbol, rc.store_id
+			FROM receipt_lines l
+			JOIN receipts rc ON rc.id = l.receipt_id
+			JOIN sizes s ON s.id = l.size_id
+			WHERE l.id = $1 AND l.receipt_id = $2
+			FOR UPDATE OF l`
+		err := tx.QueryRow(ctx, lineSQL, line.ID, receiptID).Scan(
+			&line.Quantity, &line.Price, &line.Discount, &line.Total, &item.ID, &size.ID, &size.SizeNumber, &size.SizeSymbol, &storeID,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sales.ErrLineNotFound
+		}
+		if err != nil {
+			return err
+		}
+		line.Item, line.Size = &item, &size
+
+		var (
+			returned int64
+			refunded float64
+		)
+		const returnedSQL = "SELECT COALESCE(SUM(quantity), 0), COALESCE(SUM(refund), 0) FROM receipt_returns WHERE line_id = $1"
+		if err := tx.QueryRow(ctx, returnedSQL, line.ID).Scan(&returned, &refunded); err != nil {
+			return err
+		}
+		if ret.Quantity > line.Quantity-returned {
+			return sales.ErrReturnTooMuch
+		}
+		refundable := line.Total - refunded
+		if ret.Refund < 0 {
+			if returned+ret.Quantity == line.Quantity {
+				// the last return takes the rest so rounding never loses cents
+				ret.Refund = refundable
+			} else {
+				ret.Refund = line.Total * float64(ret.Quantity) / float64(line.Quantity)
+			}
+			ret.Refund = math.Round(ret.Refund*100) / 100
+		}
+		if math.Round(ret.Refund*100) > math.Round(refundable*100) {
+			return sales.ErrRefundTooBig
+		}
+
+		var sameOwner bool
+		const warehouseSQL = `SELECT EXISTS (
+			SELECT 1 FROM warehouses w
+			JOIN stores st ON st.owner_id = w.owner_id
+			WHERE w.id = $1 AND st.id = $2
+		)`
+		if err := tx.QueryRow(ctx, warehouseSQL, ret.Warehouse.ID, storeID).Scan(&sameOwner); err != nil {
+			return err
+		}
+		if !sameOwner {
+			return sales.ErrWrongWarehouse
+		}
+
+		sizeID, err := findOrCreateSize(ctx, tx, item.ID, ret.Warehouse.ID, size.SizeNumber, size.SizeSymbol)
+		if err != nil {
+			return err
+		}
+
+		const insertReturn = `INSERT INTO receipt_returns (id, line_id, warehouse_id, size_id, seller_id, quantity, refund, reason, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		_, err = tx.Exec(ctx, insertReturn,
+			ret.ID, line.ID, ret.Warehouse.ID, sizeID, sellerID, ret.Quantity, ret.Refund, ret.Reason, ret.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		m, err := entities.NewMovement(&entities.Size{ID: sizeID}, entities.MovementReturn, ret.Quantity, ret.Reason)
+		if err != nil {
+			return err
+		}
+		m.ReferenceID = &ret.ID
+		_, err = recordMovement(ctx, tx, m)
+		return err
+	})
+	if err != nil {
+		return entities.ReceiptReturn{}, err
+	}
+
+	return ret, nil
+}
+
+func (r salesRepository) ReadReturns(ctx context.Context, receiptID string) ([]entities.ReceiptReturn, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"salesRepository.ReadReturns").End()
+
+	const sql = `SELECT rr.id, rr.quantity, rr.refund, rr.reason, rr.created_at, rr.warehouse_id, rr.seller_id,
+			l.id, l.quantity, l.price, l.discount, l.total, l.item_id, l.size_id
+		FROM receipt_returns rr
+		JOIN receipt_lines l ON l.id = rr.line_id
+		WHERE l.receipt_id = $1
+		ORDER BY rr.created_at`
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, receiptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.ReceiptReturn, 0)
+	for rows.Next() {
+		var (
+			ret       entities.ReceiptReturn
+			line      entities.ReceiptLine
+			item      entities.Item
+			size      entities.Size
+			warehouse entities.Warehouse
+			sellerID  *uuid.UUID
+		)
+		err := rows.Scan(
+			&ret.ID, &ret.Quantity, &ret.Refund, &ret.Reason, &ret.CreatedAt, &warehouse.ID, &sellerID,
+			&line.ID, &line.Quantity, &line.Price, &line.Discount, &line.Total, &item.ID, &size.ID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		line.Item, line.Size = &item, &size
+		ret.Line, ret.Warehouse = &line, &warehouse
+		if sellerID != nil {
+			ret.Seller = &entities.Seller{ID: *sellerID}
+		}
+		result = append(result, ret)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,176 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+// Methods below make sellersRepository an auth.SellersRepository.
+
+func (r sellersRepository) Read(ctx context.Context, id string) (entities.Seller, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.Read").End()
+
+	return r.readOne(ctx, "id", id)
+}
+
+func (r sellersRepository) ReadByUsername(ctx context.Context, username string) (entities.Seller, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.ReadByUsername").End()
+
+	return r.readOne(ctx, "username", username)
+}
+
+func (r sellersRepository) readOne(ctx context.Context, column, value string) (entities.Seller, error) {
+	sql := `SELECT id, owner_id, username, full_name, phone_number, is_active, created_at
+		FROM sellers WHERE ` + column + ` = $1`
+
+	var (
+		seller entities.Seller
+		owner  entities.Owner
+	)
+	err := db(ctx, r.conn).QueryRow(ctx, sql, value).Scan(
+		&seller.ID, &owner.ID, &seller.Username, &seller.FullName, &seller.PhoneNumber, &seller.IsActive, &seller.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Seller{}, auth.ErrSellerNotFound
+		}
+		return entities.Seller{}, err
+	}
+	seller.Owner = &owner
+
+	seller.Stores, err = r.readStores(ctx, seller.ID)
+	if err != nil {
+		return entities.Seller{}, err
+	}
+
+	return seller, nil
+}
+
+func (r sellersRepository) CreateLoginRequest(ctx context.Context, request entities.SellerLoginRequest) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.CreateLoginRequest").End()
+
+	if request.Seller == nil {
+		return errors.New("seller is required")
+	}
+
+	const sql = `INSERT INTO seller_login_requests (id, seller_id, code_hash, status, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db(ctx, r.conn).Exec(ctx, sql,
+		request.ID, request.Seller.ID, request.CodeHash, request.Status, request.ExpiresAt, request.CreatedAt,
+	)
+	return err
+}
+
+func (r sellersRepository) ReadLoginRequest(ctx context.Context, id string) (entities.SellerLoginRequest, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.ReadLoginRequest").End()
+
+	const sql = `SELECT id, seller_id, code_hash, attempts, status, expires_at, created_at
+		FROM seller_login_requests WHERE id = $1`
+
+	var (
+		request entities.SellerLoginRequest
+		seller  entities.Seller
+	)
+	err := db(ctx, r.conn).QueryRow(ctx, sql, id).Scan(
+		&request.ID, &seller.ID, &request.CodeHash, &request.Attempts, &request.Status, &request.ExpiresAt, &request.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.SellerLoginRequest{}, auth.ErrLoginRequestNotFound
+		}
+		return entities.SellerLoginRequest{}, err
+	}
+	request.Seller = &seller
+
+	return request, nil
+}
+
+func (r sellersRepository) ReadLoginRequests(ctx context.Context, ownerID string) ([]entities.SellerLoginRequest, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.ReadLoginRequests").End()
+
+	const sql = `SELECT lr.id, lr.status, lr.expires_at, lr.created_at,
+			se.id, se.username, se.full_name, se.phone_number, se.is_active, se.created_at
+		FROM seller_login_requests lr
+		JOIN sellers se ON se.id = lr.seller_id
+		WHERE se.owner_id = $1 AND lr.status = $2 AND lr.expires_at > NOW()
+		ORDER BY lr.created_at DESC`
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, ownerID, entities.SellerLoginPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.SellerLoginRequest, 0)
+	for rows.Next() {
+		var (
+			request entities.SellerLoginRequest
+			seller  entities.Seller
+		)
+		err := rows.Scan(
+			&request.ID, &request.Status, &request.ExpiresAt, &request.CreatedAt,
+			&seller.ID, &seller.Username, &seller.FullName, &seller.PhoneNumber, &seller.IsActive, &seller.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		request.Seller = &seller
+		result = append(result, request)
+	}
+
+	return result, rows.Err()
+}
+
+func (r sellersRepository) DecideLoginRequest(ctx context.Context, ownerID, id, status string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.DecideLoginRequest").End()
+
+	const sql = `UPDATE seller_login_requests lr SET status = $3
+		FROM sellers se
+		WHERE se.id = lr.seller_id AND lr.id = $1 AND se.owner_id = $2
+			AND lr.status = $4 AND lr.expires_at > NOW()`
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, id, ownerID, status, entities.SellerLoginPending)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrLoginRequestNotFound
+	}
+
+	return nil
+}
+
+func (r sellersRepository) UseLoginRequest(ctx context.Context, id, from string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.UseLoginRequest").End()
+
+	const sql = "UPDATE seller_login_requests SET status = $2 WHERE id = $1 AND status = $3"
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, id, entities.SellerLoginUsed, from)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrLoginRequestNotFound
+	}
+
+	return nil
+}
+
+func (r sellersRepository) UseLoginAttempt(ctx context.Context, id string, max int) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.UseLoginAttempt").End()
+
+	// checking and counting in one statement keeps parallel guesses within max
+	const sql = "UPDATE seller_login_requests SET attempts = attempts + 1 WHERE id = $1 AND attempts < $2 RETURNING attempts"
+
+	var attempts int
+	err := db(ctx, r.conn).QueryRow(ctx, sql, id, max).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.ErrTooManyAttempts
+	}
+	return err
+}
@@ -0,0 +1,270 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sellers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type sellersRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r sellersRepository) Create(ctx context.Context, seller entities.Seller) (entities.Seller, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.Create").End()
+
+	if seller.Owner == nil {
+		return entities.Seller{}, errors.New("owner is required")
+	}
+
+	sql, args, err := sq.Insert("sellers").
+		Columns("id", "owner_id", "username", "full_name", "phone_number", "is_active", "created_at").
+		Values(seller.ID, seller.Owner.ID, seller.Username, seller.FullName, seller.PhoneNumber, seller.IsActive, seller.CreatedAt).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return entities.Seller{}, err
+	}
+
+	if _, err := db(ctx, r.conn).Exec(ctx, sql, args...); err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return entities.Seller{}, sellers.ErrUsernameTaken
+		}
+		return entities.Seller{}, err
+	}
+
+	return seller, nil
+}
+
+var sellerSortingFields = map[string]string{
+	sellers.SortByCreatedAt: "sellers.created_at",
+	sellers.SortByFullName:  "sellers.full_name",
+}
+
+func whereSellers(query sq.SelectBuilder, filter sellers.ReadByInput) sq.SelectBuilder {
+	if id, ok := filter.ID.Get(); ok {
+		return query.Where(sq.Eq{"id": id})
+	}
+
+	if val, ok := filter.OwnerID.Get(); ok {
+		query = query.Where(sq.Eq{"owner_id": val})
+	}
+	if val, ok := filter.StoreID.Get(); ok {
+		query = query.Where(sq.Expr(
+			"EXISTS (SELECT 1 FROM seller_stores ss WHERE ss.seller_id = sellers.id AND ss.store_id = ?)", val,
+		))
+	}
+	if val, ok := filter.IsActive.Get(); ok {
+		query = query.Where(sq.Eq{"is_active": val})
+	}
+	return query
+}
+
+func (r sellersRepository) ReadBy(ctx context.Context, filter sellers.ReadByInput) ([]entities.Seller, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.ReadBy").End()
+
+	query := whereSellers(sq.Select("id", "owner_id", "username", "full_name", "phone_number", "is_active", "created_at").
+		From("sellers").
+		PlaceholderFormat(sq.Dollar), filter)
+
+	_, byID := filter.ID.Get()
+	if !byID {
+		sortBy, ok := filter.SortBy.Get()
+		if ok {
+			sortBy, ok := sellerSortingFields[sortBy]
+			if !ok {
+				sortBy = "sellers.created_at"
+			}
+			sortOrder, ok := filter.SortOrder.Get()
+			if !ok {
+				sortOrder = sellers.SortOrderAsc
+			}
+			query = query.OrderBy(sortBy + " " + sortOrder)
+		} else {
+			query = query.OrderBy("sellers.created_at desc")
+		}
+
+		pageSize, ok := filter.PageSize.Get()
+		if !ok {
+			pageSize = 10
+		}
+		page, ok := filter.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.Limit(uint64(pageSize)).Offset((page - 1) * uint64(pageSize))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Seller, 0)
+	for rows.Next() {
+		var (
+			seller entities.Seller
+			owner  entities.Owner
+		)
+		err := rows.Scan(&seller.ID, &owner.ID, &seller.Username, &seller.FullName, &seller.PhoneNumber, &seller.IsActive, &seller.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		seller.Owner = &owner
+		result = append(result, seller)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// a single seller is requested, so it is cheap to show its stores too
+	if byID && len(result) == 1 {
+		result[0].Stores, err = r.readStores(ctx, result[0].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r sellersRepository) Count(ctx context.Context, filter sellers.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.Count").End()
+
+	sql, args, err := whereSellers(sq.Select("COUNT(*)").From("sellers").PlaceholderFormat(sq.Dollar), filter).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r sellersRepository) readStores(ctx context.Context, sellerID uuid.UUID) ([]entities.Store, error) {
+	const sql = `SELECT s.id, s.name, s.description, s.created_at FROM stores s
+		JOIN seller_stores ss ON ss.store_id = s.id
+		WHERE ss.seller_id = $1
+		ORDER BY s.name`
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := make([]entities.Store, 0)
+	for rows.Next() {
+		var s entities.Store
+		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		stores = append(stores, s)
+	}
+
+	return stores, rows.Err()
+}
+
+func (r sellersRepository) SetActive(ctx context.Context, ownerID, id string, active bool) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.SetActive").End()
+
+	sql, args, err := sq.Update("sellers").
+		Set("is_active", active).
+		Where(sq.Eq{"id": id, "owner_id": ownerID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return sellers.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r sellersRepository) Delete(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.Delete").End()
+
+	sql, args, err := sq.Delete("sellers").
+		Where(sq.Eq{"id": id, "owner_id": ownerID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, args...)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return sellers.ErrHasReceipts
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return sellers.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r sellersRepository) AssignStore(ctx context.Context, ownerID, sellerID, storeID string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.AssignStore").End()
+
+	// assignment is only created when both belong to the owner
+	const sql = `INSERT INTO seller_stores (seller_id, store_id)
+		SELECT se.id, st.id FROM sellers se
+		JOIN stores st ON st.owner_id = se.owner_id
+		WHERE se.id = $1 AND st.id = $2 AND se.owner_id = $3
+		ON CONFLICT DO NOTHING
+		RETURNING seller_id`
+
+	var assigned string
+	err := db(ctx, r.conn).QueryRow(ctx, sql, sellerID, storeID, ownerID).Scan(&assigned)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// either assignment already exists or owners differ
+			var exists bool
+			const check = `SELECT EXISTS (
+				SELECT 1 FROM seller_stores ss
+				JOIN sellers se ON se.id = ss.seller_id
+				WHERE ss.seller_id = $1 AND ss.store_id = $2 AND se.owner_id = $3
+			)`
+			if err := db(ctx, r.conn).QueryRow(ctx, check, sellerID, storeID, ownerID).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return nil
+			}
+			return sellers.ErrStoreMismatch
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r sellersRepository) UnassignStore(ctx context.Context, ownerID, sellerID, storeID string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"sellersRepository.UnassignStore").End()
+
+	const sql = `DELETE FROM seller_stores ss
+		USING sellers se
+		WHERE se.id = ss.seller_id AND ss.seller_id = $1 AND ss.store_id = $2 AND se.owner_id = $3`
+
+	_, err := db(ctx, r.conn).Exec(ctx, sql, sellerID, storeID, ownerID)
+	return err
+}
@@ -0,0 +1,379 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type stockRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r stockRepository) Create(ctx context.Context, size entities.Size) (entities.Size, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.Create").End()
+
+	if size.Item == nil || size.Warehouse == nil {
+		return entities.Size{}, errors.New("item and warehouse are required")
+	}
+
+	// quantity is never written directly, opening quantity is recorded as a receipt
+	quantity := size.Quantity
+	sql, args, err := sq.Insert("sizes").
+		Columns("item_id", "warehouse_id", "size_number", "size_symbol", "quantity", "cost", "created_at").
+		Values(size.Item.ID, size.Warehouse.ID, size.SizeNumber, size.SizeSymbol, 0, size.Cost, size.CreatedAt).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return entities.Size{}, err
+	}
+
+	err = pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, sql, args...).Scan(&size.ID); err != nil {
+			return err
+		}
+		size.Quantity = 0
+		if quantity == 0 {
+			return nil
+		}
+
+		m, err := entities.NewMovement(&size, entities.MovementReceipt, quantity, "opening balance")
+		if err != nil {
+			return err
+		}
+		m, err = recordMovement(ctx, tx, m)
+		if err != nil {
+			return err
+		}
+		size.Quantity = m.Balance
+		return nil
+	})
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return entities.Size{}, stock.ErrSizeExists
+		}
+		if isPgError(err, pgCheckViolation) {
+			return entities.Size{}, entities.ErrSizeExclusive
+		}
+		return entities.Size{}, err
+	}
+
+	return size, nil
+}
+
+func whereSizes(query sq.SelectBuilder, filters stock.ReadByInput) sq.SelectBuilder {
+	if id, ok := filters.ID.Get(); ok {
+		return query.Where(sq.Eq{"sizes.id": id})
+	}
+
+	itemID, ok := filters.ItemID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"sizes.item_id": itemID})
+	}
+	warehouseID, ok := filters.WarehouseID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"sizes.warehouse_id": warehouseID})
+	}
+	return query
+}
+
+func (r stockRepository) ReadBy(ctx context.Context, filters stock.ReadByInput) ([]entities.Size, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.ReadBy").End()
+
+	query := whereSizes(sq.Select(
+		"sizes.id", "sizes.warehouse_id", "sizes.size_number", "sizes.size_symbol", "sizes.quantity", "sizes.cost", "sizes.created_at",
+		"items.id", "items.name", "items.article", "items.color", "items.price",
+	).
+		From("sizes").
+		Join("items ON items.id = sizes.item_id").
+		PlaceholderFormat(sq.Dollar), filters)
+
+	_, byID := filters.ID.Get()
+	if !byID {
+		pageSize, ok := filters.PageSize.Get()
+		if !ok {
+			pageSize = 50
+		}
+		page, ok := filters.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.
+			OrderBy("items.name asc", "sizes.id asc").
+			Limit(uint64(pageSize)).
+			Offset((page - 1) * uint64(pageSize))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Size, 0)
+	for rows.Next() {
+		var (
+			size      entities.Size
+			item      entities.Item
+			warehouse entities.Warehouse
+		)
+		err := rows.Scan(
+			&size.ID,
+			&warehouse.ID,
+			&size.SizeNumber,
+			&size.SizeSymbol,
+			&size.Quantity,
+			&size.Cost,
+			&size.CreatedAt,
+			&item.ID,
+			&item.Name,
+			&item.Article,
+			&item.Color,
+			&item.Price,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		size.Item = &item
+		size.Warehouse = &warehouse
+		result = append(result, size)
+	}
+	return result, rows.Err()
+}
+
+func (r stockRepository) Count(ctx context.Context, filters stock.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.Count").End()
+
+	sql, args, err := whereSizes(sq.Select("COUNT(*)").From("sizes").PlaceholderFormat(sq.Dollar), filters).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r stockRepository) Update(ctx context.Context, id int64, changeset stock.UpdateInput) (entities.Size, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.Update").End()
+
+	query := sq.Update("sizes").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	if val, ok := changeset.Cost.Get(); ok {
+		query = query.Set("cost", val)
+	}
+
+	sql, args, err := query.
+		Suffix(`RETURNING "id", "item_id", "warehouse_id", "size_number", "size_symbol", "quantity", "cost", "created_at"`).
+		ToSql()
+	if err != nil {
+		return entities.Size{}, err
+	}
+
+	var (
+		size      entities.Size
+		item      entities.Item
+		warehouse entities.Warehouse
+	)
+	err = db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(
+		&size.ID,
+		&item.ID,
+		&warehouse.ID,
+		&size.SizeNumber,
+		&size.SizeSymbol,
+		&size.Quantity,
+		&size.Cost,
+		&size.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Size{}, stock.ErrNotFound
+		}
+		return entities.Size{}, err
+	}
+
+	size.Item = &item
+	size.Warehouse = &warehouse
+	return size, nil
+}
+
+func (r stockRepository) Delete(ctx context.Context, id int64) error {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.Delete").End()
+
+	sql, args, err := sq.Delete("sizes").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db(ctx, r.conn).Exec(ctx, sql, args...)
+	if isPgError(err, pgForeignKeyViolation) {
+		return stock.ErrHasMovements
+	}
+	return err
+}
+
+func (r stockRepository) Move(ctx context.Context, movement entities.Movement) (entities.Movement, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.Move").End()
+
+	err := pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		var err error
+		movement, err = recordMovement(ctx, tx, movement)
+		return err
+	})
+	if err != nil {
+		return entities.Movement{}, err
+	}
+
+	return movement, nil
+}
+
+// whereMovements filters a query over stock_movements m joined with sizes s.
+func whereMovements(query sq.SelectBuilder, filters stock.MovementsReadByInput) sq.SelectBuilder {
+	if itemID, ok := filters.ItemID.Get(); ok {
+		query = query.Where(sq.Eq{"s.item_id": itemID})
+	}
+	if sizeID, ok := filters.SizeID.Get(); ok {
+		query = query.Where(sq.Eq{"m.size_id": sizeID})
+	}
+	return query
+}
+
+func (r stockRepository) ReadMovements(ctx context.Context, filters stock.MovementsReadByInput) ([]entities.Movement, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.ReadMovements").End()
+
+	// running balance has to be calculated over the whole history
+	// of a size, so pagination is applied only after the window function
+	journal := sq.Select(
+		"m.id", "m.size_id", "m.type", "m.quantity", "m.reference_id", "m.comment", "m.created_at",
+		"SUM(m.quantity) OVER (PARTITION BY m.size_id ORDER BY m.id) AS balance",
+		"s.item_id", "s.warehouse_id", "s.size_number", "s.size_symbol",
+	).
+		From("stock_movements m").
+		Join("sizes s ON s.id = m.size_id")
+	journal = whereMovements(journal, filters)
+
+	pageSize, ok := filters.PageSize.Get()
+	if !ok {
+		pageSize = 50
+	}
+	page, ok := filters.PageNumber.Get()
+	if !ok {
+		page = 1
+	}
+
+	sql, args, err := sq.Select("*").
+		FromSelect(journal, "journal").
+		OrderBy("id desc").
+		Limit(uint64(pageSize)).
+		Offset((page - 1) * uint64(pageSize)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Movement, 0)
+	for rows.Next() {
+		var (
+			m         entities.Movement
+			size      entities.Size
+			item      entities.Item
+			warehouse entities.Warehouse
+		)
+		err := rows.Scan(
+			&m.ID, &size.ID, &m.Type, &m.Quantity, &m.ReferenceID, &m.Comment, &m.CreatedAt,
+			&m.Balance,
+			&item.ID, &warehouse.ID, &size.SizeNumber, &size.SizeSymbol,
+		)
+		if err != nil {
+			return nil, err
+		}
+		size.Item = &item
+		size.Warehouse = &warehouse
+		m.Size = &size
+		result = append(result, m)
+	}
+
+	return result, rows.Err()
+}
+
+func (r stockRepository) CountMovements(ctx context.Context, filters stock.MovementsReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"stockRepository.CountMovements").End()
+
+	sql, args, err := whereMovements(sq.Select("COUNT(*)").
+		From("stock_movements m").
+		Join("sizes s ON s.id = m.size_id").
+		PlaceholderFormat(sq.Dollar), filters).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+// recordMovement appends the movement to the journal and applies it to the
+// materialized quantity of the size. It must be called inside a transaction.
+func recordMovement(ctx context.Context, tx pgx.Tx, m entities.Movement) (entities.Movement, error) {
+	if m.Size == nil {
+		return entities.Movement{}, errors.New("size is required")
+	}
+
+	// row lock taken by UPDATE serializes concurrent movements of the same size
+	const updateSQL = "UPDATE sizes SET quantity = quantity + $2 WHERE id = $1 RETURNING quantity"
+	if err := tx.QueryRow(ctx, updateSQL, m.Size.ID, m.Quantity).Scan(&m.Balance); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Movement{}, stock.ErrNotFound
+		}
+		if isPgError(err, pgCheckViolation) {
+			return entities.Movement{}, stock.ErrInsufficientStock
+		}
+		return entities.Movement{}, err
+	}
+
+	const insertSQL = `INSERT INTO stock_movements (size_id, type, quantity, reference_id, comment, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := tx.QueryRow(ctx, insertSQL, m.Size.ID, m.Type, m.Quantity, m.ReferenceID, m.Comment, m.CreatedAt).Scan(&m.ID)
+	if err != nil {
+		return entities.Movement{}, err
+	}
+
+	return m, nil
+}
+
+// findOrCreateSize returns id of the size of the item in the warehouse,
+// creating an empty one if the warehouse never had it.
+// It must be called inside a transaction.
+func findOrCreateSize(ctx context.Context, tx pgx.Tx, itemID, warehouseID uuid.UUID, sizeNumber, sizeSymbol *string) (int64, error) {
+	// DO UPDATE instead of DO NOTHING, so that RETURNING works for existing rows too
+	const sql = `INSERT INTO sizes (item_id, warehouse_id, size_number, size_symbol)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id, warehouse_id, COALESCE(size_number, ''), COALESCE(size_symbol, ''))
+		DO UPDATE SET item_id = EXCLUDED.item_id
+		RETURNING id`
+
+	var id int64
+	err := tx.QueryRow(ctx, sql, itemID, warehouseID, sizeNumber, sizeSymbol).Scan(&id)
+	return id, err
+}
@@ -7,6 +7,7 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
 	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
@@ -40,7 +41,7 @@ func (r storesRepository) Create(ctx context.Context, store entities.Store) (ent
 		return entities.Store{}, err
 	}
 
-	row := r.conn.QueryRow(ctx, sql, args...)
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
 	if err := row.Scan(&store.ID); err != nil {
 		return entities.Store{}, err
 	}
@@ -48,18 +49,18 @@ func (r storesRepository) Create(ctx context.Context, store entities.Store) (ent
 	return store, nil
 }
 
-var storeSortingFields = map[string]string{
-	stores.SortByCreatedAt: "stores.created_at",
-	stores.SortByName:      "stores.name",
+var storeSortingFields = map[string]sortField{
+	stores.SortByCreatedAt: {[]string{"stores.created_at"}, timeKey},
+	stores.SortByName:      {[]string{"stores.name"}, textKey},
 }
 
-func (r storesRepository) ReadBy(ctx context.Context, filter stores.ReadByInput) ([]entities.Store, error) {
-	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.ReadBy").End()
-
-	query := sq.Select("stores.id", "owner_id", "owners.full_name", "owners.username", "owners.created_at", "name", "description", "stores.created_at").
-		LeftJoin("owners ON owners.id = stores.owner_id").
-		From("stores").
-		PlaceholderFormat(sq.Dollar)
+// whereStores filters query by everything in filter but pagination.
+func whereStores(query sq.SelectBuilder, filter stores.ReadByInput) sq.SelectBuilder {
+	if deleted, _ := filter.Deleted.Get(); deleted {
+		query = query.Where("stores.deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("stores.deleted_at IS NULL")
+	}
 
 	val, ok := filter.ID.Get()
 	if !ok {
@@ -68,6 +69,11 @@ func (r storesRepository) ReadBy(ctx context.Context, filter stores.ReadByInput)
 			query = query.Where(sq.Eq{"owner_id": val})
 		}
 
+		ids, ok := filter.IDs.Get()
+		if ok {
+			query = query.Where(sq.Eq{"stores.id": ids})
+		}
+
 		val, ok = filter.Text.Get()
 		if ok {
 			// full text search on 'tsv' column
@@ -76,41 +82,55 @@ func (r storesRepository) ReadBy(ctx context.Context, filter stores.ReadByInput)
 	} else {
 		query = query.Where(sq.Eq{"stores.id": val})
 	}
+	return query
+}
 
-	sortBy, ok := filter.SortBy.Get()
-	if ok {
-		sortBy, ok := storeSortingFields[sortBy]
-		if !ok {
-			// TODO: return error or something
-			sortBy = "stores.created_at"
-		}
-		sortOrder, ok := filter.SortOrder.Get()
-		if !ok {
-			sortOrder = "asc"
+func (r storesRepository) ReadBy(ctx context.Context, filter stores.ReadByInput) ([]entities.Store, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.ReadBy").End()
+
+	query := sq.Select("stores.id", "owner_id", "owners.full_name", "owners.username", "owners.created_at", "name", "description", "stores.created_at", "stores.deleted_at").
+		LeftJoin("owners ON owners.id = stores.owner_id").
+		From("stores").
+		PlaceholderFormat(sq.Dollar)
+	query = whereStores(query, filter)
+
+	field, sortOrder := storeSortingFields[stores.SortByCreatedAt], "desc"
+	if sortBy, ok := filter.SortBy.Get(); ok {
+		if f, ok := storeSortingFields[sortBy]; ok {
+			field = f
 		}
-		query = query.OrderBy(sortBy + " " + sortOrder)
-	} else {
-		query = query.OrderBy("stores.created_at desc")
+		sortOrder, _ = filter.SortOrder.Get()
+	}
+
+	var cursor *entities.Cursor
+	if c, ok := filter.Cursor.Get(); ok {
+		cursor = &c
+	}
+	query, err := keyset(query, field, "stores.id", sortOrder, cursor)
+	if err != nil {
+		return nil, err
 	}
 
 	pageSize, ok := filter.PageSize.Get()
 	if !ok {
 		pageSize = 10
 	}
+	query = query.Limit(uint64(pageSize))
 
-	page, ok := filter.PageNumber.Get()
-	if !ok {
-		page = 1
+	if cursor == nil {
+		page, ok := filter.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.Offset(uint64((page - 1) * uint64(pageSize)))
 	}
 
-	query = query.Limit(uint64(pageSize)).Offset(uint64((page - 1) * uint64(pageSize)))
-
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := r.conn.Query(ctx, sql, args...)
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -120,65 +140,196 @@ func (r storesRepository) ReadBy(ctx context.Context, filter stores.ReadByInput)
 	for rows.Next() {
 		var store entities.Store
 		var owner entities.Owner
-		if err := rows.Scan(&store.ID, &owner.ID, &owner.FullName, &owner.Username, &owner.CreatedAt, &store.Name, &store.Description, &store.CreatedAt); err != nil {
+		if err := rows.Scan(&store.ID, &owner.ID, &owner.FullName, &owner.Username, &owner.CreatedAt, &store.Name, &store.Description, &store.CreatedAt, &store.DeletedAt); err != nil {
 			return nil, err
 		}
 		store.Owner = &owner
 		stores = append(stores, store)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if cursor != nil && cursor.Backward {
+		reverse(stores)
+	}
+
+	// a single store is requested, so it is cheap to show its warehouses and sellers too
+	if _, ok := filter.ID.Get(); ok && len(stores) == 1 {
+		stores[0].Warehouses, err = r.readWarehouses(ctx, stores[0].ID)
+		if err != nil {
+			return nil, err
+		}
+		stores[0].Sellers, err = r.readSellers(ctx, stores[0].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return stores, nil
 }
 
+func (r storesRepository) Count(ctx context.Context, filter stores.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.Count").End()
+
+	sql, args, err := whereStores(sq.Select("COUNT(*)").From("stores").PlaceholderFormat(sq.Dollar), filter).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r storesRepository) readWarehouses(ctx context.Context, storeID uuid.UUID) ([]entities.Warehouse, error) {
+	const sql = `SELECT w.id, w.name, w.description, w.created_at FROM warehouses w
+		JOIN store_warehouses sw ON sw.warehouse_id = w.id
+		WHERE sw.store_id = $1
+		ORDER BY w.name`
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	warehouses := make([]entities.Warehouse, 0)
+	for rows.Next() {
+		var w entities.Warehouse
+		if err := rows.Scan(&w.ID, &w.Name, &w.Description, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+
+	return warehouses, rows.Err()
+}
+
+func (r storesRepository) readSellers(ctx context.Context, storeID uuid.UUID) ([]entities.Seller, error) {
+	const sql = `SELECT se.id, se.username, se.full_name, se.phone_number, se.is_active, se.created_at FROM sellers se
+		JOIN seller_stores ss ON ss.seller_id = se.id
+		WHERE ss.store_id = $1
+		ORDER BY se.full_name`
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sellers := make([]entities.Seller, 0)
+	for rows.Next() {
+		var s entities.Seller
+		if err := rows.Scan(&s.ID, &s.Username, &s.FullName, &s.PhoneNumber, &s.IsActive, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		sellers = append(sellers, s)
+	}
+
+	return sellers, rows.Err()
+}
+
 func (r storesRepository) Update(ctx context.Context, id string, changeset stores.UpdateInput) (entities.Store, error) {
 	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.Update").End()
 
-	store := entities.Store{}
 	query := sq.Update("stores").
-		Where(sq.Eq{"id": id})
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING id, owner_id, name, description, created_at")
 
-	val, ok := changeset.Name.Get()
-	if ok {
+	var name, description *string
+	if val, ok := changeset.Name.Get(); ok {
 		query = query.Set("name", val)
-		store.Name = val
+		name = &val
 	}
-
-	val, ok = changeset.Description.Get()
-	if ok {
+	if val, ok := changeset.Description.Get(); ok {
 		query = query.Set("description", val)
-		store.Description = val
+		description = &val
 	}
 
-	if ok { // if at least one of the fields is changed
-		query = query.Set("tsv", sq.Expr(
-			`setweight(to_tsvector(name), 'A') || setweight(to_tsvector(description), 'B')`,
-		))
-	}
+	// columns still hold old values here, so tsv is built from new ones
+	query = query.Set("tsv", sq.Expr(
+		`setweight(to_tsvector(COALESCE(?, name)), 'A') || setweight(to_tsvector(COALESCE(?, description)), 'B')`,
+		name, description,
+	))
 
 	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
 	if err != nil {
 		return entities.Store{}, err
 	}
 
-	_, err = r.conn.Exec(ctx, sql, args...)
-	if err != nil {
+	var (
+		store entities.Store
+		owner entities.Owner
+	)
+	row := db(ctx, r.conn).QueryRow(ctx, sql, args...)
+	if err := row.Scan(&store.ID, &owner.ID, &store.Name, &store.Description, &store.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Store{}, stores.ErrNotFound
+		}
 		return entities.Store{}, err
 	}
-	store.ID = uuid.MustParse(id)
+	store.Owner = &owner
 
 	return store, nil
 }
 
+// Delete moves store to trash together with its live categories. They
+// share deleted_at, so that Restore brings back exactly them.
 func (r storesRepository) Delete(ctx context.Context, id string) error {
 	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.Delete").End()
 
-	sql, args, err := sq.Delete("stores").
-		Where(sq.Eq{"id": id}).
-		PlaceholderFormat(sq.Dollar).ToSql()
-	if err != nil {
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE stores SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL", id, deletedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		_, err = tx.Exec(ctx, "UPDATE categories SET deleted_at = $2 WHERE store_id = $1 AND deleted_at IS NULL", id, deletedAt)
 		return err
-	}
+	})
+}
 
-	_, err = r.conn.Exec(ctx, sql, args...)
-	return err
+// Restore takes store out of trash with categories deleted together with it.
+func (r storesRepository) Restore(ctx context.Context, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.Restore").End()
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		var deletedAt *time.Time
+		err := tx.QueryRow(ctx, "SELECT deleted_at FROM stores WHERE id = $1 FOR UPDATE", id).Scan(&deletedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return stores.ErrNotFound
+			}
+			return err
+		}
+		if deletedAt == nil {
+			return stores.ErrNotFound
+		}
+
+		if _, err := tx.Exec(ctx, "UPDATE stores SET deleted_at = NULL WHERE id = $1", id); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "UPDATE categories SET deleted_at = NULL WHERE store_id = $1 AND deleted_at = $2", id, *deletedAt)
+		return err
+	})
+}
+
+// Purge removes stores deleted before deletedBefore. Stores that still
+// have categories, items or receipts stay in trash, sales history must
+// not be lost. Categories are purged separately and before stores.
+func (r storesRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"storesRepository.Purge").End()
+
+	const sql = `DELETE FROM stores s WHERE s.deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM categories c WHERE c.store_id = s.id)
+		AND NOT EXISTS (SELECT 1 FROM items i WHERE i.store_id = s.id)
+		AND NOT EXISTS (SELECT 1 FROM receipts r WHERE r.store_id = s.id)`
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, deletedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
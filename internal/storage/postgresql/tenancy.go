@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+)
+
+// tenantSetting is the session variable row level security policies
+// compare owner_id with, see migration "tenant_isolation". Empty value
+// hides data of every owner.
+const tenantSetting = "app.owner_id"
+
+// systemSetting is "on" when the connection works for the system and
+// sees every owner, see tenancy.WithSystem.
+const systemSetting = "app.system"
+
+// withTenancy makes the pool set tenantSetting to owner of the context
+// every connection is acquired with. A transaction holds one connection,
+// so everything in a unit of work runs as the same owner.
+func withTenancy(c *pgxpool.Config, log *logging.Logger) {
+	c.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		return setTenant(ctx, conn, log)
+	}
+}
+
+// setTenant runs on every acquire, so that a connection never keeps the
+// owner or system access of a previous request.
+func setTenant(ctx context.Context, conn *pgx.Conn, log *logging.Logger) bool {
+	ownerID, ok := tenancy.OwnerFrom(ctx)
+	system := ""
+	if !ok {
+		if reason, ok := tenancy.SystemFrom(ctx); ok {
+			system = "on"
+			log.Info("postgresql:setTenant - system access", logging.String("stage", "tenancy"), logging.String("reason", reason))
+		}
+	}
+	_, err := conn.Exec(ctx, "SELECT set_config($1, $2, false), set_config($3, $4, false)", tenantSetting, ownerID, systemSetting, system)
+	// on error pool drops the connection and tries another one
+	return err == nil
+}
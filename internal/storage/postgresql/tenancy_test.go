@@ -0,0 +1,289 @@
+package postgresql
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/config"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sellers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
+)
+
+// tenantTestRole is used when TEST_DATABASE_URL connects as a superuser,
+// row level security does not apply to them.
+const tenantTestRole = "accounter_tenancy_test"
+
+// TestTenantIsolation needs a database, set TEST_DATABASE_URL to run it.
+// Data is created by the system and then read by owners through a pool
+// configured like the one of NewRepositories.
+func TestTenantIsolation(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	log, err := logging.NewLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{DatabaseURL: url}
+	cfg.Flags.WithMigrations = true
+
+	ctx := systemContext()
+	system, err := NewRepositories(ctx, cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { system.Close(ctx) })
+
+	pool := tenantPool(t, system.storesRepo.conn, url)
+	tenantStores := storesRepository{pool}
+	tenantCategories := categoriesRepository{pool}
+	tenantStock := stockRepository{pool}
+	tenantSellers := sellersRepository{pool}
+
+	alice, aliceStore, _ := createTenant(t, system)
+	bob, bobStore, bobCategory := createTenant(t, system)
+	_, bobSize := createStockedOwner(t, system)
+	asAlice := tenancy.WithOwner(ctx, alice.ID.String())
+
+	t.Run("ReadOwnStore", func(t *testing.T) {
+		list, err := tenantStores.ReadBy(asAlice, storesByID(aliceStore.ID))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 1 {
+			t.Errorf("ReadBy: got %d stores, want 1", len(list))
+		}
+	})
+
+	t.Run("ReadForeignStore", func(t *testing.T) {
+		list, err := tenantStores.ReadBy(asAlice, storesByID(bobStore.ID))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("ReadBy: got store of another owner %+v", list)
+		}
+	})
+
+	t.Run("ReadForeignStoreInUnitOfWork", func(t *testing.T) {
+		err := unitOfWork{pool}.Do(asAlice, func(ctx context.Context) error {
+			list, err := tenantStores.ReadBy(ctx, storesByID(bobStore.ID))
+			if err != nil {
+				return err
+			}
+			if len(list) != 0 {
+				t.Errorf("ReadBy: got store of another owner %+v", list)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	})
+
+	t.Run("ReadForeignCategory", func(t *testing.T) {
+		var filter categories.ReadByInput
+		filter.ID.Set(bobCategory.ID.String())
+		list, err := tenantCategories.ReadBy(asAlice, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("ReadBy: got category of another owner %+v", list)
+		}
+	})
+
+	t.Run("ReadForeignSize", func(t *testing.T) {
+		var filter stock.ReadByInput
+		filter.ID.Set(bobSize.ID)
+		list, err := tenantStock.ReadBy(asAlice, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("ReadBy: got size of another owner %+v", list)
+		}
+
+		var movements stock.MovementsReadByInput
+		movements.SizeID.Set(bobSize.ID)
+		journal, err := tenantStock.ReadMovements(asAlice, movements)
+		if err != nil {
+			t.Fatalf("ReadMovements: %v", err)
+		}
+		if len(journal) != 0 {
+			t.Errorf("ReadMovements: got movements of another owner %+v", journal)
+		}
+	})
+
+	t.Run("ReadForeignSeller", func(t *testing.T) {
+		seller := entities.NewSeller(&entities.Owner{ID: bob.ID}, "seller_"+uuid.NewString(), "Tenant Seller", "")
+		seller, err := system.Sellers().Create(ctx, seller)
+		if err != nil {
+			t.Fatalf("could not create seller: %v", err)
+		}
+
+		var filter sellers.ReadByInput
+		filter.ID.Set(seller.ID.String())
+		list, err := tenantSellers.ReadBy(asAlice, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("ReadBy: got seller of another owner %+v", list)
+		}
+	})
+
+	t.Run("UpdateForeignStore", func(t *testing.T) {
+		var changeset stores.UpdateInput
+		changeset.Name.Set("Taken Over")
+		if _, err := tenantStores.Update(asAlice, bobStore.ID.String(), changeset); err != stores.ErrNotFound {
+			t.Errorf("Update: got %v, want %v", err, stores.ErrNotFound)
+		}
+	})
+
+	t.Run("CreateInForeignStore", func(t *testing.T) {
+		_, err := tenantCategories.Create(asAlice, entities.Category{
+			Store: &entities.Store{ID: bobStore.ID},
+			Name:  "Planted",
+		})
+		if err == nil {
+			t.Error("Create: category in store of another owner was created")
+		}
+	})
+
+	t.Run("SystemReadsEveryone", func(t *testing.T) {
+		list, err := tenantStores.ReadBy(ctx, storesByID(bobStore.ID))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 1 {
+			t.Errorf("ReadBy: got %d stores, want 1", len(list))
+		}
+	})
+
+	// a request that forgot to set its owner must not see everyone
+	t.Run("NoTenantReadsNothing", func(t *testing.T) {
+		list, err := tenantStores.ReadBy(context.Background(), storesByID(bobStore.ID))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("ReadBy: got store without tenant %+v", list)
+		}
+	})
+}
+
+// systemContext is the context tests create and remove data with, like
+// background jobs it sees every owner.
+func systemContext() context.Context {
+	return tenancy.WithSystem(context.Background(), "tests")
+}
+
+// tenantPool connects to url as a role row level security applies to.
+func tenantPool(t *testing.T, admin *pgxpool.Pool, url string) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+
+	var super, bypass bool
+	err := admin.QueryRow(ctx, "SELECT rolsuper, rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&super, &bypass)
+	if err != nil {
+		t.Fatalf("could not read current role: %v", err)
+	}
+	if bypass && !super {
+		t.Skip("TEST_DATABASE_URL role bypasses row level security")
+	}
+
+	log, err := logging.NewLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withTenancy(c, log)
+
+	if super {
+		_, err := admin.Exec(ctx, `DO $$ BEGIN
+			CREATE ROLE `+tenantTestRole+` NOLOGIN;
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`)
+		if err != nil {
+			t.Fatalf("could not create role: %v", err)
+		}
+		if _, err := admin.Exec(ctx, "GRANT USAGE ON SCHEMA public TO "+tenantTestRole); err != nil {
+			t.Fatalf("could not grant schema: %v", err)
+		}
+		if _, err := admin.Exec(ctx, "GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO "+tenantTestRole); err != nil {
+			t.Fatalf("could not grant tables: %v", err)
+		}
+		c.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			_, err := conn.Exec(ctx, "SET ROLE "+tenantTestRole)
+			return err
+		}
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// createTenant creates an owner with a store and a category in it, the
+// owner is deleted together with them when the test ends.
+func createTenant(t *testing.T, system RepositoryCombiner) (entities.Owner, entities.Store, entities.Category) {
+	t.Helper()
+	ctx := systemContext()
+
+	owner, err := entities.NewOwner("+996700000000", "Tenant", "tenant_"+uuid.NewString(), "password", entities.MinHashCost)
+	if err != nil {
+		t.Fatalf("could not build owner: %v", err)
+	}
+	owner, err = system.Owners().Create(ctx, owner)
+	if err != nil {
+		t.Fatalf("could not create owner: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := system.Owners().Delete(ctx, owner.ID.String()); err != nil {
+			t.Errorf("could not delete owner: %v", err)
+		}
+	})
+
+	store, err := system.Stores().Create(ctx, entities.Store{
+		Owner:       &entities.Owner{ID: owner.ID},
+		Name:        "Tenant Store",
+		Description: "of " + owner.Username,
+	})
+	if err != nil {
+		t.Fatalf("could not create store: %v", err)
+	}
+
+	category, err := system.Categories().Create(ctx, entities.Category{
+		Store: &entities.Store{ID: store.ID},
+		Name:  "Tenant Category",
+	})
+	if err != nil {
+		t.Fatalf("could not create category: %v", err)
+	}
+	return owner, store, category
+}
+
+func storesByID(id uuid.UUID) stores.ReadByInput {
+	var filter stores.ReadByInput
+	filter.ID.Set(id.String())
+	return filter
+}
@@ -0,0 +1,389 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/transfers"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type transfersRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r transfersRepository) Create(ctx context.Context, transfer entities.Transfer) (entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"transfersRepository.Create").End()
+
+	if transfer.Owner == nil || transfer.Source == nil || transfer.Destination == nil {
+		return entities.Transfer{}, errors.New("owner, source and destination are required")
+	}
+
+	sizeIDs := make([]int64, 0, len(transfer.Lines))
+	for _, l := range transfer.Lines {
+		sizeIDs = append(sizeIDs, l.Size.ID)
+	}
+
+	err := pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		var warehousesCount, sizesCount int
+		const checkWarehouses = "SELECT COUNT(*) FROM warehouses WHERE id IN ($1, $2) AND owner_id = $3"
+		err := tx.QueryRow(ctx, checkWarehouses, transfer.Source.ID, transfer.Destination.ID, transfer.Owner.ID).Scan(&warehousesCount)
+		if err != nil {
+			return err
+		}
+		const checkSizes = "SELECT COUNT(*) FROM sizes WHERE id = ANY($1) AND warehouse_id = $2"
+		if err := tx.QueryRow(ctx, checkSizes, sizeIDs, transfer.Source.ID).Scan(&sizesCount); err != nil {
+			return err
+		}
+		if warehousesCount != 2 || sizesCount != len(sizeIDs) {
+			return transfers.ErrWrongWarehouse
+		}
+
+		const insertTransfer = `INSERT INTO transfers (id, owner_id, source_warehouse_id, destination_warehouse_id, status, comment, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		_, err = tx.Exec(ctx, insertTransfer,
+			transfer.ID, transfer.Owner.ID, transfer.Source.ID, transfer.Destination.ID, transfer.Status, transfer.Comment, transfer.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		const insertLine = "INSERT INTO transfer_lines (transfer_id, size_id, quantity) VALUES ($1, $2, $3) RETURNING id"
+		for i, l := range transfer.Lines {
+			if err := tx.QueryRow(ctx, insertLine, transfer.ID, l.Size.ID, l.Quantity).Scan(&transfer.Lines[i].ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entities.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func whereTransfers(query sq.SelectBuilder, filters transfers.ReadByInput) sq.SelectBuilder {
+	if val, ok := filters.OwnerID.Get(); ok {
+		query = query.Where(sq.Eq{"owner_id": val})
+	}
+	if id, ok := filters.ID.Get(); ok {
+		return query.Where(sq.Eq{"id": id})
+	}
+
+	if val, ok := filters.WarehouseID.Get(); ok {
+		query = query.Where(sq.Or{
+			sq.Eq{"source_warehouse_id": val},
+			sq.Eq{"destination_warehouse_id": val},
+		})
+	}
+	if val, ok := filters.Status.Get(); ok {
+		query = query.Where(sq.Eq{"status": val})
+	}
+	return query
+}
+
+func (r transfersRepository) ReadBy(ctx context.Context, filters transfers.ReadByInput) ([]entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"transfersRepository.ReadBy").End()
+
+	query := whereTransfers(sq.Select("id", "owner_id", "source_warehouse_id", "destination_warehouse_id", "status", "comment", "created_at", "shipped_at", "received_at").
+		From("transfers").
+		PlaceholderFormat(sq.Dollar), filters)
+
+	_, byID := filters.ID.Get()
+	if !byID {
+		pageSize, ok := filters.PageSize.Get()
+		if !ok {
+			pageSize = 10
+		}
+		page, ok := filters.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.
+			OrderBy("created_at desc").
+			Limit(uint64(pageSize)).
+			Offset((page - 1) * uint64(pageSize))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Transfer, 0)
+	for rows.Next() {
+		var (
+			t                     entities.Transfer
+			owner                 entities.Owner
+			source, destination   entities.Warehouse
+			shippedAt, receivedAt *time.Time
+		)
+		err := rows.Scan(&t.ID, &owner.ID, &source.ID, &destination.ID, &t.Status, &t.Comment, &t.CreatedAt, &shippedAt, &receivedAt)
+		if err != nil {
+			return nil, err
+		}
+		t.Owner, t.Source, t.Destination = &owner, &source, &destination
+		t.ShippedAt, t.ReceivedAt = shippedAt, receivedAt
+		result = append(result, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// lines are shown only for a single transfer
+	if byID && len(result) == 1 {
+		result[0].Lines, err = r.readLines(ctx, db(ctx, r.conn), result[0].ID, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r transfersRepository) Count(ctx context.Context, filters transfers.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"transfersRepository.Count").End()
+
+	sql, args, err := whereTransfers(sq.Select("COUNT(*)").From("transfers").PlaceholderFormat(sq.Dollar), filters).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r transfersRepository) Delete(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"transfersRepository.Delete").End()
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		status, err := r.lock(ctx, tx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if status != entities.TransferDraft {
+			return transfers.ErrInvalidStatus
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM transfers WHERE id = $1", id)
+		return err
+	})
+}
+
+func (r transfersRepository) Ship(ctx context.Context, ownerID, id string) (entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"transfersRepository.Ship").End()
+
+	err := pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		status, err := r.lock(ctx, tx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if status != entities.TransferDraft {
+			return transfers.ErrInvalidStatus
+		}
+
+		transferID := uuid.MustParse(id)
+		lines, err := r.readLines(ctx, tx, transferID, true)
+		if err != nil {
+			return err
+		}
+		for _, l := range lines {
+			m, err := entities.NewMovement(l.Size, entities.MovementTransferOut, l.Quantity, "transfer")
+			if err != nil {
+				return err
+			}
+			m.ReferenceID = &transferID
+			if _, err := recordMovement(ctx, tx, m); err != nil {
+				if err == stock.ErrInsufficientStock {
+					return transfers.ErrInsufficientStock
+				}
+				return err
+			}
+		}
+
+		const sql = "UPDATE transfers SET status = $2, shipped_at = NOW() WHERE id = $1"
+		_, err = tx.Exec(ctx, sql, id, entities.TransferShipped)
+		return err
+	})
+	if err != nil {
+		return entities.Transfer{}, err
+	}
+
+	return r.readOne(ctx, id)
+}
+
+func (r transfersRepository) Receive(ctx context.Context, ownerID, id string, input transfers.ReceiveInput) (entities.Transfer, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"transfersRepository.Receive").End()
+
+	err := pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		status, err := r.lock(ctx, tx, ownerID, id)
+		if err != nil {
+			return err
+		}
+		if status != entities.TransferShipped && status != entities.TransferPartiallyReceived {
+			return transfers.ErrInvalidStatus
+		}
+
+		var destinationID uuid.UUID
+		const destinationSQL = "SELECT destination_warehouse_id FROM transfers WHERE id = $1"
+		if err := tx.QueryRow(ctx, destinationSQL, id).Scan(&destinationID); err != nil {
+			return err
+		}
+
+		transferID := uuid.MustParse(id)
+		lines, err := r.readLines(ctx, tx, transferID, true)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int64]*entities.TransferLine, len(lines))
+		for i := range lines {
+			byID[lines[i].ID] = &lines[i]
+		}
+
+		const updateLine = `UPDATE transfer_lines
+			SET received_quantity = $2, discrepancy = $3, discrepancy_note = $4
+			WHERE id = $1`
+
+		for _, in := range input.Lines {
+			l, ok := byID[in.LineID]
+			if !ok {
+				return transfers.ErrLineNotFound
+			}
+			if in.Quantity > l.Quantity-l.ReceivedQuantity-l.Discrepancy {
+				return transfers.ErrReceivedTooMuch
+			}
+			if in.DiscrepancyNote != "" {
+				l.DiscrepancyNote = in.DiscrepancyNote
+			}
+			if in.Quantity == 0 {
+				continue
+			}
+
+			sizeID, err := findOrCreateSize(ctx, tx, l.Size.Item.ID, destinationID, l.Size.SizeNumber, l.Size.SizeSymbol)
+			if err != nil {
+				return err
+			}
+			m, err := entities.NewMovement(&entities.Size{ID: sizeID}, entities.MovementTransferIn, in.Quantity, "transfer")
+			if err != nil {
+				return err
+			}
+			m.ReferenceID = &transferID
+			if _, err := recordMovement(ctx, tx, m); err != nil {
+				return err
+			}
+			l.ReceivedQuantity += in.Quantity
+		}
+
+		complete := true
+		for i := range lines {
+			l := &lines[i]
+			if input.Final {
+				// whatever did not arrive by now is lost
+				l.Discrepancy = l.Quantity - l.ReceivedQuantity
+			}
+			if l.ReceivedQuantity+l.Discrepancy < l.Quantity {
+				complete = false
+			}
+			if _, err := tx.Exec(ctx, updateLine, l.ID, l.ReceivedQuantity, l.Discrepancy, l.DiscrepancyNote); err != nil {
+				return err
+			}
+		}
+
+		if complete {
+			const sql = "UPDATE transfers SET status = $2, received_at = NOW() WHERE id = $1"
+			_, err = tx.Exec(ctx, sql, id, entities.TransferReceived)
+			return err
+		}
+		const sql = "UPDATE transfers SET status = $2 WHERE id = $1"
+		_, err = tx.Exec(ctx, sql, id, entities.TransferPartiallyReceived)
+		return err
+	})
+	if err != nil {
+		return entities.Transfer{}, err
+	}
+
+	return r.readOne(ctx, id)
+}
+
+func (r transfersRepository) readOne(ctx context.Context, id string) (entities.Transfer, error) {
+	in := transfers.ReadByInput{}
+	in.ID.Set(id)
+	res, err := r.ReadBy(ctx, in)
+	if err != nil {
+		return entities.Transfer{}, err
+	}
+	if len(res) == 0 {
+		return entities.Transfer{}, transfers.ErrNotFound
+	}
+	return res[0], nil
+}
+
+// lock takes a row lock on the transfer and returns its status.
+// Transfers of other owners are ErrNotFound.
+func (r transfersRepository) lock(ctx context.Context, tx pgx.Tx, ownerID, id string) (string, error) {
+	var status string
+	err := tx.QueryRow(ctx, "SELECT status FROM transfers WHERE id = $1 AND owner_id = $2 FOR UPDATE", id, ownerID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", transfers.ErrNotFound
+	}
+	return status, err
+}
+
+type rowsQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (r transfersRepository) readLines(ctx context.Context, q rowsQuerier, transferID uuid.UUID, forUpdate bool) ([]entities.TransferLine, error) {
+	sql := `SELECT l.id, l.quantity, l.received_quantity, l.discrepancy, l.discrepancy_note,
+			s.id, s.item_id, s.warehouse_id, s.size_number, s.size_symbol
+		FROM transfer_lines l
+		JOIN sizes s ON s.id = l.size_id
+		WHERE l.transfer_id = $1
+		ORDER BY l.id`
+	if forUpdate {
+		sql += " FOR UPDATE OF l"
+	}
+
+	rows, err := q.Query(ctx, sql, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]entities.TransferLine, 0)
+	for rows.Next() {
+		var (
+			l         entities.TransferLine
+			size      entities.Size
+			item      entities.Item
+			warehouse entities.Warehouse
+		)
+		err := rows.Scan(
+			&l.ID, &l.Quantity, &l.ReceivedQuantity, &l.Discrepancy, &l.DiscrepancyNote,
+			&size.ID, &item.ID, &warehouse.ID, &size.SizeNumber, &size.SizeSymbol,
+		)
+		if err != nil {
+			return nil, err
+		}
+		size.Item, size.Warehouse = &item, &warehouse
+		l.Size = &size
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
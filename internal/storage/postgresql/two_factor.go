@@ -0,0 +1,114 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type twoFactorRepository struct {
+	conn *pgxpool.Pool
+}
+
+var _ auth.TwoFactorRepository = (*twoFactorRepository)(nil)
+
+func (r twoFactorRepository) ReadTwoFactor(ctx context.Context, ownerID string) (auth.TwoFactorSettings, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"twoFactorRepository.ReadTwoFactor").End()
+
+	var (
+		settings auth.TwoFactorSettings
+		secret   *string
+	)
+	err := db(ctx, r.conn).QueryRow(ctx, "SELECT totp_secret, totp_enabled FROM owners WHERE id = $1", ownerID).
+		Scan(&secret, &settings.Enabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.TwoFactorSettings{}, auth.ErrIdNotFound
+		}
+		return auth.TwoFactorSettings{}, err
+	}
+	if secret != nil {
+		settings.Secret = *secret
+	}
+	return settings, nil
+}
+
+func (r twoFactorRepository) SetPendingSecret(ctx context.Context, ownerID, secret string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"twoFactorRepository.SetPendingSecret").End()
+
+	// enabled owners keep their secret, enrollment can't overwrite it
+	tag, err := db(ctx, r.conn).Exec(ctx, "UPDATE owners SET totp_secret = $2 WHERE id = $1 AND NOT totp_enabled", ownerID, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+func (r twoFactorRepository) EnableTwoFactor(ctx context.Context, ownerID string, recoveryHashes []string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"twoFactorRepository.EnableTwoFactor").End()
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE owners SET totp_enabled = TRUE WHERE id = $1 AND totp_secret IS NOT NULL", ownerID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return auth.ErrTwoFactorNotEnrolled
+		}
+		return replaceRecoveryCodes(ctx, tx, ownerID, recoveryHashes)
+	})
+}
+
+func (r twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, ownerID string, recoveryHashes []string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"twoFactorRepository.ReplaceRecoveryCodes").End()
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, ownerID, recoveryHashes)
+	})
+}
+
+func (r twoFactorRepository) DisableTwoFactor(ctx context.Context, ownerID string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"twoFactorRepository.DisableTwoFactor").End()
+
+	return pgx.BeginFunc(ctx, db(ctx, r.conn), func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "UPDATE owners SET totp_secret = NULL, totp_enabled = FALSE WHERE id = $1", ownerID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM owner_recovery_codes WHERE owner_id = $1", ownerID)
+		return err
+	})
+}
+
+func (r twoFactorRepository) UseRecoveryCode(ctx context.Context, ownerID, codeHash string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"twoFactorRepository.UseRecoveryCode").End()
+
+	const sql = `UPDATE owner_recovery_codes SET used_at = NOW()
+		WHERE owner_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, ownerID, codeHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return auth.ErrWrongCode
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, ownerID string, recoveryHashes []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM owner_recovery_codes WHERE owner_id = $1", ownerID); err != nil {
+		return err
+	}
+
+	const sql = `INSERT INTO owner_recovery_codes (owner_id, code_hash)
+		SELECT $1, UNNEST($2::VARCHAR[])`
+	_, err := tx.Exec(ctx, sql, ownerID, recoveryHashes)
+	return err
+}
@@ -0,0 +1,289 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/warehouses"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
+)
+
+type warehousesRepository struct {
+	conn *pgxpool.Pool
+}
+
+func (r warehousesRepository) Create(ctx context.Context, warehouse entities.Warehouse) (entities.Warehouse, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.Create").End()
+
+	if warehouse.Owner == nil {
+		return entities.Warehouse{}, errors.New("owner is required")
+	}
+
+	sql, args, err := sq.Insert("warehouses").
+		Columns("id", "owner_id", "name", "description", "created_at", "tsv").
+		Values(
+			warehouse.ID, warehouse.Owner.ID, warehouse.Name, warehouse.Description, warehouse.CreatedAt,
+			sq.Expr(
+				`setweight(to_tsvector(?), 'A') || setweight(to_tsvector(?), 'B')`,
+				warehouse.Name, warehouse.Description,
+			)).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return entities.Warehouse{}, err
+	}
+
+	if err := db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&warehouse.ID); err != nil {
+		return entities.Warehouse{}, err
+	}
+
+	return warehouse, nil
+}
+
+var warehouseSortingFields = map[string]string{
+	warehouses.SortByCreatedAt: "warehouses.created_at",
+	warehouses.SortByName:      "warehouses.name",
+}
+
+func whereWarehouses(query sq.SelectBuilder, filter warehouses.ReadByInput) sq.SelectBuilder {
+	val, ok := filter.OwnerID.Get()
+	if ok {
+		query = query.Where(sq.Eq{"owner_id": val})
+	}
+
+	if id, ok := filter.ID.Get(); ok {
+		return query.Where(sq.Eq{"warehouses.id": id})
+	}
+
+	val, ok = filter.StoreID.Get()
+	if ok {
+		query = query.Where(sq.Expr(
+			"EXISTS (SELECT 1 FROM store_warehouses sw WHERE sw.warehouse_id = warehouses.id AND sw.store_id = ?)", val,
+		))
+	}
+
+	val, ok = filter.Text.Get()
+	if ok {
+		// full text search on 'tsv' column
+		query = query.Where(sq.Expr("tsv @@ plainto_tsquery(?)", val))
+	}
+	return query
+}
+
+func (r warehousesRepository) ReadBy(ctx context.Context, filter warehouses.ReadByInput) ([]entities.Warehouse, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.ReadBy").End()
+
+	query := whereWarehouses(sq.Select("warehouses.id", "owner_id", "owners.full_name", "owners.username", "owners.created_at", "name", "description", "warehouses.created_at").
+		From("warehouses").
+		LeftJoin("owners ON owners.id = warehouses.owner_id").
+		PlaceholderFormat(sq.Dollar), filter)
+
+	_, byID := filter.ID.Get()
+	if !byID {
+		sortBy, ok := filter.SortBy.Get()
+		if ok {
+			sortBy, ok := warehouseSortingFields[sortBy]
+			if !ok {
+				sortBy = "warehouses.created_at"
+			}
+			sortOrder, ok := filter.SortOrder.Get()
+			if !ok {
+				sortOrder = warehouses.SortOrderAsc
+			}
+			query = query.OrderBy(sortBy + " " + sortOrder)
+		} else {
+			query = query.OrderBy("warehouses.created_at desc")
+		}
+
+		pageSize, ok := filter.PageSize.Get()
+		if !ok {
+			pageSize = 10
+		}
+		page, ok := filter.PageNumber.Get()
+		if !ok {
+			page = 1
+		}
+		query = query.Limit(uint64(pageSize)).Offset((page - 1) * uint64(pageSize))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db(ctx, r.conn).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Warehouse, 0)
+	for rows.Next() {
+		var (
+			warehouse entities.Warehouse
+			owner     entities.Owner
+		)
+		if err := rows.Scan(&warehouse.ID, &owner.ID, &owner.FullName, &owner.Username, &owner.CreatedAt, &warehouse.Name, &warehouse.Description, &warehouse.CreatedAt); err != nil {
+			return nil, err
+		}
+		warehouse.Owner = &owner
+		result = append(result, warehouse)
+	}
+
+	return result, rows.Err()
+}
+
+func (r warehousesRepository) Count(ctx context.Context, filter warehouses.ReadByInput) (uint64, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.Count").End()
+
+	sql, args, err := whereWarehouses(sq.Select("COUNT(*)").From("warehouses").PlaceholderFormat(sq.Dollar), filter).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	return count, db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&count)
+}
+
+func (r warehousesRepository) Update(ctx context.Context, ownerID, id string, changeset warehouses.UpdateInput) (entities.Warehouse, error) {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.Update").End()
+
+	query := sq.Update("warehouses").
+		Where(sq.Eq{"id": id, "owner_id": ownerID}).
+		PlaceholderFormat(sq.Dollar)
+
+	// tsv has to be built from the new values, because in UPDATE
+	// column references on the right side point to the old row
+	var (
+		tsvChanged  bool
+		name        = sq.Expr("name")
+		description = sq.Expr("description")
+	)
+	if val, ok := changeset.Name.Get(); ok {
+		query = query.Set("name", val)
+		name, tsvChanged = sq.Expr("?", val), true
+	}
+	if val, ok := changeset.Description.Get(); ok {
+		query = query.Set("description", val)
+		description, tsvChanged = sq.Expr("?", val), true
+	}
+	if tsvChanged {
+		query = query.Set("tsv", sq.Expr(
+			`setweight(to_tsvector(?), 'A') || setweight(to_tsvector(?), 'B')`,
+			name, description,
+		))
+	}
+
+	sql, args, err := query.
+		Suffix(`RETURNING "id", "owner_id", "name", "description", "created_at"`).
+		ToSql()
+	if err != nil {
+		return entities.Warehouse{}, err
+	}
+
+	var (
+		warehouse entities.Warehouse
+		owner     entities.Owner
+	)
+	err = db(ctx, r.conn).QueryRow(ctx, sql, args...).Scan(&warehouse.ID, &owner.ID, &warehouse.Name, &warehouse.Description, &warehouse.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Warehouse{}, warehouses.ErrNotFound
+		}
+		return entities.Warehouse{}, err
+	}
+	warehouse.Owner = &owner
+
+	return warehouse, nil
+}
+
+func (r warehousesRepository) Delete(ctx context.Context, ownerID, id string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.Delete").End()
+
+	sql, args, err := sq.Delete("warehouses").
+		Where(sq.Eq{"id": id, "owner_id": ownerID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := db(ctx, r.conn).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return warehouses.ErrNotFound
+	}
+	return nil
+}
+
+// ownsWarehouse is ErrNotFound when warehouse does not belong to ownerID.
+func (r warehousesRepository) ownsWarehouse(ctx context.Context, ownerID, warehouseID string) error {
+	var owns bool
+	const sql = "SELECT EXISTS (SELECT 1 FROM warehouses WHERE id = $1 AND owner_id = $2)"
+	if err := db(ctx, r.conn).QueryRow(ctx, sql, warehouseID, ownerID).Scan(&owns); err != nil {
+		return err
+	}
+	if !owns {
+		return warehouses.ErrNotFound
+	}
+	return nil
+}
+
+func (r warehousesRepository) LinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.LinkStore").End()
+
+	if err := r.ownsWarehouse(ctx, ownerID, warehouseID); err != nil {
+		return err
+	}
+
+	// link is only created when both belong to the same owner
+	const sql = `INSERT INTO store_warehouses (store_id, warehouse_id)
+		SELECT s.id, w.id FROM stores s
+		JOIN warehouses w ON w.owner_id = s.owner_id
+		WHERE s.id = $1 AND w.id = $2
+		ON CONFLICT DO NOTHING
+		RETURNING store_id`
+
+	var linked string
+	err := db(ctx, r.conn).QueryRow(ctx, sql, storeID, warehouseID).Scan(&linked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// either link already exists or owners differ
+			var exists bool
+			const check = "SELECT EXISTS (SELECT 1 FROM store_warehouses WHERE store_id = $1 AND warehouse_id = $2)"
+			if err := db(ctx, r.conn).QueryRow(ctx, check, storeID, warehouseID).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return nil
+			}
+			return warehouses.ErrStoreMismatch
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r warehousesRepository) UnlinkStore(ctx context.Context, ownerID, warehouseID, storeID string) error {
+	defer telemetry.NewSpan(ctx, PackageName+"warehousesRepository.UnlinkStore").End()
+
+	if err := r.ownsWarehouse(ctx, ownerID, warehouseID); err != nil {
+		return err
+	}
+
+	sql, args, err := sq.Delete("store_warehouses").
+		Where(sq.Eq{"store_id": storeID, "warehouse_id": warehouseID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db(ctx, r.conn).Exec(ctx, sql, args...)
+	return err
+}
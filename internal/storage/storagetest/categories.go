@@ -0,0 +1,193 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+func categoriesOfStore(store entities.Store, sortBy, sortOrder string, pageNumber uint64, pageSize uint) categories.ReadByInput {
+	var filter categories.ReadByInput
+	filter.StoreID.Set(store.ID.String())
+	filter.SortBy.Set(sortBy)
+	filter.SortOrder.Set(sortOrder)
+	filter.PageNumber.Set(pageNumber)
+	filter.PageSize.Set(pageSize)
+	return filter
+}
+
+func testCategories(t *testing.T, repos Repositories) {
+	ctx := systemContext()
+
+	owner := createOwner(t, repos)
+	store := createStore(t, repos, owner, "Golf Boutique", "categories live here")
+	other := createStore(t, repos, owner, "Hotel Boutique", "other categories")
+
+	shoes := createCategory(t, repos, store, nil, "Shoes", "A-3")
+	shirts := createCategory(t, repos, store, nil, "Shirts", "A-1")
+	createCategory(t, repos, store, nil, "Hats", "A-2")
+	sneakers := createCategory(t, repos, store, &shoes, "Sneakers", "A-4")
+	createCategory(t, repos, other, nil, "Shoes elsewhere", "B-1")
+
+	t.Run("ReadByID", func(t *testing.T) {
+		var filter categories.ReadByInput
+		filter.ID.Set(sneakers.ID.String())
+
+		list, err := repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("ReadBy: got %d categories, want 1", len(list))
+		}
+		got := list[0]
+		if got.Name != "Sneakers" || got.Article == nil || *got.Article != "A-4" {
+			t.Errorf("ReadBy: got %+v", got)
+		}
+		if got.Store == nil || got.Store.ID != store.ID {
+			t.Errorf("ReadBy: got store %+v, want %s", got.Store, store.ID)
+		}
+		if got.ParentCategory == nil || got.ParentCategory.ID != shoes.ID {
+			t.Errorf("ReadBy: got parent %+v, want %s", got.ParentCategory, shoes.ID)
+		}
+	})
+
+	t.Run("SortAndPaginate", func(t *testing.T) {
+		list, err := repos.Categories.ReadBy(ctx, categoriesOfStore(store, categories.SortByName, categories.SortOrderAsc, 1, 3))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "first page", categoryNames(list), "Hats", "Shirts", "Shoes")
+
+		list, err = repos.Categories.ReadBy(ctx, categoriesOfStore(store, categories.SortByName, categories.SortOrderAsc, 2, 3))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "second page", categoryNames(list), "Sneakers")
+
+		list, err = repos.Categories.ReadBy(ctx, categoriesOfStore(store, categories.SortByArticle, categories.SortOrderDesc, 1, 10))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "by article", categoryNames(list), "Sneakers", "Shoes", "Hats", "Shirts")
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		cursorAt := func(c entities.Category, backward bool) entities.Cursor {
+			return entities.Cursor{
+				SortBy:    categories.SortByArticle,
+				SortOrder: categories.SortOrderAsc,
+				Key:       categories.SortKey(c, categories.SortByArticle),
+				ID:        c.ID,
+				Backward:  backward,
+			}
+		}
+
+		// categories without article go last
+		unlabeled, err := repos.Categories.Create(ctx, entities.Category{Store: &entities.Store{ID: other.ID}, Name: "Unlabeled"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		filter := categoriesOfStore(other, categories.SortByArticle, categories.SortOrderAsc, 1, 1)
+		list, err := repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "first page", categoryNames(list), "Shoes elsewhere")
+		if len(list) != 1 {
+			t.FailNow()
+		}
+
+		filter.Cursor.Set(cursorAt(list[0], false))
+		list, err = repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "after article", categoryNames(list), "Unlabeled")
+
+		filter.Cursor.Set(cursorAt(unlabeled, false))
+		list, err = repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "after NULL", categoryNames(list))
+
+		filter.Cursor.Set(cursorAt(unlabeled, true))
+		list, err = repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "before NULL", categoryNames(list), "Shoes elsewhere")
+
+		count, err := repos.Categories.Count(ctx, filter)
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Count: got %d, want 2", count)
+		}
+	})
+
+	t.Run("FilterByText", func(t *testing.T) {
+		filter := categoriesOfStore(store, categories.SortByName, categories.SortOrderAsc, 1, 10)
+		filter.Text.Set("SH")
+
+		list, err := repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "text", categoryNames(list), "Shirts", "Shoes")
+	})
+
+	t.Run("FilterByParent", func(t *testing.T) {
+		filter := categoriesOfStore(store, categories.SortByName, categories.SortOrderAsc, 1, 10)
+		filter.ParentCategoryID.Set(shoes.ID.String())
+
+		list, err := repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "children", categoryNames(list), "Sneakers")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		changeset := categories.UpdateInput{ID: shirts.ID.String()}
+		changeset.Name.Set("T-Shirts")
+		article := "A-9"
+		changeset.Article.Set(&article)
+
+		got, err := repos.Categories.Update(ctx, changeset)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got.ID != shirts.ID || got.Name != "T-Shirts" || got.Article == nil || *got.Article != "A-9" {
+			t.Errorf("Update: got %+v", got)
+		}
+		if got.Store == nil || got.Store.ID != store.ID {
+			t.Errorf("Update: got store %+v, want %s", got.Store, store.ID)
+		}
+
+		changeset.ID = uuid.NewString()
+		if _, err := repos.Categories.Update(ctx, changeset); err != categories.ErrNotFound {
+			t.Errorf("Update of missing category: got %v, want %v", err, categories.ErrNotFound)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := repos.Categories.Delete(ctx, sneakers.ID.String()); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		var filter categories.ReadByInput
+		filter.ID.Set(sneakers.ID.String())
+		list, err := repos.Categories.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("deleted category is still there: %+v", list)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Package storagetest holds the behaviour every implementation of a
+// repository has to share. Implementations run it from their own tests, so
+// in-memory repositories can stand in for postgres ones in service tests.
+//
+// Tests only create rows under fresh owners and delete those owners at the
+// end, so they can run against a database that already has data.
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type Repositories struct {
+	Owners     auth.OwnersRepository
+	Stores     stores.StoresRepository
+	Categories categories.CategoriesRepository
+}
+
+// Run checks repos against the contract.
+func Run(t *testing.T, repos Repositories) {
+	t.Run("Owners", func(t *testing.T) { testOwners(t, repos) })
+	t.Run("Stores", func(t *testing.T) { testStores(t, repos) })
+	t.Run("Categories", func(t *testing.T) { testCategories(t, repos) })
+}
+
+// systemContext is the context repositories are called with. Tests work
+// with data of several owners, like background jobs of the application.
+func systemContext() context.Context {
+	return tenancy.WithSystem(context.Background(), "storage contract tests")
+}
+
+// createOwner creates an owner with unique username that is deleted
+// together with everything they have when the test ends.
+func createOwner(t *testing.T, repos Repositories) entities.Owner {
+	t.Helper()
+	ctx := systemContext()
+
+	owner, err := entities.NewOwner("+996700000000", "Test Owner", "owner_"+uuid.NewString(), "password", entities.MinHashCost)
+	if err != nil {
+		t.Fatalf("could not build owner: %v", err)
+	}
+	owner, err = repos.Owners.Create(ctx, owner)
+	if err != nil {
+		t.Fatalf("could not create owner: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := repos.Owners.Delete(ctx, owner.ID.String()); err != nil && err != auth.ErrIdNotFound {
+			t.Errorf("could not delete owner: %v", err)
+		}
+	})
+	return owner
+}
+
+func createStore(t *testing.T, repos Repositories, owner entities.Owner, name, description string) entities.Store {
+	t.Helper()
+
+	store, err := repos.Stores.Create(systemContext(), entities.Store{
+		Owner:       &entities.Owner{ID: owner.ID},
+		Name:        name,
+		Description: description,
+	})
+	if err != nil {
+		t.Fatalf("could not create store %q: %v", name, err)
+	}
+	if store.ID == uuid.Nil {
+		t.Fatalf("store %q got no id", name)
+	}
+	return store
+}
+
+func createCategory(t *testing.T, repos Repositories, store entities.Store, parent *entities.Category, name, article string) entities.Category {
+	t.Helper()
+
+	category := entities.Category{
+		Store:   &entities.Store{ID: store.ID},
+		Name:    name,
+		Article: &article,
+	}
+	if parent != nil {
+		category.ParentCategory = &entities.Category{ID: parent.ID}
+	}
+	category, err := repos.Categories.Create(systemContext(), category)
+	if err != nil {
+		t.Fatalf("could not create category %q: %v", name, err)
+	}
+	if category.ID == uuid.Nil {
+		t.Fatalf("category %q got no id", name)
+	}
+	return category
+}
+
+func storeNames(list []entities.Store) []string {
+	names := make([]string, 0, len(list))
+	for _, s := range list {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func categoryNames(list []entities.Category) []string {
+	names := make([]string, 0, len(list))
+	for _, c := range list {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func equalNames(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("%s: got %q, want %q", what, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s: got %q, want %q", what, got, want)
+			return
+		}
+	}
+}
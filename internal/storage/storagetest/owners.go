@@ -0,0 +1,154 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+)
+
+func testOwners(t *testing.T, repos Repositories) {
+	ctx := systemContext()
+
+	t.Run("CreateAndRead", func(t *testing.T) {
+		owner := createOwner(t, repos)
+		if owner.ID == uuid.Nil {
+			t.Fatal("owner got no id")
+		}
+
+		got, err := repos.Owners.Read(ctx, owner.ID.String())
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if got.Username != owner.Username || got.FullName != owner.FullName || got.PhoneNumber != owner.PhoneNumber {
+			t.Errorf("Read: got %+v, want %+v", got, owner)
+		}
+		if err := got.ComparePassword("password"); err != nil {
+			t.Errorf("Read: password hash is not kept: %v", err)
+		}
+
+		got, err = repos.Owners.ReadByUsername(ctx, owner.Username)
+		if err != nil {
+			t.Fatalf("ReadByUsername: %v", err)
+		}
+		if got.ID != owner.ID {
+			t.Errorf("ReadByUsername: got id %s, want %s", got.ID, owner.ID)
+		}
+
+		all, err := repos.Owners.ReadAll(ctx)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		found := false
+		for _, o := range all {
+			found = found || o.ID == owner.ID
+		}
+		if !found {
+			t.Error("ReadAll: created owner is missing")
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := repos.Owners.Read(ctx, uuid.NewString()); err != auth.ErrIdNotFound {
+			t.Errorf("Read: got %v, want %v", err, auth.ErrIdNotFound)
+		}
+		if _, err := repos.Owners.ReadByUsername(ctx, "missing_"+uuid.NewString()); err != auth.ErrUsernameNotFound {
+			t.Errorf("ReadByUsername: got %v, want %v", err, auth.ErrUsernameNotFound)
+		}
+	})
+
+	t.Run("UsernameTaken", func(t *testing.T) {
+		owner := createOwner(t, repos)
+		other := createOwner(t, repos)
+
+		duplicate := owner
+		duplicate.ID = uuid.New()
+		if _, err := repos.Owners.Create(ctx, duplicate); err != auth.ErrUsernameTaken {
+			t.Errorf("Create: got %v, want %v", err, auth.ErrUsernameTaken)
+		}
+
+		other.Username = owner.Username
+		if _, err := repos.Owners.Update(ctx, other); err != auth.ErrUsernameTaken {
+			t.Errorf("Update: got %v, want %v", err, auth.ErrUsernameTaken)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		owner := createOwner(t, repos)
+		owner.FullName = "Renamed Owner"
+		owner.Username = "renamed_" + uuid.NewString()
+
+		if _, err := repos.Owners.Update(ctx, owner); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := repos.Owners.Read(ctx, owner.ID.String())
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if got.FullName != owner.FullName || got.Username != owner.Username {
+			t.Errorf("Read after Update: got %+v, want %+v", got, owner)
+		}
+
+		owner.ID = uuid.New()
+		if _, err := repos.Owners.Update(ctx, owner); err != auth.ErrIdNotFound {
+			t.Errorf("Update of missing owner: got %v, want %v", err, auth.ErrIdNotFound)
+		}
+	})
+
+	t.Run("DeleteRemovesOwnerData", func(t *testing.T) {
+		owner := createOwner(t, repos)
+		store := createStore(t, repos, owner, "Doomed Store", "will be deleted")
+		createCategory(t, repos, store, nil, "Doomed Category", "D-1")
+
+		if err := repos.Owners.Delete(ctx, owner.ID.String()); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repos.Owners.Read(ctx, owner.ID.String()); err != auth.ErrIdNotFound {
+			t.Errorf("Read after Delete: got %v, want %v", err, auth.ErrIdNotFound)
+		}
+		if err := repos.Owners.Delete(ctx, owner.ID.String()); err != auth.ErrIdNotFound {
+			t.Errorf("second Delete: got %v, want %v", err, auth.ErrIdNotFound)
+		}
+
+		list, err := repos.Stores.ReadBy(ctx, storesByID(store.ID.String()))
+		if err != nil {
+			t.Fatalf("Stores.ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("store of deleted owner is still there: %+v", list)
+		}
+	})
+	t.Run("AnonymizeKeepsOwnerData", func(t *testing.T) {
+		owner := createOwner(t, repos)
+		store := createStore(t, repos, owner, "Kept Store", "stays after anonymization")
+
+		if err := repos.Owners.Anonymize(ctx, owner.ID.String()); err != nil {
+			t.Fatalf("Anonymize: %v", err)
+		}
+		got, err := repos.Owners.Read(ctx, owner.ID.String())
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if got.FullName != "" || got.PhoneNumber != "" || got.Username == owner.Username {
+			t.Errorf("Read after Anonymize: personal data is kept %+v", got)
+		}
+		if err := got.ComparePassword("password"); err == nil {
+			t.Error("Read after Anonymize: owner can still sign in")
+		}
+		if _, err := repos.Owners.ReadByUsername(ctx, owner.Username); err != auth.ErrUsernameNotFound {
+			t.Errorf("ReadByUsername after Anonymize: got %v, want %v", err, auth.ErrUsernameNotFound)
+		}
+
+		list, err := repos.Stores.ReadBy(ctx, storesByID(store.ID.String()))
+		if err != nil {
+			t.Fatalf("Stores.ReadBy: %v", err)
+		}
+		if len(list) != 1 {
+			t.Errorf("store of anonymized owner is gone: %+v", list)
+		}
+
+		if err := repos.Owners.Anonymize(ctx, uuid.NewString()); err != auth.ErrIdNotFound {
+			t.Errorf("Anonymize of missing owner: got %v, want %v", err, auth.ErrIdNotFound)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+func storesByID(id string) stores.ReadByInput {
+	var filter stores.ReadByInput
+	filter.ID.Set(id)
+	return filter
+}
+
+func storesOfOwner(ownerID uuid.UUID, sortBy, sortOrder string, pageNumber uint64, pageSize uint) stores.ReadByInput {
+	var filter stores.ReadByInput
+	filter.OwnerID.Set(ownerID.String())
+	filter.SortBy.Set(sortBy)
+	filter.SortOrder.Set(sortOrder)
+	filter.PageNumber.Set(pageNumber)
+	filter.PageSize.Set(pageSize)
+	return filter
+}
+
+func testStores(t *testing.T, repos Repositories) {
+	ctx := systemContext()
+
+	owner := createOwner(t, repos)
+	alpha := createStore(t, repos, owner, "Alpha Shop", "shoes and bags")
+	bravo := createStore(t, repos, owner, "Bravo Market", "groceries")
+	charlie := createStore(t, repos, owner, "Charlie Shop", "clothes")
+
+	stranger := createOwner(t, repos)
+	createStore(t, repos, stranger, "Alpha Stranger", "not ours")
+
+	t.Run("ReadByID", func(t *testing.T) {
+		list, err := repos.Stores.ReadBy(ctx, storesByID(alpha.ID.String()))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("ReadBy: got %d stores, want 1", len(list))
+		}
+		got := list[0]
+		if got.ID != alpha.ID || got.Name != alpha.Name || got.Description != alpha.Description {
+			t.Errorf("ReadBy: got %+v, want %+v", got, alpha)
+		}
+		if got.Owner == nil || got.Owner.ID != owner.ID || got.Owner.Username != owner.Username {
+			t.Errorf("ReadBy: got owner %+v, want %s", got.Owner, owner.Username)
+		}
+	})
+
+	t.Run("SortAndPaginate", func(t *testing.T) {
+		list, err := repos.Stores.ReadBy(ctx, storesOfOwner(owner.ID, stores.SortByName, "asc", 1, 2))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "first page", storeNames(list), "Alpha Shop", "Bravo Market")
+
+		list, err = repos.Stores.ReadBy(ctx, storesOfOwner(owner.ID, stores.SortByName, "asc", 2, 2))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "second page", storeNames(list), "Charlie Shop")
+
+		list, err = repos.Stores.ReadBy(ctx, storesOfOwner(owner.ID, stores.SortByName, "desc", 1, 10))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "descending", storeNames(list), "Charlie Shop", "Bravo Market", "Alpha Shop")
+	})
+
+	t.Run("FilterByIDs", func(t *testing.T) {
+		filter := storesOfOwner(owner.ID, stores.SortByName, "asc", 1, 10)
+		filter.IDs.Set([]string{alpha.ID.String(), bravo.ID.String()})
+
+		list, err := repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "ids", storeNames(list), "Alpha Shop", "Bravo Market")
+	})
+
+	t.Run("FilterByText", func(t *testing.T) {
+		filter := storesOfOwner(owner.ID, stores.SortByName, "asc", 1, 10)
+		filter.Text.Set("shop")
+
+		list, err := repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "text in name", storeNames(list), "Alpha Shop", "Charlie Shop")
+
+		filter.Text.Set("groceries")
+		list, err = repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "text in description", storeNames(list), "Bravo Market")
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		cursorAt := func(s entities.Store, sortOrder string, backward bool) entities.Cursor {
+			return entities.Cursor{
+				SortBy:    stores.SortByName,
+				SortOrder: sortOrder,
+				Key:       stores.SortKey(s, stores.SortByName),
+				ID:        s.ID,
+				Backward:  backward,
+			}
+		}
+
+		filter := storesOfOwner(owner.ID, stores.SortByName, "asc", 1, 2)
+		filter.Cursor.Set(cursorAt(alpha, "asc", false))
+		list, err := repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "after", storeNames(list), "Bravo Market", "Charlie Shop")
+
+		filter.PageSize.Set(1)
+		filter.Cursor.Set(cursorAt(charlie, "asc", true))
+		list, err = repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "before", storeNames(list), "Bravo Market")
+
+		filter = storesOfOwner(owner.ID, stores.SortByName, "desc", 1, 10)
+		filter.Cursor.Set(cursorAt(charlie, "desc", false))
+		list, err = repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "after descending", storeNames(list), "Bravo Market", "Alpha Shop")
+
+		count, err := repos.Stores.Count(ctx, filter)
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("Count: got %d, want 3", count)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		store := createStore(t, repos, owner, "Delta Kiosk", "newspapers")
+
+		var changeset stores.UpdateInput
+		changeset.Name.Set("Echo Kiosk")
+		got, err := repos.Stores.Update(ctx, store.ID.String(), changeset)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got.ID != store.ID || got.Name != "Echo Kiosk" || got.Description != "newspapers" {
+			t.Errorf("Update: got %+v", got)
+		}
+
+		// search must see the new name and forget the old one
+		filter := storesOfOwner(owner.ID, stores.SortByName, "asc", 1, 10)
+		filter.Text.Set("echo")
+		list, err := repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "new name", storeNames(list), "Echo Kiosk")
+
+		filter.Text.Set("delta")
+		list, err = repos.Stores.ReadBy(ctx, filter)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		equalNames(t, "old name", storeNames(list))
+
+		if _, err := repos.Stores.Update(ctx, uuid.NewString(), changeset); err != stores.ErrNotFound {
+			t.Errorf("Update of missing store: got %v, want %v", err, stores.ErrNotFound)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := createStore(t, repos, owner, "Foxtrot Stall", "temporary")
+
+		if err := repos.Stores.Delete(ctx, store.ID.String()); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		list, err := repos.Stores.ReadBy(ctx, storesByID(store.ID.String()))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("deleted store is still there: %+v", list)
+		}
+	})
+
+	t.Run("TrashAndRestore", func(t *testing.T) {
+		store := createStore(t, repos, owner, "Golf Stall", "comes back")
+		category := createCategory(t, repos, store, nil, "Hats", "H-1")
+
+		if err := repos.Stores.Delete(ctx, store.ID.String()); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		trash := storesByID(store.ID.String())
+		trash.Deleted.Set(true)
+		list, err := repos.Stores.ReadBy(ctx, trash)
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 1 || list[0].DeletedAt == nil {
+			t.Fatalf("trash: got %+v, want the deleted store", list)
+		}
+
+		// categories go to trash with the store and come back only with it
+		if err := repos.Categories.Restore(ctx, category.ID.String()); err != categories.ErrParentDeleted {
+			t.Errorf("Restore of category in deleted store: got %v, want %v", err, categories.ErrParentDeleted)
+		}
+		var changeset stores.UpdateInput
+		changeset.Name.Set("Golf Ghost")
+		if _, err := repos.Stores.Update(ctx, store.ID.String(), changeset); err != stores.ErrNotFound {
+			t.Errorf("Update of deleted store: got %v, want %v", err, stores.ErrNotFound)
+		}
+
+		if err := repos.Stores.Restore(ctx, store.ID.String()); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		list, err = repos.Stores.ReadBy(ctx, storesByID(store.ID.String()))
+		if err != nil {
+			t.Fatalf("ReadBy: %v", err)
+		}
+		if len(list) != 1 || list[0].DeletedAt != nil {
+			t.Errorf("restored store: got %+v", list)
+		}
+		cats, err := repos.Categories.ReadBy(ctx, categoriesOfStore(store, categories.SortByName, categories.SortOrderAsc, 1, 10))
+		if err != nil {
+			t.Fatalf("ReadBy categories: %v", err)
+		}
+		equalNames(t, "restored categories", categoryNames(cats), "Hats")
+
+		if err := repos.Stores.Restore(ctx, store.ID.String()); err != stores.ErrNotFound {
+			t.Errorf("Restore of live store: got %v, want %v", err, stores.ErrNotFound)
+		}
+	})
+}
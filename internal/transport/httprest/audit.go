@@ -0,0 +1,82 @@
+package httprest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+)
+
+type AuditReadRequest struct {
+	ActorID    string `query:"actorID"`
+	Action     string `query:"action"` // create, update, delete, restore, link, unlink
+	EntityType string `query:"entityType"`
+	EntityID   string `query:"entityID"`
+	From       string `query:"from"` // RFC 3339
+	To         string `query:"to"`   // RFC 3339, not included
+
+	// Pagination
+	PageNumber uint64 `query:"pageNumber"`
+	PageSize   uint   `query:"pageSize"`
+}
+
+// AuditHandler is only reachable by owners, see MiddlewareOnlyOwners.
+type AuditHandler struct {
+	auditService audit.Service
+}
+
+func (h AuditHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(AuditReadRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := audit.ReadByInput{OwnerID: session.UserID}
+	if req.ActorID != "" {
+		in.ActorID.Set(req.ActorID)
+	}
+	if req.Action != "" {
+		in.Action.Set(req.Action)
+	}
+	if req.EntityType != "" {
+		in.EntityType.Set(req.EntityType)
+	}
+	if req.EntityID != "" {
+		in.EntityID.Set(req.EntityID)
+	}
+	if req.From != "" {
+		from, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			return respondErr(ctx, apperr.NewField(apperr.KindInvalid, "from", "http.timeFormat", "from должен быть в формате RFC 3339"))
+		}
+		in.From.Set(from)
+	}
+	if req.To != "" {
+		to, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			return respondErr(ctx, apperr.NewField(apperr.KindInvalid, "to", "http.timeFormat", "to должен быть в формате RFC 3339"))
+		}
+		in.To.Set(to)
+	}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+
+	page, err := h.auditService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
@@ -1,17 +1,21 @@
 package httprest
 
 import (
-	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/audit"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/tenancy"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 )
 
 const (
 	AuthRefreshCookieName  = "refresh_token"
 	AuthSessionContextName = "session"
+	AuthAPIKeyHeader       = "X-API-Key"
 )
 
 type AuthRefreshRequest struct {
@@ -25,16 +29,16 @@ type AuthHandler struct {
 func (h AuthHandler) Register(ctx echo.Context) error {
 	req := new(auth.RegisterInput)
 	if err := ctx.Bind(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	if err := ctx.Validate(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	session, err := h.service.Register(ctx.Request().Context(), *req)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	ctx.SetCookie(&http.Cookie{
@@ -51,16 +55,22 @@ func (h AuthHandler) Register(ctx echo.Context) error {
 func (h AuthHandler) Login(ctx echo.Context) error {
 	req := new(auth.LoginInput)
 	if err := ctx.Bind(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	if err := ctx.Validate(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
+	req.IP = ctx.RealIP()
+
 	session, err := h.service.Login(ctx.Request().Context(), *req)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
+	}
+	if session.ChallengeToken != "" {
+		// client has to send a code to /auth/login/2fa
+		return ctx.JSON(http.StatusOK, session)
 	}
 
 	ctx.SetCookie(&http.Cookie{
@@ -74,25 +84,131 @@ func (h AuthHandler) Login(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, session)
 }
 
+func (h AuthHandler) LoginTwoFactor(ctx echo.Context) error {
+	req := new(auth.TwoFactorLoginInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	req.IP = ctx.RealIP()
+
+	session, err := h.service.LoginTwoFactor(ctx.Request().Context(), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	ctx.SetCookie(&http.Cookie{
+		Name:     AuthRefreshCookieName,
+		Value:    session.RefreshToken,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return ctx.JSON(http.StatusOK, session)
+}
+
+func (h AuthHandler) EnrollTwoFactor(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	enrollment, err := h.service.EnrollTwoFactor(ctx.Request().Context(), session)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, enrollment)
+}
+
+func (h AuthHandler) ConfirmTwoFactor(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(auth.TwoFactorCodeInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(ctx.Request().Context(), session, *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{"recoveryCodes": codes})
+}
+
+func (h AuthHandler) RegenerateRecoveryCodes(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(auth.TwoFactorCodeInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(ctx.Request().Context(), session, *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, echo.Map{"recoveryCodes": codes})
+}
+
+func (h AuthHandler) DisableTwoFactor(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(auth.DisableTwoFactorInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	if err := h.service.DisableTwoFactor(ctx.Request().Context(), session, *req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
 func (h AuthHandler) Refresh(ctx echo.Context) error {
 	refreshToken, err := ctx.Cookie(AuthRefreshCookieName)
 	if err != nil {
 		// search for refresh token in request body
 		req := new(AuthRefreshRequest)
 		if err := ctx.Bind(req); err != nil {
-			return respondErr(ctx, http.StatusBadRequest, err)
+			return respondErr(ctx, err)
 		}
-		if ctx.Validate(req) == nil {
-			refreshToken = &http.Cookie{
-				Value: req.RefreshToken,
-			}
+		if ctx.Validate(req) != nil {
+			return respondErr(ctx, apperr.New(apperr.KindUnauthorized, "http.refreshTokenMissing", "токен обновления нужно передать в cookie или в теле запроса"))
+		}
+		refreshToken = &http.Cookie{
+			Value: req.RefreshToken,
 		}
-		return respondErr(ctx, http.StatusBadRequest, errors.New("refresh token is required in cookie or request body"))
 	}
 
 	session, err := h.service.Refresh(ctx.Request().Context(), refreshToken.Value)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	ctx.SetCookie(&http.Cookie{
@@ -106,50 +222,427 @@ func (h AuthHandler) Refresh(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, session)
 }
 
-func (h AuthHandler) Logout(ctx echo.Context) error {
+func (h AuthHandler) UpdateProfile(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := make(echo.Map)
+	if err := ctx.Bind(&req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := auth.UpdateProfileInput{}
+	if v, ok := req["fullName"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("fullName", "строкой"))
+		}
+		in.FullName.Set(tmp)
+	}
+	if v, ok := req["username"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("username", "строкой"))
+		}
+		in.Username.Set(tmp)
+	}
+	if v, ok := req["phoneNumber"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("phoneNumber", "строкой"))
+		}
+		in.PhoneNumber.Set(tmp)
+	}
+
+	me, err := h.service.UpdateProfile(ctx.Request().Context(), session, in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, me)
+}
+
+func (h AuthHandler) DeleteAccount(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(auth.DeleteAccountInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	if err := h.service.DeleteAccount(ctx.Request().Context(), session, *req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	clearRefreshCookie(ctx)
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h AuthHandler) ChangePassword(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(auth.ChangePasswordInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	newSession, err := h.service.ChangePassword(ctx.Request().Context(), session, *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
 
 	ctx.SetCookie(&http.Cookie{
 		Name:     AuthRefreshCookieName,
-		Value:    "",
+		Value:    newSession.RefreshToken,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return ctx.JSON(http.StatusOK, newSession)
+}
+
+func (h AuthHandler) RequestPasswordReset(ctx echo.Context) error {
+	req := new(auth.RequestPasswordResetInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	if err := h.service.RequestPasswordReset(ctx.Request().Context(), *req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	// same answer whether the username exists or not
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func (h AuthHandler) ResetPassword(ctx echo.Context) error {
+	req := new(auth.ResetPasswordInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	if err := h.service.ResetPassword(ctx.Request().Context(), *req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	clearRefreshCookie(ctx)
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h AuthHandler) RequestSellerLogin(ctx echo.Context) error {
+	req := new(auth.RequestSellerLoginInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	req.IP = ctx.RealIP()
+
+	request, err := h.service.RequestSellerLogin(ctx.Request().Context(), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, request)
+}
+
+func (h AuthHandler) CompleteSellerLogin(ctx echo.Context) error {
+	req := new(auth.CompleteSellerLoginInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	session, err := h.service.CompleteSellerLogin(ctx.Request().Context(), *req)
+	if err != nil {
+		if err == auth.ErrLoginNotApproved {
+			// client is expected to poll until owner decides
+			return ctx.JSON(http.StatusAccepted, echo.Map{"error": err.Error()})
+		}
+		return respondErr(ctx, err)
+	}
+
+	ctx.SetCookie(&http.Cookie{
+		Name:     AuthRefreshCookieName,
+		Value:    session.RefreshToken,
 		Path:     "/",
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
 	})
 
+	return ctx.JSON(http.StatusOK, session)
+}
+
+func (h AuthHandler) ReadSellerLoginRequests(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	requests, err := h.service.ReadSellerLoginRequests(ctx.Request().Context(), session.UserID)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(entities.WholePage(requests)))
+}
+
+func (h AuthHandler) ApproveSellerLogin(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.service.ApproveSellerLogin(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h AuthHandler) RejectSellerLogin(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.service.RejectSellerLogin(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h AuthHandler) Logout(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.service.Logout(ctx.Request().Context(), session); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	clearRefreshCookie(ctx)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// LogoutAll ends sessions of the user on all devices.
+func (h AuthHandler) LogoutAll(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.service.LogoutAll(ctx.Request().Context(), session); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	clearRefreshCookie(ctx)
 	return ctx.NoContent(http.StatusOK)
 }
 
+func clearRefreshCookie(ctx echo.Context) {
+	ctx.SetCookie(&http.Cookie{
+		Name:     AuthRefreshCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1,
+	})
+}
+
+// MiddlewareUnpackAccess accepts either an access token or an api key
+// in AuthAPIKeyHeader. Groups using it must also use MiddlewareScope.
 func (h AuthHandler) MiddlewareUnpackAccess(next echo.HandlerFunc) echo.HandlerFunc {
+	unpackToken := h.MiddlewareUnpackToken(next)
+	return func(ctx echo.Context) error {
+		key := ctx.Request().Header.Get(AuthAPIKeyHeader)
+		if key == "" {
+			return unpackToken(ctx)
+		}
+
+		session, err := h.service.ParseAPIKey(ctx.Request().Context(), key)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+
+		setSession(ctx, session)
+		return next(ctx)
+	}
+}
+
+// MiddlewareUnpackToken accepts only access tokens, it guards account
+// management that api keys must not reach.
+func (h AuthHandler) MiddlewareUnpackToken(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
 		accessHeader := ctx.Request().Header.Get("Authorization")
 		if accessHeader == "" {
-			return respondErr(ctx, http.StatusUnauthorized, errors.New("access token is required in 'Authorization' header"))
+			return respondErr(ctx, apperr.New(apperr.KindUnauthorized, "http.accessTokenMissing", "токен доступа нужно передать в заголовке Authorization"))
 		}
 
 		access := strings.Split(accessHeader, " ")
 		if len(access) != 2 || access[0] != "Bearer" {
-			return respondErr(ctx, http.StatusUnauthorized, errors.New("invalid access token format"))
+			return respondErr(ctx, apperr.New(apperr.KindUnauthorized, "http.accessTokenFormat", "заголовок Authorization должен быть в формате Bearer <токен>"))
 		}
 
 		session, err := h.service.ParseAccessKey(ctx.Request().Context(), access[1])
 		if err != nil {
-			return respondErr(ctx, http.StatusUnauthorized, err)
+			return respondErr(ctx, err)
+		}
+
+		setSession(ctx, session)
+		return next(ctx)
+	}
+}
+
+// setSession makes session available to handlers, tells domains who
+// makes the request, see audit.ActorFrom, and scopes storage to data of
+// their owner, see tenancy.OwnerFrom.
+func setSession(ctx echo.Context, session auth.AccessKey) {
+	ctx.Set(AuthSessionContextName, session)
+
+	ownerID := session.Owner()
+	reqCtx := audit.WithActor(ctx.Request().Context(), audit.Actor{
+		ID:        session.UserID,
+		Role:      session.Role,
+		OwnerID:   ownerID,
+		APIKeyID:  session.APIKeyID,
+		RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+	})
+	ctx.SetRequest(ctx.Request().WithContext(tenancy.WithOwner(reqCtx, ownerID)))
+}
+
+// MiddlewareScope checks that api keys have "<resource>:read" scope for
+// safe methods and "<resource>:write" for the rest. Must go after
+// MiddlewareUnpackAccess.
+func (h AuthHandler) MiddlewareScope(resource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+			if !ok {
+				return respondErr(ctx, errUnauthorized)
+			}
+
+			access := auth.ScopeWrite
+			switch ctx.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				access = auth.ScopeRead
+			}
+			if !session.Allows(resource + ":" + access) {
+				return respondErr(ctx, auth.ErrScopeNotAllowed)
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+func (h AuthHandler) CreateAPIKey(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(auth.CreateAPIKeyInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	key, err := h.service.CreateAPIKey(ctx.Request().Context(), session, *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, key)
+}
+
+func (h AuthHandler) ReadAPIKeys(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	keys, err := h.service.ReadAPIKeys(ctx.Request().Context(), session)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(entities.WholePage(keys)))
+}
+
+func (h AuthHandler) RevokeAPIKey(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.service.RevokeAPIKey(ctx.Request().Context(), session, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// MiddlewareOnlyOwners must go after MiddlewareUnpackAccess.
+func (h AuthHandler) MiddlewareOnlyOwners(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+		if !ok {
+			return respondErr(ctx, errUnauthorized)
+		}
+		if session.Role != auth.RoleOwner {
+			return respondErr(ctx, apperr.New(apperr.KindForbidden, "http.ownersOnly", "доступно только владельцу"))
 		}
 
-		ctx.Set(AuthSessionContextName, session)
 		return next(ctx)
 	}
 }
 
+// JWKS lets other services verify tokens without sharing a secret.
+func (h AuthHandler) JWKS(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(http.StatusOK, h.service.JWKS(ctx.Request().Context()))
+}
+
 func (h AuthHandler) Me(ctx echo.Context) error {
 	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
 	if !ok {
-		return respondErr(ctx, http.StatusInternalServerError, errors.New("session not found in context"))
+		return respondErr(ctx, errInternal)
 	}
 
 	me, err := h.service.Me(ctx.Request().Context(), session)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, me)
@@ -1,11 +1,12 @@
 package httprest
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/categories"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
 )
 
 type (
@@ -13,38 +14,67 @@ type (
 		PageNumber       uint64 `query:"pageNumber"`
 		PageSize         uint   `query:"pageSize"`
 		Text             string `query:"text"`
-		StoreID          string `query:"storeID"`
+		StoreID          string `query:"storeID" validate:"required"`
 		ParentCategoryID string `query:"parentCategoryID"`
 		SortBy           string `query:"sortBy"`
 		SortOrder        string `query:"sortOrder"`
+		// with Cursor PageNumber and sorting are ignored
+		Cursor    string `query:"cursor"`
+		WithTotal bool   `query:"withTotal"`
 	}
 )
 
 type CategoriesHandler struct {
 	categoriesService categories.Service
+	policiesService   policies.Service
+	cursors           cursorCodec
 }
 
 func (h CategoriesHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
 	req := new(categories.CreateInput)
 	if err := ctx.Bind(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 	if err := ctx.Validate(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
+	}
+
+	err := h.policiesService.AuthorizeStore(ctx.Request().Context(), session, req.StoreID, policies.ActionManage)
+	if err != nil {
+		return respondErr(ctx, err)
 	}
 
 	category, err := h.categoriesService.Create(ctx.Request().Context(), *req)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusCreated, category)
 }
 
 func (h CategoriesHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
 	req := new(CategoriesReadByRequest)
 	if err := ctx.Bind(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	// categories are always listed inside a single store
+	err := h.policiesService.AuthorizeStore(ctx.Request().Context(), session, req.StoreID, policies.ActionRead)
+	if err != nil {
+		return respondErr(ctx, err)
 	}
 
 	in := categories.ReadByInput{}
@@ -66,12 +96,27 @@ func (h CategoriesHandler) ReadBy(ctx echo.Context) error {
 	if req.SortBy != "" {
 		in.SortBy.Set(req.SortBy)
 	}
+	if req.SortOrder != "" {
+		in.SortOrder.Set(req.SortOrder)
+	}
+	if req.Cursor != "" {
+		cursor, err := h.cursors.Decode(req.Cursor)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+		in.Cursor.Set(cursor)
+	}
+	if req.WithTotal {
+		in.WithTotal.Set(true)
+	}
 
-	res, err := h.categoriesService.ReadBy(ctx.Request().Context(), in)
+	page, err := h.categoriesService.ReadBy(ctx.Request().Context(), in)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
+	res := newListResponse(page)
+	res.NextCursor, res.PrevCursor = h.cursors.encodeOpt(page.Next), h.cursors.encodeOpt(page.Prev)
 	return ctx.JSON(http.StatusOK, res)
 }
 
@@ -81,18 +126,18 @@ func (h CategoriesHandler) Read(ctx echo.Context) error {
 	in := categories.ReadByInput{}
 	in.ID.Set(id)
 
-	res, err := h.categoriesService.ReadBy(ctx.Request().Context(), in)
+	page, err := h.categoriesService.ReadBy(ctx.Request().Context(), in)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
-	return ctx.JSON(http.StatusOK, res)
+	return ctx.JSON(http.StatusOK, page.Items)
 }
 
 func (h CategoriesHandler) Update(ctx echo.Context) error {
 	req := make(echo.Map)
 	if err := ctx.Bind(&req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	id := ctx.Param("id")
@@ -102,38 +147,97 @@ func (h CategoriesHandler) Update(ctx echo.Context) error {
 	if v, ok := req["name"]; ok {
 		tmp, ok := v.(string)
 		if !ok {
-			return respondErr(ctx, http.StatusBadRequest, errors.New("поле name должно быть строкой"))
+			return respondErr(ctx, errFieldType("name", "строкой"))
 		}
 		in.Name.Set(tmp)
 	}
 	if v, ok := req["article"]; ok {
 		tmp, ok := v.(string)
 		if !ok {
-			return respondErr(ctx, http.StatusBadRequest, errors.New("поле article должно быть строкой"))
+			return respondErr(ctx, errFieldType("article", "строкой"))
 		}
 		in.Article.Set(&tmp)
 	}
 	if v, ok := req["parentCategoryID"]; ok {
 		tmp, ok := v.(string)
 		if !ok {
-			return respondErr(ctx, http.StatusBadRequest, errors.New("поле parentCategoryID должно быть строкой"))
+			return respondErr(ctx, errFieldType("parentCategoryID", "строкой"))
 		}
 		in.ParentCategoryID.Set(&tmp)
 	}
 
 	category, err := h.categoriesService.Update(ctx.Request().Context(), in)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, category)
 }
 
+// Trash lists deleted categories of a store, only those who may manage
+// the store see it.
+func (h CategoriesHandler) Trash(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(CategoriesReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	err := h.policiesService.AuthorizeStore(ctx.Request().Context(), session, req.StoreID, policies.ActionManage)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := categories.ReadByInput{}
+	in.StoreID.Set(req.StoreID)
+	in.Deleted.Set(true)
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	if req.Cursor != "" {
+		cursor, err := h.cursors.Decode(req.Cursor)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+		in.Cursor.Set(cursor)
+	}
+	if req.WithTotal {
+		in.WithTotal.Set(true)
+	}
+
+	page, err := h.categoriesService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	res := newListResponse(page)
+	res.NextCursor, res.PrevCursor = h.cursors.encodeOpt(page.Next), h.cursors.encodeOpt(page.Prev)
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h CategoriesHandler) Restore(ctx echo.Context) error {
+	if err := h.categoriesService.Restore(ctx.Request().Context(), ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
 func (h CategoriesHandler) Delete(ctx echo.Context) error {
 	id := ctx.Param("id")
 
 	if err := h.categoriesService.Delete(ctx.Request().Context(), id); err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	return ctx.NoContent(http.StatusOK)
@@ -0,0 +1,69 @@
+package httprest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+)
+
+var errInvalidCursor = apperr.NewField(apperr.KindInvalid, "cursor", "http.invalidCursor", "курсор не валиден")
+
+// cursorCodec turns cursors into opaque tokens. Tokens are signed, so
+// clients can only pass back cursors they were given.
+type cursorCodec struct {
+	secret []byte
+}
+
+// deriveCursorSecret derives a key for cursors from jwt secret with
+// HKDF, so a cursor signature is never a valid token signature.
+func deriveCursorSecret(jwtSecret []byte) []byte {
+	secret := make([]byte, sha256.Size)
+	// reading one hash length from HKDF can not fail
+	io.ReadFull(hkdf.New(sha256.New, jwtSecret, nil, []byte("accounter-backend cursor")), secret)
+	return secret
+}
+
+func (c cursorCodec) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c cursorCodec) Encode(cursor entities.Cursor) string {
+	// a cursor is always marshalable
+	data, _ := json.Marshal(cursor)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + c.sign(payload)
+}
+
+func (c cursorCodec) Decode(token string) (entities.Cursor, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.sign(payload))) {
+		return entities.Cursor{}, errInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return entities.Cursor{}, errInvalidCursor
+	}
+	var cursor entities.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return entities.Cursor{}, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// encodeOpt encodes cursor if there is one.
+func (c cursorCodec) encodeOpt(cursor *entities.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return c.Encode(*cursor)
+}
@@ -0,0 +1,95 @@
+package httprest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := cursorCodec{secret: []byte("cursor secret")}
+	key := "milk"
+
+	for name, cursor := range map[string]entities.Cursor{
+		"Forward":  {SortBy: "name", SortOrder: "asc", Key: &key, ID: uuid.New()},
+		"Backward": {SortBy: "name", SortOrder: "desc", Key: &key, ID: uuid.New(), Backward: true},
+		"NullKey":  {SortBy: "created_at", SortOrder: "asc", ID: uuid.New()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := codec.Decode(codec.Encode(cursor))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, cursor) {
+				t.Errorf("Decode: got %+v, want %+v", got, cursor)
+			}
+		})
+	}
+}
+
+func TestCursorCodecRejects(t *testing.T) {
+	codec := cursorCodec{secret: []byte("cursor secret")}
+	token := codec.Encode(entities.Cursor{SortBy: "name", SortOrder: "asc", ID: uuid.New()})
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// forged is a cursor the client made up and signed with a wrong key
+	forged := cursorCodec{secret: []byte("other secret")}.Encode(entities.Cursor{SortBy: "name", ID: uuid.New()})
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"password_hash","o":"asc"}`))
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("not json"))
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"Empty", ""},
+		{"NoSignature", payload},
+		{"EmptySignature", payload + "."},
+		{"OtherKey", forged},
+		{"TamperedPayload", tampered + "." + signature},
+		{"TruncatedSignature", payload + "." + signature[:len(signature)-1]},
+		{"NotBase64", "!!!." + codec.sign("!!!")},
+		{"NotJSON", notJSON + "." + codec.sign(notJSON)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := codec.Decode(c.token); err != errInvalidCursor {
+				t.Errorf("Decode: got %v, want %v", err, errInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestDeriveCursorSecret(t *testing.T) {
+	jwtSecret := []byte("0123456789abcdef0123456789abcdef")
+
+	secret := deriveCursorSecret(jwtSecret)
+	if len(secret) != 32 {
+		t.Errorf("deriveCursorSecret: got %d bytes, want 32", len(secret))
+	}
+	if bytes.Equal(secret, jwtSecret) {
+		t.Errorf("deriveCursorSecret: cursor secret equals jwt secret")
+	}
+	if !bytes.Equal(secret, deriveCursorSecret(jwtSecret)) {
+		t.Errorf("deriveCursorSecret: secret differs between calls, cursors would not survive restarts")
+	}
+	if bytes.Equal(secret, deriveCursorSecret([]byte("another jwt secret of 32 bytes!!"))) {
+		t.Errorf("deriveCursorSecret: different jwt secrets give the same cursor secret")
+	}
+}
+
+func TestEncodeOpt(t *testing.T) {
+	codec := cursorCodec{secret: []byte("cursor secret")}
+	if got := codec.encodeOpt(nil); got != "" {
+		t.Errorf("encodeOpt(nil): got %q, want empty", got)
+	}
+	cursor := entities.Cursor{SortBy: "name", ID: uuid.New()}
+	if got := codec.encodeOpt(&cursor); got != codec.Encode(cursor) {
+		t.Errorf("encodeOpt: got %q, want %q", got, codec.Encode(cursor))
+	}
+}
@@ -0,0 +1,231 @@
+package httprest
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/items"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+)
+
+type (
+	ItemsReadByRequest struct {
+		StoreID    string   `query:"storeID" validate:"required"`
+		CategoryID string   `query:"categoryID"`
+		Text       string   `query:"text"`
+		Color      string   `query:"color"`
+		PriceFrom  *float64 `query:"priceFrom"`
+		PriceTo    *float64 `query:"priceTo"`
+
+		// Pagination
+		PageNumber uint64 `query:"pageNumber"`
+		PageSize   uint   `query:"pageSize"`
+
+		// Sorting
+		SortBy    string `query:"sortBy"`    // name, price, createdAt
+		SortOrder string `query:"sortOrder"` // asc, desc
+	}
+)
+
+type ItemsHandler struct {
+	itemsService    items.Service
+	policiesService policies.Service
+}
+
+func (h ItemsHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(items.CreateInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	err := h.policiesService.AuthorizeStore(ctx.Request().Context(), session, req.StoreID, policies.ActionManage)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	if req.CategoryID != nil {
+		err := h.policiesService.AuthorizeCategory(ctx.Request().Context(), session, *req.CategoryID, policies.ActionManage)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+	}
+
+	item, err := h.itemsService.Create(ctx.Request().Context(), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, item)
+}
+
+func (h ItemsHandler) Read(ctx echo.Context) error {
+	id := ctx.Param("id")
+
+	in := items.ReadByInput{}
+	in.ID.Set(id)
+
+	page, err := h.itemsService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	if len(page.Items) == 0 {
+		return respondErr(ctx, items.ErrNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, page.Items[0])
+}
+
+func (h ItemsHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(ItemsReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	// items are always listed inside a single store
+	err := h.policiesService.AuthorizeStore(ctx.Request().Context(), session, req.StoreID, policies.ActionRead)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := items.ReadByInput{}
+	in.StoreID.Set(req.StoreID)
+	if req.CategoryID != "" {
+		in.CategoryID.Set(req.CategoryID)
+	}
+	if req.Text != "" {
+		in.Text.Set(req.Text)
+	}
+	if req.Color != "" {
+		in.Color.Set(req.Color)
+	}
+	if req.PriceFrom != nil {
+		in.PriceFrom.Set(*req.PriceFrom)
+	}
+	if req.PriceTo != nil {
+		in.PriceTo.Set(*req.PriceTo)
+	}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	if req.SortBy != "" {
+		in.SortBy.Set(req.SortBy)
+	}
+	if req.SortOrder != "" {
+		in.SortOrder.Set(req.SortOrder)
+	}
+
+	page, err := h.itemsService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h ItemsHandler) Update(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := make(echo.Map)
+	if err := ctx.Bind(&req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := items.UpdateInput{}
+
+	if v, ok := req["categoryID"]; ok {
+		if v == nil {
+			in.CategoryID.Set(nil)
+		} else {
+			tmp, ok := v.(string)
+			if !ok {
+				return respondErr(ctx, errFieldType("categoryID", "строкой"))
+			}
+			err := h.policiesService.AuthorizeCategory(ctx.Request().Context(), session, tmp, policies.ActionManage)
+			if err != nil {
+				return respondErr(ctx, err)
+			}
+			in.CategoryID.Set(&tmp)
+		}
+	}
+	if v, ok := req["name"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("name", "строкой"))
+		}
+		in.Name.Set(tmp)
+	}
+	if v, ok := req["article"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("article", "строкой"))
+		}
+		in.Article.Set(tmp)
+	}
+	if v, ok := req["description"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("description", "строкой"))
+		}
+		in.Description.Set(tmp)
+	}
+	if v, ok := req["iconURL"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("iconURL", "строкой"))
+		}
+		in.IconURL.Set(tmp)
+	}
+	if v, ok := req["color"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("color", "строкой"))
+		}
+		in.Color.Set(tmp)
+	}
+	if v, ok := req["price"]; ok {
+		tmp, ok := v.(float64)
+		if !ok {
+			return respondErr(ctx, errFieldType("price", "числом"))
+		}
+		in.Price.Set(tmp)
+	}
+
+	item, err := h.itemsService.Update(ctx.Request().Context(), ctx.Param("id"), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, item)
+}
+
+func (h ItemsHandler) Delete(ctx echo.Context) error {
+	id := ctx.Param("id")
+
+	if err := h.itemsService.Delete(ctx.Request().Context(), id); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,32 @@
+package httprest
+
+import "github.com/rasulov-emirlan/accounter-backend/internal/entities"
+
+// ListResponse is what every list endpoint responds with.
+type ListResponse[T any] struct {
+	Items []T `json:"items"`
+	// Total is missing for pages read with a cursor unless withTotal is set.
+	Total *uint64 `json:"total,omitempty"`
+	// Page is missing for pages read with a cursor.
+	Page       uint64 `json:"page,omitempty"`
+	PageSize   uint   `json:"pageSize"`
+	HasNext    bool   `json:"hasNext"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+}
+
+// newListResponse leaves cursors empty, handlers of lists read with
+// cursors encode them.
+func newListResponse[T any](page entities.Page[T]) ListResponse[T] {
+	items := page.Items
+	if items == nil {
+		items = make([]T, 0)
+	}
+	return ListResponse[T]{
+		Items:    items,
+		Total:    page.Total,
+		Page:     page.PageNumber,
+		PageSize: page.PageSize,
+		HasNext:  page.HasNext,
+	}
+}
@@ -0,0 +1,56 @@
+package httprest
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+)
+
+// PoliciesHandler provides middlewares that authorize access to the
+// resource from path parameter "id". They must go after
+// MiddlewareUnpackAccess.
+type PoliciesHandler struct {
+	policiesService policies.Service
+}
+
+func (h PoliciesHandler) StoreAccess(action string) echo.MiddlewareFunc {
+	return access(h.policiesService.AuthorizeStore, action)
+}
+
+func (h PoliciesHandler) CategoryAccess(action string) echo.MiddlewareFunc {
+	return access(h.policiesService.AuthorizeCategory, action)
+}
+
+func (h PoliciesHandler) ItemAccess(action string) echo.MiddlewareFunc {
+	return access(h.policiesService.AuthorizeItem, action)
+}
+
+func (h PoliciesHandler) WarehouseAccess(action string) echo.MiddlewareFunc {
+	return access(h.policiesService.AuthorizeWarehouse, action)
+}
+
+// SizeAccess authorizes access to the size of an item in stock.
+func (h PoliciesHandler) SizeAccess(action string) echo.MiddlewareFunc {
+	return access(h.policiesService.AuthorizeSize, action)
+}
+
+type authorizeFunc func(ctx context.Context, key auth.AccessKey, id, action string) error
+
+func access(authorize authorizeFunc, action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+			if !ok {
+				return respondErr(ctx, errUnauthorized)
+			}
+
+			if err := authorize(ctx.Request().Context(), session, ctx.Param("id"), action); err != nil {
+				return respondErr(ctx, err)
+			}
+
+			return next(ctx)
+		}
+	}
+}
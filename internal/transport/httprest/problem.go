@@ -0,0 +1,112 @@
+package httprest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is an error response as described in RFC 7807.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Code is the kind of the error, see apperr.Kind.Code.
+	Code string `json:"code"`
+	// MessageKey names the exact error, clients translate Detail by it.
+	MessageKey string              `json:"messageKey,omitempty"`
+	Fields     []apperr.FieldError `json:"fields,omitempty"`
+	RequestID  string              `json:"requestId,omitempty"`
+}
+
+// statuses is the only place where errors become statuses.
+var statuses = map[apperr.Kind]int{
+	apperr.KindInternal:        http.StatusInternalServerError,
+	apperr.KindInvalid:         http.StatusBadRequest,
+	apperr.KindUnauthorized:    http.StatusUnauthorized,
+	apperr.KindForbidden:       http.StatusForbidden,
+	apperr.KindNotFound:        http.StatusNotFound,
+	apperr.KindConflict:        http.StatusConflict,
+	apperr.KindGone:            http.StatusGone,
+	apperr.KindValidation:      http.StatusUnprocessableEntity,
+	apperr.KindTooManyRequests: http.StatusTooManyRequests,
+	apperr.KindUnavailable:     http.StatusServiceUnavailable,
+}
+
+var (
+	errUnauthorized = apperr.New(apperr.KindUnauthorized, "http.unauthorized", "нужно войти в систему")
+	errInternal     = apperr.New(apperr.KindInternal, "http.internal", "что-то пошло не так")
+	errIDRequired   = apperr.New(apperr.KindInvalid, "http.idRequired", "нужно указать id")
+	errSizeID       = apperr.New(apperr.KindInvalid, "http.sizeID", "id размера должен быть числом")
+	errDateFormat   = apperr.New(apperr.KindInvalid, "http.dateFormat", "неверный формат даты")
+)
+
+// errFieldType is returned when a field of a request has a wrong type.
+func errFieldType(field, want string) error {
+	return apperr.NewField(apperr.KindInvalid, field, "http.fieldType", fmt.Sprintf("поле %s должно быть %s", field, want))
+}
+
+func newProblem(ctx echo.Context, err error) Problem {
+	var (
+		e      *apperr.Error
+		status int
+	)
+	if he, ok := err.(*echo.HTTPError); ok {
+		// echo fails like this on malformed bodies and unknown routes
+		e = apperr.New(kindOfStatus(he.Code), fmt.Sprintf("http.status%d", he.Code), fmt.Sprint(he.Message))
+		status = he.Code
+	} else {
+		if e, ok = apperr.As(err); !ok {
+			// untyped errors may tell too much, services log them
+			e = errInternal
+		}
+		status = statuses[e.Kind]
+	}
+
+	return Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     e.Message,
+		Instance:   ctx.Request().URL.Path,
+		Code:       e.Kind.Code(),
+		MessageKey: e.Key,
+		Fields:     e.Fields,
+		RequestID:  ctx.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
+
+func respondErr(ctx echo.Context, err error) error {
+	p := newProblem(ctx, err)
+	ctx.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return ctx.JSON(p.Status, p)
+}
+
+// handleErr responds to errors handlers return instead of responding.
+func handleErr(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+	if err := respondErr(ctx, err); err != nil {
+		ctx.Logger().Error(err)
+	}
+}
+
+func kindOfStatus(status int) apperr.Kind {
+	for kind, s := range statuses {
+		if s == status {
+			return kind
+		}
+	}
+	if status < http.StatusInternalServerError {
+		return apperr.KindInvalid
+	}
+	return apperr.KindInternal
+}
@@ -0,0 +1,126 @@
+package httprest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/validation"
+)
+
+func TestStatuses(t *testing.T) {
+	// every kind must have a status, otherwise it would be answered with 0
+	for kind := apperr.KindInternal; kind <= apperr.KindUnavailable; kind++ {
+		if statuses[kind] == 0 {
+			t.Errorf("kind %q has no status", kind.Code())
+		}
+		if kind.Code() == "" {
+			t.Errorf("kind %d has no code", kind)
+		}
+	}
+}
+
+func TestRespondErr(t *testing.T) {
+	fieldErr := &apperr.Error{
+		Kind:    apperr.KindValidation,
+		Key:     validation.ErrInvalid.Key,
+		Message: validation.ErrInvalid.Message,
+		Fields:  []apperr.FieldError{{Field: "username", Key: "validation.required", Message: "обязательное поле"}},
+	}
+
+	cases := []struct {
+		name string
+		err  error
+		want Problem
+	}{
+		{"Unauthorized", auth.ErrInvalidCredentials, Problem{
+			Status: http.StatusUnauthorized, Code: "unauthorized", MessageKey: "auth.invalidCredentials",
+			Detail: auth.ErrInvalidCredentials.Message,
+		}},
+		{"TooManyRequests", auth.ErrLoginLocked, Problem{
+			Status: http.StatusTooManyRequests, Code: "too_many_requests", MessageKey: "auth.loginLocked",
+			Detail: auth.ErrLoginLocked.Message,
+		}},
+		{"Wrapped", fmt.Errorf("reading store: %w", errIDRequired), Problem{
+			Status: http.StatusBadRequest, Code: "bad_request", MessageKey: "http.idRequired",
+			Detail: errIDRequired.Message,
+		}},
+		{"Field", errInvalidCursor, Problem{
+			Status: http.StatusBadRequest, Code: "bad_request", MessageKey: "http.invalidCursor",
+			Detail: errInvalidCursor.Message, Fields: errInvalidCursor.Fields,
+		}},
+		{"Validation", fieldErr, Problem{
+			Status: http.StatusUnprocessableEntity, Code: "validation_failed", MessageKey: "validation.failed",
+			Detail: fieldErr.Message, Fields: fieldErr.Fields,
+		}},
+		// untyped errors may hold sql or file paths
+		{"Untyped", errors.New("pq: relation owners does not exist"), Problem{
+			Status: http.StatusInternalServerError, Code: "internal", MessageKey: "http.internal",
+			Detail: errInternal.Message,
+		}},
+		{"EchoNotFound", echo.ErrNotFound, Problem{
+			Status: http.StatusNotFound, Code: "not_found", MessageKey: "http.status404",
+			Detail: http.StatusText(http.StatusNotFound),
+		}},
+		{"EchoUnknownStatus", echo.NewHTTPError(http.StatusRequestEntityTooLarge, "body is too large"), Problem{
+			Status: http.StatusRequestEntityTooLarge, Code: "bad_request", MessageKey: "http.status413",
+			Detail: "body is too large",
+		}},
+		{"EchoServerError", echo.NewHTTPError(http.StatusBadGateway, "upstream failed"), Problem{
+			Status: http.StatusBadGateway, Code: "internal", MessageKey: "http.status502",
+			Detail: "upstream failed",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/stores/1", nil), rec)
+			ctx.Response().Header().Set(echo.HeaderXRequestID, "request")
+
+			if err := respondErr(ctx, c.err); err != nil {
+				t.Fatalf("respondErr: %v", err)
+			}
+			if rec.Code != c.want.Status {
+				t.Errorf("status: got %d, want %d", rec.Code, c.want.Status)
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); got != MIMEApplicationProblemJSON {
+				t.Errorf("content type: got %q, want %q", got, MIMEApplicationProblemJSON)
+			}
+
+			var got Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			want := c.want
+			want.Type = "about:blank"
+			want.Title = http.StatusText(want.Status)
+			want.Instance = "/stores/1"
+			want.RequestID = "request"
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("problem: got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestHandleErrCommitted(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if err := ctx.NoContent(http.StatusNoContent); err != nil {
+		t.Fatalf("NoContent: %v", err)
+	}
+
+	// handler already answered, the error can only be logged
+	handleErr(errInternal, ctx)
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("handleErr: got %d %q, want untouched response", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,218 @@
+package httprest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sales"
+	"github.com/rasulov-emirlan/accounter-backend/internal/entities"
+)
+
+type (
+	SalesCreateRequest struct {
+		StoreID string                  `json:"storeID" validate:"required,uuid4"`
+		Lines   []sales.CreateLineInput `json:"lines" validate:"required,min=1,dive"`
+	}
+
+	SalesReadByRequest struct {
+		StoreID  string `query:"storeID"`
+		SellerID string `query:"sellerID"`
+		From     string `query:"from"` // RFC3339 or 2006-01-02
+		To       string `query:"to"`   // RFC3339 or 2006-01-02, exclusive
+
+		// Pagination
+		PageNumber uint64 `query:"pageNumber"`
+		PageSize   uint   `query:"pageSize"`
+	}
+)
+
+type SalesHandler struct {
+	salesService    sales.Service
+	policiesService policies.Service
+}
+
+func (h SalesHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(SalesCreateRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	err := h.policiesService.AuthorizeStore(ctx.Request().Context(), session, req.StoreID, policies.ActionSell)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := sales.CreateInput{
+		StoreID: req.StoreID,
+		Lines:   req.Lines,
+	}
+	if session.Role == auth.RoleSeller {
+		in.SellerID = &session.UserID
+	}
+
+	receipt, err := h.salesService.Create(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, receipt)
+}
+
+func (h SalesHandler) Read(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	in := sales.ReadByInput{}
+	if err := scopeReceipts(session, &in); err != nil {
+		return respondErr(ctx, err)
+	}
+	in.ID.Set(ctx.Param("id"))
+
+	page, err := h.salesService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	if len(page.Items) == 0 {
+		return respondErr(ctx, sales.ErrNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, page.Items[0])
+}
+
+func (h SalesHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(SalesReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := sales.ReadByInput{}
+	if err := scopeReceipts(session, &in); err != nil {
+		return respondErr(ctx, err)
+	}
+	if req.StoreID != "" {
+		in.StoreID.Set(req.StoreID)
+	}
+	if req.SellerID != "" {
+		in.SellerID.Set(req.SellerID)
+	}
+	if req.From != "" {
+		from, err := parseDate(req.From)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+		in.From.Set(from)
+	}
+	if req.To != "" {
+		to, err := parseDate(req.To)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+		in.To.Set(to)
+	}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+
+	page, err := h.salesService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h SalesHandler) Return(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(sales.ReturnInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	req.ReceiptID = ctx.Param("id")
+	if session.Role == auth.RoleSeller {
+		req.SellerID = &session.UserID
+	}
+
+	err := h.policiesService.AuthorizeReceipt(ctx.Request().Context(), session, req.ReceiptID, policies.ActionSell)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	ret, err := h.salesService.Return(ctx.Request().Context(), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, ret)
+}
+
+func (h SalesHandler) ReadReturns(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	err := h.policiesService.AuthorizeReceipt(ctx.Request().Context(), session, ctx.Param("id"), policies.ActionRead)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	res, err := h.salesService.ReadReturns(ctx.Request().Context(), ctx.Param("id"))
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(entities.WholePage(res)))
+}
+
+// scopeReceipts lets everyone see only receipts of stores they may read.
+func scopeReceipts(session auth.AccessKey, in *sales.ReadByInput) error {
+	switch session.Role {
+	case auth.RoleOwner:
+		in.OwnerID.Set(session.UserID)
+	case auth.RoleSeller:
+		in.OwnerID.Set(session.OwnerID)
+		in.StoreIDs.Set(session.StoreIDs)
+	default:
+		return policies.ErrForbidden
+	}
+	return nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, errDateFormat
+	}
+	return t, nil
+}
@@ -0,0 +1,190 @@
+package httprest
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/sellers"
+)
+
+type (
+	SellersCreateRequest struct {
+		Username    string   `json:"username" validate:"required,min=6,max=500"`
+		FullName    string   `json:"fullName" validate:"required"`
+		PhoneNumber string   `json:"phoneNumber" validate:"max=500"`
+		StoreIDs    []string `json:"storeIDs" validate:"max=100"`
+	}
+
+	SellersReadRequest struct {
+		StoreID  string `query:"storeID"`
+		IsActive *bool  `query:"isActive"`
+
+		// Pagination
+		PageNumber uint64 `query:"pageNumber"`
+		PageSize   uint   `query:"pageSize"`
+
+		// Sorting
+		SortBy    string `query:"sortBy"`    // fullName, createdAt
+		SortOrder string `query:"sortOrder"` // asc, desc
+	}
+)
+
+// SellersHandler is only reachable by owners, see MiddlewareOnlyOwners.
+type SellersHandler struct {
+	sellersService sellers.Service
+}
+
+func (h SellersHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(SellersCreateRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	seller, err := h.sellersService.Create(ctx.Request().Context(), sellers.CreateInput{
+		OwnerID:     session.UserID,
+		Username:    req.Username,
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+		StoreIDs:    req.StoreIDs,
+	})
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, seller)
+}
+
+func (h SellersHandler) Read(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	in := sellers.ReadByInput{}
+	in.ID.Set(ctx.Param("id"))
+	page, err := h.sellersService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	if len(page.Items) == 0 || page.Items[0].Owner.ID.String() != session.UserID {
+		return respondErr(ctx, sellers.ErrNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, page.Items[0])
+}
+
+func (h SellersHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(SellersReadRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := sellers.ReadByInput{}
+	in.OwnerID.Set(session.UserID)
+	if req.StoreID != "" {
+		in.StoreID.Set(req.StoreID)
+	}
+	if req.IsActive != nil {
+		in.IsActive.Set(*req.IsActive)
+	}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	if req.SortBy != "" {
+		in.SortBy.Set(req.SortBy)
+	}
+	if req.SortOrder != "" {
+		in.SortOrder.Set(req.SortOrder)
+	}
+
+	page, err := h.sellersService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h SellersHandler) Activate(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.sellersService.Activate(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h SellersHandler) Deactivate(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.sellersService.Deactivate(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h SellersHandler) Delete(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.sellersService.Delete(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h SellersHandler) AssignStore(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	err := h.sellersService.AssignStore(ctx.Request().Context(), session.UserID, ctx.Param("id"), ctx.Param("storeID"))
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h SellersHandler) UnassignStore(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	err := h.sellersService.UnassignStore(ctx.Request().Context(), session.UserID, ctx.Param("id"), ctx.Param("storeID"))
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -2,6 +2,8 @@ package httprest
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/rasulov-emirlan/accounter-backend/config"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/health"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/logging"
 	"github.com/rasulov-emirlan/accounter-backend/pkg/telemetry"
@@ -21,32 +24,70 @@ var (
 )
 
 type server struct {
-	srvr        *http.Server
-	serviceName string
+	srvr           *http.Server
+	serviceName    string
+	cursors        cursorCodec
+	trustedProxies []string
 }
 
 func NewServer(cfg config.Config) server {
+	cursorSecret := []byte(cfg.CursorSecret)
+	if len(cursorSecret) == 0 {
+		cursorSecret = deriveCursorSecret([]byte(cfg.JWTsecret))
+	}
+
 	return server{
 		srvr: &http.Server{
 			Addr:         cfg.Server.Port,
 			ReadTimeout:  cfg.Server.TimeoutRead,
 			WriteTimeout: cfg.Server.TimeoutWrite,
 		},
-		serviceName: cfg.ServiceName,
+		serviceName:    cfg.ServiceName,
+		cursors:        cursorCodec{cursorSecret},
+		trustedProxies: cfg.Server.TrustedProxies,
+	}
+}
+
+// ipExtractor trusts X-Forwarded-For only when it comes from one of
+// proxies, echo trusts private networks by default, so they are turned off.
+func ipExtractor(proxies []string) (echo.IPExtractor, error) {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect(), nil
 	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, p := range proxies {
+		_, ipRange, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", p, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
 }
 
 func (s server) Start(log *logging.Logger, doms domains.DomainCombiner) error {
 	router := echo.New()
 
+	extractor, err := ipExtractor(s.trustedProxies)
+	if err != nil {
+		return err
+	}
+	router.IPExtractor = extractor
+
 	router.Use(middleware.Recover())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.CORS())
 	router.Use(middleware.Secure())
 	router.Use(middleware.RemoveTrailingSlash())
 	router.Use(middleware.Gzip())
 	router.Use(log.NewEchoMiddleware)
 	router.Use(otelecho.Middleware(s.serviceName))
-	router.HTTPErrorHandler = telemetry.EchoHTTPErrorHandler(router)
+	router.HTTPErrorHandler = telemetry.EchoHTTPErrorHandler(handleErr)
 	router.Validator = validation.GetValidator()
 
 	router.Any(
@@ -55,32 +96,149 @@ func (s server) Start(log *logging.Logger, doms domains.DomainCombiner) error {
 	)
 
 	authHandler := AuthHandler{doms.AuthService()}
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/register", authHandler.Register)
 		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/login/2fa", authHandler.LoginTwoFactor)
 		authGroup.POST("/refresh", authHandler.Refresh)
-		authGroup.GET("/me", authHandler.Me, authHandler.MiddlewareUnpackAccess)
+		authGroup.GET("/me", authHandler.Me, authHandler.MiddlewareUnpackToken)
+		authGroup.PATCH("/me", authHandler.UpdateProfile, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.DELETE("/me", authHandler.DeleteAccount, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/logout", authHandler.Logout, authHandler.MiddlewareUnpackToken)
+		authGroup.POST("/logout-all", authHandler.LogoutAll, authHandler.MiddlewareUnpackToken)
+
+		authGroup.POST("/password/change", authHandler.ChangePassword, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/password/reset/request", authHandler.RequestPasswordReset)
+		authGroup.POST("/password/reset", authHandler.ResetPassword)
+
+		authGroup.POST("/2fa/enroll", authHandler.EnrollTwoFactor, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/2fa/confirm", authHandler.ConfirmTwoFactor, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/2fa/disable", authHandler.DisableTwoFactor, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+
+		authGroup.GET("/api-keys", authHandler.ReadAPIKeys, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/api-keys", authHandler.CreateAPIKey, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.DELETE("/api-keys/:id", authHandler.RevokeAPIKey, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+
+		authGroup.POST("/sellers/login/request", authHandler.RequestSellerLogin)
+		authGroup.POST("/sellers/login", authHandler.CompleteSellerLogin)
+		authGroup.GET("/sellers/login-requests", authHandler.ReadSellerLoginRequests, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/sellers/login-requests/:id/approve", authHandler.ApproveSellerLogin, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
+		authGroup.POST("/sellers/login-requests/:id/reject", authHandler.RejectSellerLogin, authHandler.MiddlewareUnpackToken, authHandler.MiddlewareOnlyOwners)
 	}
 
-	storesHandler := StoresHandler{doms.StoresService()}
-	storesGroup := router.Group("/stores", authHandler.MiddlewareUnpackAccess)
+	policiesHandler := PoliciesHandler{doms.PoliciesService()}
+	canReadStore := policiesHandler.StoreAccess(policies.ActionRead)
+	canManageStore := policiesHandler.StoreAccess(policies.ActionManage)
+	canReadCategory := policiesHandler.CategoryAccess(policies.ActionRead)
+	canManageCategory := policiesHandler.CategoryAccess(policies.ActionManage)
+	canReadItem := policiesHandler.ItemAccess(policies.ActionRead)
+	canManageItem := policiesHandler.ItemAccess(policies.ActionManage)
+	canReadWarehouse := policiesHandler.WarehouseAccess(policies.ActionRead)
+	canManageWarehouse := policiesHandler.WarehouseAccess(policies.ActionManage)
+	canReadSize := policiesHandler.SizeAccess(policies.ActionRead)
+	canManageSize := policiesHandler.SizeAccess(policies.ActionManage)
+
+	storesHandler := StoresHandler{doms.StoresService(), s.cursors}
+	storesGroup := router.Group("/stores", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("stores"))
 	{
-		storesGroup.GET("/:id", storesHandler.Read)
+		storesGroup.GET("/trash", storesHandler.Trash, authHandler.MiddlewareOnlyOwners)
+		storesGroup.GET("/:id", storesHandler.Read, canReadStore)
 		storesGroup.GET("", storesHandler.ReadBy)
 		storesGroup.POST("", storesHandler.Create)
-		storesGroup.PATCH("/:id", storesHandler.Update)
-		storesGroup.DELETE("/:id", storesHandler.Delete)
+		storesGroup.PATCH("/:id", storesHandler.Update, canManageStore)
+		storesGroup.DELETE("/:id", storesHandler.Delete, canManageStore)
+		storesGroup.POST("/:id/restore", storesHandler.Restore, canManageStore)
 	}
 
-	categoriesHandler := CategoriesHandler{doms.CategoriesService()}
-	categoriesGroup := router.Group("/categories", authHandler.MiddlewareUnpackAccess)
+	categoriesHandler := CategoriesHandler{doms.CategoriesService(), doms.PoliciesService(), s.cursors}
+	categoriesGroup := router.Group("/categories", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("categories"))
 	{
-		categoriesGroup.GET("/:id", categoriesHandler.Read)
+		categoriesGroup.GET("/trash", categoriesHandler.Trash, authHandler.MiddlewareOnlyOwners)
+		categoriesGroup.GET("/:id", categoriesHandler.Read, canReadCategory)
 		categoriesGroup.GET("", categoriesHandler.ReadBy)
 		categoriesGroup.POST("", categoriesHandler.Create)
-		categoriesGroup.PATCH("/:id", categoriesHandler.Update)
-		categoriesGroup.DELETE("/:id", categoriesHandler.Delete)
+		categoriesGroup.PATCH("/:id", categoriesHandler.Update, canManageCategory)
+		categoriesGroup.DELETE("/:id", categoriesHandler.Delete, canManageCategory)
+		categoriesGroup.POST("/:id/restore", categoriesHandler.Restore, canManageCategory)
+	}
+
+	itemsHandler := ItemsHandler{doms.ItemsService(), doms.PoliciesService()}
+	itemsGroup := router.Group("/items", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("items"))
+	{
+		itemsGroup.GET("/:id", itemsHandler.Read, canReadItem)
+		itemsGroup.GET("", itemsHandler.ReadBy)
+		itemsGroup.POST("", itemsHandler.Create)
+		itemsGroup.PATCH("/:id", itemsHandler.Update, canManageItem)
+		itemsGroup.DELETE("/:id", itemsHandler.Delete, canManageItem)
+	}
+
+	stockHandler := StockHandler{doms.StockService(), doms.PoliciesService()}
+	stockGroup := router.Group("/stock", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("stock"))
+	{
+		stockGroup.GET("/items/:id", stockHandler.ReadByItem, canReadItem)
+		stockGroup.GET("/warehouses/:id", stockHandler.ReadByWarehouse, canReadWarehouse)
+		stockGroup.GET("/items/:id/movements", stockHandler.ReadItemMovements, canReadItem)
+		stockGroup.GET("/:id/movements", stockHandler.ReadSizeMovements, canReadSize)
+		stockGroup.POST("/:id/movements", stockHandler.Move, canManageSize)
+		stockGroup.POST("", stockHandler.Create)
+		stockGroup.PATCH("/:id", stockHandler.Update, canManageSize)
+		stockGroup.DELETE("/:id", stockHandler.Delete, canManageSize)
+	}
+
+	warehousesHandler := WarehousesHandler{doms.WarehousesService()}
+	warehousesGroup := router.Group("/warehouses", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("warehouses"))
+	{
+		warehousesGroup.GET("/:id", warehousesHandler.Read, canReadWarehouse)
+		warehousesGroup.GET("", warehousesHandler.ReadBy)
+		warehousesGroup.POST("", warehousesHandler.Create, authHandler.MiddlewareOnlyOwners)
+		warehousesGroup.PATCH("/:id", warehousesHandler.Update, canManageWarehouse)
+		warehousesGroup.DELETE("/:id", warehousesHandler.Delete, canManageWarehouse)
+		warehousesGroup.POST("/:id/stores/:storeID", warehousesHandler.LinkStore, canManageWarehouse)
+		warehousesGroup.DELETE("/:id/stores/:storeID", warehousesHandler.UnlinkStore, canManageWarehouse)
+	}
+
+	transfersHandler := TransfersHandler{doms.TransfersService()}
+	transfersGroup := router.Group("/transfers", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("transfers"), authHandler.MiddlewareOnlyOwners)
+	{
+		transfersGroup.GET("/:id", transfersHandler.Read)
+		transfersGroup.GET("", transfersHandler.ReadBy)
+		transfersGroup.POST("", transfersHandler.Create)
+		transfersGroup.DELETE("/:id", transfersHandler.Delete)
+		transfersGroup.POST("/:id/ship", transfersHandler.Ship)
+		transfersGroup.POST("/:id/receive", transfersHandler.Receive)
+	}
+
+	salesHandler := SalesHandler{doms.SalesService(), doms.PoliciesService()}
+	salesGroup := router.Group("/sales", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("sales"))
+	{
+		salesGroup.GET("/:id", salesHandler.Read)
+		salesGroup.GET("", salesHandler.ReadBy)
+		salesGroup.POST("", salesHandler.Create)
+		salesGroup.GET("/:id/returns", salesHandler.ReadReturns)
+		salesGroup.POST("/:id/returns", salesHandler.Return)
+	}
+
+	sellersHandler := SellersHandler{doms.SellersService()}
+	sellersGroup := router.Group("/sellers", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("sellers"), authHandler.MiddlewareOnlyOwners)
+	{
+		sellersGroup.GET("/:id", sellersHandler.Read)
+		sellersGroup.GET("", sellersHandler.ReadBy)
+		sellersGroup.POST("", sellersHandler.Create)
+		sellersGroup.POST("/:id/activate", sellersHandler.Activate)
+		sellersGroup.POST("/:id/deactivate", sellersHandler.Deactivate)
+		sellersGroup.DELETE("/:id", sellersHandler.Delete)
+		sellersGroup.POST("/:id/stores/:storeID", sellersHandler.AssignStore)
+		sellersGroup.DELETE("/:id/stores/:storeID", sellersHandler.UnassignStore)
+	}
+
+	auditHandler := AuditHandler{doms.AuditService()}
+	auditGroup := router.Group("/audit", authHandler.MiddlewareUnpackAccess, authHandler.MiddlewareScope("audit"), authHandler.MiddlewareOnlyOwners)
+	{
+		auditGroup.GET("", auditHandler.ReadBy)
 	}
 
 	s.srvr.Handler = router
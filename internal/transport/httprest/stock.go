@@ -0,0 +1,222 @@
+package httprest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stock"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
+)
+
+type (
+	StockReadByRequest struct {
+		// Pagination
+		PageNumber uint64 `query:"pageNumber"`
+		PageSize   uint   `query:"pageSize"`
+	}
+)
+
+type StockHandler struct {
+	stockService    stock.Service
+	policiesService policies.Service
+}
+
+func (h StockHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(stock.CreateInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	err := h.policiesService.AuthorizeItem(ctx.Request().Context(), session, req.ItemID, policies.ActionManage)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	err = h.policiesService.AuthorizeWarehouse(ctx.Request().Context(), session, req.WarehouseID, policies.ActionManage)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	size, err := h.stockService.Create(ctx.Request().Context(), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, size)
+}
+
+// ReadByItem lists stock of a single item across all warehouses.
+func (h StockHandler) ReadByItem(ctx echo.Context) error {
+	req := new(StockReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := h.mapToReadByInput(req)
+	in.ItemID.Set(ctx.Param("id"))
+
+	page, err := h.stockService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+// ReadByWarehouse lists stock of every item in a single warehouse.
+func (h StockHandler) ReadByWarehouse(ctx echo.Context) error {
+	req := new(StockReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := h.mapToReadByInput(req)
+	in.WarehouseID.Set(ctx.Param("id"))
+
+	page, err := h.stockService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h StockHandler) Update(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return respondErr(ctx, errSizeID)
+	}
+
+	req := make(echo.Map)
+	if err := ctx.Bind(&req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := stock.UpdateInput{}
+	if _, ok := req["quantity"]; ok {
+		return respondErr(ctx, apperr.NewField(apperr.KindValidation, "quantity", "stock.quantityReadOnly", "количество меняется только через движения товара"))
+	}
+	if v, ok := req["cost"]; ok {
+		tmp, ok := v.(float64)
+		if !ok {
+			return respondErr(ctx, errFieldType("cost", "числом"))
+		}
+		in.Cost.Set(tmp)
+	}
+
+	size, err := h.stockService.Update(ctx.Request().Context(), id, in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, size)
+}
+
+func (h StockHandler) Delete(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return respondErr(ctx, errSizeID)
+	}
+
+	if err := h.stockService.Delete(ctx.Request().Context(), id); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h StockHandler) Move(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return respondErr(ctx, errSizeID)
+	}
+
+	req := new(stock.MoveInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	req.SizeID = id
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	movement, err := h.stockService.Move(ctx.Request().Context(), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, movement)
+}
+
+// ReadSizeMovements shows the journal of a single size.
+func (h StockHandler) ReadSizeMovements(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return respondErr(ctx, errSizeID)
+	}
+
+	req := new(StockReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := h.mapToMovementsReadByInput(req)
+	in.SizeID.Set(id)
+
+	page, err := h.stockService.ReadMovements(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+// ReadItemMovements shows the journal of every size of an item.
+func (h StockHandler) ReadItemMovements(ctx echo.Context) error {
+	req := new(StockReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := h.mapToMovementsReadByInput(req)
+	in.ItemID.Set(ctx.Param("id"))
+
+	page, err := h.stockService.ReadMovements(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h StockHandler) mapToMovementsReadByInput(req *StockReadByRequest) stock.MovementsReadByInput {
+	in := stock.MovementsReadByInput{}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	return in
+}
+
+func (h StockHandler) mapToReadByInput(req *StockReadByRequest) stock.ReadByInput {
+	in := stock.ReadByInput{}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	return in
+}
@@ -1,11 +1,11 @@
 package httprest
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/policies"
 	"github.com/rasulov-emirlan/accounter-backend/internal/domains/stores"
 )
 
@@ -16,12 +16,13 @@ type (
 	}
 
 	StoresReadRequest struct {
-		Text    string `query:"text"`
-		OwnerID string `query:"ownerID"`
+		Text string `query:"text"`
 
-		// Pagination
+		// Pagination, with Cursor PageNumber and sorting are ignored
 		PageNumber uint64 `query:"pageNumber"`
 		PageSize   uint   `query:"pageSize"`
+		Cursor     string `query:"cursor"`
+		WithTotal  bool   `query:"withTotal"`
 
 		// Sorting
 		SortBy    string `query:"sortBy"`    // name, createdAt
@@ -31,21 +32,25 @@ type (
 
 type StoresHandler struct {
 	storesService stores.Service
+	cursors       cursorCodec
 }
 
 func (h StoresHandler) Create(ctx echo.Context) error {
 	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
 	if !ok {
-		return respondErr(ctx, http.StatusUnauthorized, errors.New("unauthorized"))
+		return respondErr(ctx, errUnauthorized)
+	}
+	if err := policies.CreateStore(session); err != nil {
+		return respondErr(ctx, err)
 	}
 
 	req := new(StoresCreateRequest)
 	if err := ctx.Bind(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	if err := ctx.Validate(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	store, err := h.storesService.Create(ctx.Request().Context(), stores.CreateInput{
@@ -54,7 +59,7 @@ func (h StoresHandler) Create(ctx echo.Context) error {
 		OwnerID:     session.UserID,
 	})
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, store)
@@ -63,33 +68,46 @@ func (h StoresHandler) Create(ctx echo.Context) error {
 func (h StoresHandler) Read(ctx echo.Context) error {
 	id := ctx.Param("id")
 	if id == "" {
-		return respondErr(ctx, http.StatusBadRequest, errors.New("id is required"))
+		return respondErr(ctx, errIDRequired)
 	}
 
 	in := stores.ReadByInput{}
 	in.ID.Set(id)
-	store, err := h.storesService.ReadBy(ctx.Request().Context(), in)
+	page, err := h.storesService.ReadBy(ctx.Request().Context(), in)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
-	return ctx.JSON(http.StatusOK, store)
+	return ctx.JSON(http.StatusOK, page.Items)
 }
 
 func (h StoresHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
 	req := new(StoresReadRequest)
 	if err := ctx.Bind(req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	in := stores.ReadByInput{}
 
+	// everyone sees only stores they may read
+	switch session.Role {
+	case auth.RoleOwner:
+		in.OwnerID.Set(session.UserID)
+	case auth.RoleSeller:
+		in.OwnerID.Set(session.OwnerID)
+		in.IDs.Set(session.StoreIDs)
+	default:
+		return respondErr(ctx, policies.ErrForbidden)
+	}
+
 	if req.Text != "" {
 		in.Text.Set(req.Text)
 	}
-	if req.OwnerID != "" {
-		in.OwnerID.Set(req.OwnerID)
-	}
 	if req.PageNumber != 0 {
 		in.PageNumber.Set(req.PageNumber)
 	}
@@ -102,19 +120,31 @@ func (h StoresHandler) ReadBy(ctx echo.Context) error {
 	if req.SortOrder != "" {
 		in.SortOrder.Set(req.SortOrder)
 	}
+	if req.Cursor != "" {
+		cursor, err := h.cursors.Decode(req.Cursor)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+		in.Cursor.Set(cursor)
+	}
+	if req.WithTotal {
+		in.WithTotal.Set(true)
+	}
 
-	store, err := h.storesService.ReadBy(ctx.Request().Context(), in)
+	page, err := h.storesService.ReadBy(ctx.Request().Context(), in)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
-	return ctx.JSON(http.StatusOK, store)
+	res := newListResponse(page)
+	res.NextCursor, res.PrevCursor = h.cursors.encodeOpt(page.Next), h.cursors.encodeOpt(page.Prev)
+	return ctx.JSON(http.StatusOK, res)
 }
 
 func (h StoresHandler) Update(ctx echo.Context) error {
 	req := make(map[string]any)
 	if err := ctx.Bind(&req); err != nil {
-		return respondErr(ctx, http.StatusBadRequest, err)
+		return respondErr(ctx, err)
 	}
 
 	in := h.mapToUpdateInput(req)
@@ -122,7 +152,7 @@ func (h StoresHandler) Update(ctx echo.Context) error {
 
 	s, err := h.storesService.Update(ctx.Request().Context(), id, in)
 	if err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, s)
@@ -132,7 +162,57 @@ func (h StoresHandler) Delete(ctx echo.Context) error {
 	id := ctx.Param("id")
 
 	if err := h.storesService.Delete(ctx.Request().Context(), id); err != nil {
-		return respondErr(ctx, http.StatusInternalServerError, err)
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Trash lists deleted stores of the owner, see MiddlewareOnlyOwners.
+func (h StoresHandler) Trash(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(StoresReadRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := stores.ReadByInput{}
+	in.OwnerID.Set(session.UserID)
+	in.Deleted.Set(true)
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	if req.Cursor != "" {
+		cursor, err := h.cursors.Decode(req.Cursor)
+		if err != nil {
+			return respondErr(ctx, err)
+		}
+		in.Cursor.Set(cursor)
+	}
+	if req.WithTotal {
+		in.WithTotal.Set(true)
+	}
+
+	page, err := h.storesService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	res := newListResponse(page)
+	res.NextCursor, res.PrevCursor = h.cursors.encodeOpt(page.Next), h.cursors.encodeOpt(page.Prev)
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h StoresHandler) Restore(ctx echo.Context) error {
+	if err := h.storesService.Restore(ctx.Request().Context(), ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
 	}
 
 	return ctx.NoContent(http.StatusOK)
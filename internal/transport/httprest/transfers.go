@@ -0,0 +1,163 @@
+package httprest
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/transfers"
+)
+
+type (
+	TransfersCreateRequest struct {
+		SourceWarehouseID      string                      `json:"sourceWarehouseID" validate:"required,uuid4"`
+		DestinationWarehouseID string                      `json:"destinationWarehouseID" validate:"required,uuid4"`
+		Comment                string                      `json:"comment" validate:"max=500"`
+		Lines                  []transfers.CreateLineInput `json:"lines" validate:"required,min=1,dive"`
+	}
+
+	TransfersReadByRequest struct {
+		WarehouseID string `query:"warehouseID"`
+		Status      string `query:"status"`
+
+		// Pagination
+		PageNumber uint64 `query:"pageNumber"`
+		PageSize   uint   `query:"pageSize"`
+	}
+)
+
+type TransfersHandler struct {
+	transfersService transfers.Service
+}
+
+func (h TransfersHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(TransfersCreateRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	transfer, err := h.transfersService.Create(ctx.Request().Context(), transfers.CreateInput{
+		OwnerID:                session.UserID,
+		SourceWarehouseID:      req.SourceWarehouseID,
+		DestinationWarehouseID: req.DestinationWarehouseID,
+		Comment:                req.Comment,
+		Lines:                  req.Lines,
+	})
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, transfer)
+}
+
+func (h TransfersHandler) Read(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	in := transfers.ReadByInput{}
+	in.ID.Set(ctx.Param("id"))
+	in.OwnerID.Set(session.UserID)
+
+	page, err := h.transfersService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	if len(page.Items) == 0 {
+		return respondErr(ctx, transfers.ErrNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, page.Items[0])
+}
+
+func (h TransfersHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(TransfersReadByRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := transfers.ReadByInput{}
+	in.OwnerID.Set(session.UserID)
+	if req.WarehouseID != "" {
+		in.WarehouseID.Set(req.WarehouseID)
+	}
+	if req.Status != "" {
+		in.Status.Set(req.Status)
+	}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+
+	page, err := h.transfersService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h TransfersHandler) Delete(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.transfersService.Delete(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h TransfersHandler) Ship(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	transfer, err := h.transfersService.Ship(ctx.Request().Context(), session.UserID, ctx.Param("id"))
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, transfer)
+}
+
+func (h TransfersHandler) Receive(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(transfers.ReceiveInput)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	transfer, err := h.transfersService.Receive(ctx.Request().Context(), session.UserID, ctx.Param("id"), *req)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, transfer)
+}
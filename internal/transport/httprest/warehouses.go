@@ -0,0 +1,201 @@
+package httprest
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/auth"
+	"github.com/rasulov-emirlan/accounter-backend/internal/domains/warehouses"
+)
+
+type (
+	WarehousesCreateRequest struct {
+		Name        string `json:"name" validate:"required,min=3"`
+		Description string `json:"description"`
+	}
+
+	WarehousesReadRequest struct {
+		Text    string `query:"text"`
+		StoreID string `query:"storeID"`
+
+		// Pagination
+		PageNumber uint64 `query:"pageNumber"`
+		PageSize   uint   `query:"pageSize"`
+
+		// Sorting
+		SortBy    string `query:"sortBy"`    // name, createdAt
+		SortOrder string `query:"sortOrder"` // asc, desc
+	}
+)
+
+type WarehousesHandler struct {
+	warehousesService warehouses.Service
+}
+
+func (h WarehousesHandler) Create(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(WarehousesCreateRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+	if err := ctx.Validate(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	warehouse, err := h.warehousesService.Create(ctx.Request().Context(), warehouses.CreateInput{
+		OwnerID:     session.UserID,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, warehouse)
+}
+
+func (h WarehousesHandler) Read(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	id := ctx.Param("id")
+	if id == "" {
+		return respondErr(ctx, errIDRequired)
+	}
+
+	in := warehouses.ReadByInput{}
+	in.ID.Set(id)
+	in.OwnerID.Set(session.Owner())
+	page, err := h.warehousesService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+	if len(page.Items) == 0 {
+		return respondErr(ctx, warehouses.ErrNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, page.Items[0])
+}
+
+func (h WarehousesHandler) ReadBy(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := new(WarehousesReadRequest)
+	if err := ctx.Bind(req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	// everyone sees only warehouses of their owner
+	in := warehouses.ReadByInput{}
+	in.OwnerID.Set(session.Owner())
+	if req.Text != "" {
+		in.Text.Set(req.Text)
+	}
+	if req.StoreID != "" {
+		in.StoreID.Set(req.StoreID)
+	}
+	if req.PageNumber != 0 {
+		in.PageNumber.Set(req.PageNumber)
+	}
+	if req.PageSize != 0 {
+		in.PageSize.Set(req.PageSize)
+	}
+	if req.SortBy != "" {
+		in.SortBy.Set(req.SortBy)
+	}
+	if req.SortOrder != "" {
+		in.SortOrder.Set(req.SortOrder)
+	}
+
+	page, err := h.warehousesService.ReadBy(ctx.Request().Context(), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newListResponse(page))
+}
+
+func (h WarehousesHandler) Update(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	req := make(echo.Map)
+	if err := ctx.Bind(&req); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	in := warehouses.UpdateInput{}
+	if v, ok := req["name"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("name", "строкой"))
+		}
+		in.Name.Set(tmp)
+	}
+	if v, ok := req["description"]; ok {
+		tmp, ok := v.(string)
+		if !ok {
+			return respondErr(ctx, errFieldType("description", "строкой"))
+		}
+		in.Description.Set(tmp)
+	}
+
+	warehouse, err := h.warehousesService.Update(ctx.Request().Context(), session.UserID, ctx.Param("id"), in)
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, warehouse)
+}
+
+func (h WarehousesHandler) Delete(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	if err := h.warehousesService.Delete(ctx.Request().Context(), session.UserID, ctx.Param("id")); err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h WarehousesHandler) LinkStore(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	err := h.warehousesService.LinkStore(ctx.Request().Context(), session.UserID, ctx.Param("id"), ctx.Param("storeID"))
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h WarehousesHandler) UnlinkStore(ctx echo.Context) error {
+	session, ok := ctx.Get(AuthSessionContextName).(auth.AccessKey)
+	if !ok {
+		return respondErr(ctx, errUnauthorized)
+	}
+
+	err := h.warehousesService.UnlinkStore(ctx.Request().Context(), session.UserID, ctx.Param("id"), ctx.Param("storeID"))
+	if err != nil {
+		return respondErr(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,91 @@
+// Package apperr describes errors that are safe to show to clients.
+// Every error has a kind, transports map kinds to their status codes.
+package apperr
+
+import "errors"
+
+type Kind int
+
+const (
+	KindInternal        Kind = iota // something broke on our side
+	KindInvalid                     // request can not be understood, like a malformed query
+	KindUnauthorized                // client is not who they say they are
+	KindForbidden                   // client is known but may not do this
+	KindNotFound                    // resource does not exist or is hidden from the client
+	KindConflict                    // resource is in a state that does not allow this
+	KindGone                        // resource existed but expired
+	KindValidation                  // request is well formed, but values in it are not valid
+	KindTooManyRequests             // client has to wait before trying again
+	KindUnavailable                 // feature is turned off or its dependency is down
+)
+
+var codes = map[Kind]string{
+	KindInternal:        "internal",
+	KindInvalid:         "bad_request",
+	KindUnauthorized:    "unauthorized",
+	KindForbidden:       "forbidden",
+	KindNotFound:        "not_found",
+	KindConflict:        "conflict",
+	KindGone:            "gone",
+	KindValidation:      "validation_failed",
+	KindTooManyRequests: "too_many_requests",
+	KindUnavailable:     "unavailable",
+}
+
+// Code is a machine readable name of the kind, clients may switch on it.
+func (k Kind) Code() string {
+	return codes[k]
+}
+
+type (
+	Error struct {
+		Kind Kind
+		// Key names this exact error, like "stores.notFound". Clients
+		// translate messages by it.
+		Key string
+		// Message is in russian, it is shown when client does not know Key.
+		Message string
+		Fields  []FieldError
+	}
+
+	// FieldError tells what is wrong with one field of a request.
+	FieldError struct {
+		Field   string `json:"field"`
+		Key     string `json:"messageKey"`
+		Message string `json:"message"`
+	}
+)
+
+func New(kind Kind, key, message string) *Error {
+	return &Error{Kind: kind, Key: key, Message: message}
+}
+
+// NewField is an error about a single field, message of the error is
+// the message of the field.
+func NewField(kind Kind, field, key, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Key:     key,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Key: key, Message: message}},
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// As finds an *Error in the chain of err.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf is KindInternal for errors that are not *Error.
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -55,11 +55,13 @@ func StartJaegerTraceProvider(cfg config.Config, cleaner *shutdown.Scheduler) er
 	return nil
 }
 
-func EchoHTTPErrorHandler(router *echo.Echo) func(err error, c echo.Context) {
+// EchoHTTPErrorHandler records the error in the span of the request and
+// lets next respond.
+func EchoHTTPErrorHandler(next echo.HTTPErrorHandler) echo.HTTPErrorHandler {
 	return func(err error, c echo.Context) {
 		ctx := c.Request().Context()
 		trace.SpanFromContext(ctx).RecordError(err)
 
-		router.DefaultHTTPErrorHandler(err, c)
+		next(err, c)
 	}
 }
@@ -1,14 +1,22 @@
 package validation
 
 import (
+	"reflect"
+	"strings"
 	"sync"
 	"unicode"
 
 	russian "github.com/go-playground/locales/ru"
 	ut "github.com/go-playground/universal-translator"
 	vLib "github.com/go-playground/validator/v10"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+	"github.com/rasulov-emirlan/accounter-backend/pkg/apperr"
 )
 
+// ErrInvalid is what Validate returns, with a field error for every
+// field that failed. Keys of field errors are "validation." + tag.
+var ErrInvalid = apperr.New(apperr.KindValidation, "validation.failed", "данные не прошли проверку")
+
 type Validator struct {
 	v     *vLib.Validate
 	trans ut.Translator
@@ -32,6 +40,17 @@ func GetValidator() *Validator {
 			panic("could not get translator")
 		}
 		v := vLib.New()
+		// fields are named like clients send them
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || name == "" {
+				return f.Name
+			}
+			return name
+		})
+		if err := ruTranslations.RegisterDefaultTranslations(v, trans); err != nil {
+			panic("could not register translations")
+		}
 
 		singleton = &Validator{
 			v:     v,
@@ -70,41 +89,57 @@ func (v Validator) RegisterValidation(tag string, check func(Field) bool, errMsg
 // It validates it according to the tags on the struct.
 // You can lookup available tags [here].
 // Or create custom tags by using RegisterValidation.
+// Failed fields are returned as a copy of ErrInvalid.
 //
 // [here]: https://github.com/go-playground/validator
 func (v Validator) Validate(value any) error {
-	return v.v.Struct(value)
+	err := v.v.Struct(value)
+	values, ok := err.(vLib.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	res := *ErrInvalid
+	res.Fields = make([]apperr.FieldError, 0, len(values))
+	for _, vv := range values {
+		res.Fields = append(res.Fields, apperr.FieldError{
+			Field:   vv.Field(),
+			Key:     "validation." + vv.Tag(),
+			Message: vv.Translate(v.trans),
+		})
+	}
+	return &res
 }
 
 // UnpackErrors unpacks the error returned by ValidateStruct into a slice of strings.
 func (v Validator) UnpackErrors(e error) []string {
-	values, ok := e.(vLib.ValidationErrors)
-	if !ok {
+	values, ok := apperr.As(e)
+	if !ok || len(values.Fields) == 0 {
 		return nil
 	}
-	errs := make([]string, 0, len(values))
-	for _, vv := range values {
-		errs = append(errs, vv.Translate(v.trans))
+	errs := make([]string, 0, len(values.Fields))
+	for _, vv := range values.Fields {
+		errs = append(errs, vv.Message)
 	}
 	return errs
 }
 
 func (v Validator) Mappify(e error) map[string]string {
 
-	values, ok := e.(vLib.ValidationErrors)
-	if !ok {
+	values, ok := apperr.As(e)
+	if !ok || len(values.Fields) == 0 {
 		return nil
 	}
 
 	res := make(map[string]string)
 
-	for _, vv := range values {
-		key := vv.StructField()
+	for _, vv := range values.Fields {
+		key := vv.Field
 		if len(key) != 0 {
 			// make first character lower case
 			key = string(unicode.ToLower(rune(key[0]))) + key[1:]
 		}
-		res[key] = vv.Translate(v.trans)
+		res[key] = vv.Message
 	}
 
 	return res